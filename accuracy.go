@@ -0,0 +1,106 @@
+package lru
+
+import (
+	"container/list"
+)
+
+// EvictionAccuracy measures how closely a cache's approximate eviction
+// follows exact LRU order, based on a sample of its keys.
+type EvictionAccuracy struct {
+	// Evictions is the number of sampled keys evicted to make room for
+	// others.
+	Evictions uint64
+	// Exact is the number of those an exact LRU would have evicted first
+	// among the sampled keys.
+	Exact uint64
+	// MeanRecency is the average recency of evicted sampled keys relative
+	// to the other sampled keys, from 0 for the least recently used to 1
+	// for the most recently used.  An exact LRU scores 0.
+	MeanRecency float64
+}
+
+// accuracyShadow keeps a sample of a cache's keys in exact LRU order, to
+// compare the approximate LRU's choice of victims against.
+type accuracyShadow[K comparable] struct {
+	// every sampleRate-th new key is sampled
+	sampleRate int
+	seen       int
+	order      *list.List // of K, most recently used first
+	elems      map[K]*list.Element
+
+	evictions  uint64
+	exact      uint64
+	recencySum float64
+}
+
+func newAccuracyShadow[K comparable](sampleRate int) *accuracyShadow[K] {
+	return &accuracyShadow[K]{
+		sampleRate: sampleRate,
+		order:      list.New(),
+		elems:      make(map[K]*list.Element),
+	}
+}
+
+// added records that key was added to the cache.
+func (s *accuracyShadow[K]) added(key K) {
+	if elem, ok := s.elems[key]; ok {
+		s.order.MoveToFront(elem)
+		return
+	}
+	s.seen++
+	if s.seen%s.sampleRate == 0 {
+		s.elems[key] = s.order.PushFront(key)
+	}
+}
+
+// used records that key was looked up.
+func (s *accuracyShadow[K]) used(key K) {
+	if elem, ok := s.elems[key]; ok {
+		s.order.MoveToFront(elem)
+	}
+}
+
+// removed records that key left the cache, scoring the approximate LRU's
+// choice if it was evicted.
+func (s *accuracyShadow[K]) removed(key K, evicted bool) {
+	elem, ok := s.elems[key]
+	if !ok {
+		return
+	}
+	if evicted {
+		// count how many sampled keys an exact LRU would have evicted
+		// first
+		older := 0
+		for e := s.order.Back(); e != elem; e = e.Prev() {
+			older++
+		}
+		s.evictions++
+		if older == 0 {
+			s.exact++
+		}
+		if n := s.order.Len(); n > 1 {
+			s.recencySum += float64(older) / float64(n-1)
+		}
+	}
+	s.order.Remove(elem)
+	delete(s.elems, key)
+}
+
+// EvictionAccuracy reports how closely the cache's approximate eviction
+// has followed exact LRU order, as sampled when Options.AccuracySampleRate
+// is set.  It returns the zero value otherwise.
+func (c *Cache[K, V]) EvictionAccuracy() (accuracy EvictionAccuracy) {
+	c.lock.Lock()
+	defer c.unlock()
+
+	if c.ext == nil || c.ext.accuracy == nil {
+		return accuracy
+	}
+	s := c.ext.accuracy
+	accuracy.Evictions = s.evictions
+	accuracy.Exact = s.exact
+	if s.evictions > 0 {
+		accuracy.MeanRecency = s.recencySum / float64(s.evictions)
+	}
+	return accuracy
+}
@@ -0,0 +1,40 @@
+package lru
+
+import (
+	"testing"
+)
+
+func TestLRUEvictionAccuracy(t *testing.T) {
+	l, err := NewWithOptions[int, int](128, Options[int, int]{
+		AccuracySampleRate: 1,
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for i := 0; i < 10000; i++ {
+		l.Add(i, i)
+		if i%3 == 0 {
+			l.Get(i / 2)
+		}
+	}
+	accuracy := l.EvictionAccuracy()
+	if accuracy.Evictions != 10000-128 {
+		t.Errorf("expected every eviction to be sampled: %+v", accuracy)
+	}
+	if accuracy.Exact == 0 || accuracy.Exact > accuracy.Evictions {
+		t.Errorf("bad exact count: %+v", accuracy)
+	}
+	// probing 8 entries picks a victim among the oldest ninth on average
+	if accuracy.MeanRecency <= 0 || accuracy.MeanRecency > 0.2 {
+		t.Errorf("implausible mean recency: %+v", accuracy)
+	}
+	if n := len(l.ext.accuracy.elems); n != l.Len() {
+		t.Errorf("shadow out of sync: %d keys, not %d", n, l.Len())
+	}
+
+	plain, _ := New[int, int](8)
+	if accuracy := plain.EvictionAccuracy(); accuracy != (EvictionAccuracy{}) {
+		t.Errorf("expected no accuracy without sampling: %+v", accuracy)
+	}
+}
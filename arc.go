@@ -0,0 +1,246 @@
+package lru
+
+import (
+	"errors"
+
+	"github.com/bpowers/approx-lru/internal/approxlru"
+)
+
+// ARCCache is a thread-safe fixed size Adaptive Replacement Cache (ARC).
+// ARC is an enhancement over the standard LRU cache in that it tracks both
+// frequency and recency of use.  This avoids a burst in access to new
+// entries from evicting the frequently used older entries.  It adds some
+// additional tracking overhead to a standard LRU cache, computationally it
+// is roughly 2x the cost, and the extra memory overhead is linear with the
+// size of the cache.  Like Cache, each of its lists is an approximate LRU.
+type ARCCache[K comparable, V any] struct {
+	lock mutex
+	size int // size is the total capacity of the cache
+	p    int // p is the dynamic preference towards t1 or t2
+
+	t1 approxlru.LRU[K, V]        // t1 is the LRU for recently accessed items
+	b1 approxlru.LRU[K, struct{}] // b1 is the LRU for evictions from t1
+
+	t2 approxlru.LRU[K, V]        // t2 is the LRU for frequently accessed items
+	b2 approxlru.LRU[K, struct{}] // b2 is the LRU for evictions from t2
+}
+
+// NewARC creates an ARC of the given size.
+func NewARC[K comparable, V any](size int) (*ARCCache[K, V], error) {
+	if size <= 0 {
+		return nil, errors.New("invalid size")
+	}
+
+	t1, err := approxlru.NewLRU[K, V](size, nil)
+	if err != nil {
+		return nil, err
+	}
+	b1, err := approxlru.NewLRU[K, struct{}](size, nil)
+	if err != nil {
+		return nil, err
+	}
+	t2, err := approxlru.NewLRU[K, V](size, nil)
+	if err != nil {
+		return nil, err
+	}
+	b2, err := approxlru.NewLRU[K, struct{}](size, nil)
+	if err != nil {
+		return nil, err
+	}
+	c := &ARCCache[K, V]{
+		size: size,
+		t1:   *t1,
+		b1:   *b1,
+		t2:   *t2,
+		b2:   *b2,
+	}
+	return c, nil
+}
+
+// Get looks up a key's value from the cache.
+func (c *ARCCache[K, V]) Get(key K) (value V, ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	// If the value is contained in t1 (recent), then promote it to t2
+	// (frequent)
+	if value, ok = c.t1.RemoveAndGet(key); ok {
+		c.t2.Add(key, value)
+		return value, ok
+	}
+
+	// Check if the value is contained in t2 (frequent)
+	return c.t2.Get(key)
+}
+
+// Add adds a value to the cache.  Returns true if an eviction occurred.
+func (c *ARCCache[K, V]) Add(key K, value V) (evicted bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	// Check if the value is contained in t1 (recent), and potentially
+	// promote it to frequent t2
+	if c.t1.Contains(key) {
+		c.t1.Remove(key)
+		c.t2.Add(key, value)
+		return false
+	}
+
+	// Check if the value is already in t2 (frequent) and update it
+	if c.t2.Contains(key) {
+		c.t2.Add(key, value)
+		return false
+	}
+
+	// Check if this value was recently evicted as part of the recently
+	// used list
+	if c.b1.Contains(key) {
+		// t1 set is too small, increase P appropriately
+		delta := 1
+		b1Len := c.b1.Len()
+		b2Len := c.b2.Len()
+		if b2Len > b1Len {
+			delta = b2Len / b1Len
+		}
+		if c.p+delta >= c.size {
+			c.p = c.size
+		} else {
+			c.p += delta
+		}
+
+		// Potentially need to make room in the cache
+		if c.t1.Len()+c.t2.Len() >= c.size {
+			evicted = c.replace(false)
+		}
+
+		// Remove from b1
+		c.b1.Remove(key)
+
+		// Add the key to the frequently used list
+		c.t2.Add(key, value)
+		return evicted
+	}
+
+	// Check if this value was recently evicted as part of the frequently
+	// used list
+	if c.b2.Contains(key) {
+		// t2 set is too small, decrease P appropriately
+		delta := 1
+		b1Len := c.b1.Len()
+		b2Len := c.b2.Len()
+		if b1Len > b2Len {
+			delta = b1Len / b2Len
+		}
+		if delta >= c.p {
+			c.p = 0
+		} else {
+			c.p -= delta
+		}
+
+		// Potentially need to make room in the cache
+		if c.t1.Len()+c.t2.Len() >= c.size {
+			evicted = c.replace(true)
+		}
+
+		// Remove from b2
+		c.b2.Remove(key)
+
+		// Add the key to the frequently used list
+		c.t2.Add(key, value)
+		return evicted
+	}
+
+	// Potentially need to make room in the cache
+	if c.t1.Len()+c.t2.Len() >= c.size {
+		evicted = c.replace(false)
+	}
+
+	// Keep the size of the ghost buffers trim
+	if c.b1.Len() > c.size-c.p {
+		c.b1.RemoveOldest()
+	}
+	if c.b2.Len() > c.p {
+		c.b2.RemoveOldest()
+	}
+
+	// Add to the recently seen list
+	c.t1.Add(key, value)
+	return evicted
+}
+
+// replace is used to adaptively evict from either t1 or t2 based on the
+// current learned value of p, returning whether an entry was evicted.
+// c.lock must be held.
+func (c *ARCCache[K, V]) replace(b2ContainsKey bool) bool {
+	t1Len := c.t1.Len()
+	if t1Len > 0 && (t1Len > c.p || (t1Len == c.p && b2ContainsKey)) {
+		if key, _, ok := c.t1.RemoveOldest(); ok {
+			c.b1.Add(key, struct{}{})
+			return true
+		}
+	}
+	if key, _, ok := c.t2.RemoveOldest(); ok {
+		c.b2.Add(key, struct{}{})
+		return true
+	}
+	return false
+}
+
+// Contains checks if a key is in the cache, without updating the
+// recent-ness.
+func (c *ARCCache[K, V]) Contains(key K) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.t1.Contains(key) || c.t2.Contains(key)
+}
+
+// Peek returns the key value (or undefined if not found) without updating
+// the "recently used"-ness of the key.
+func (c *ARCCache[K, V]) Peek(key K) (value V, ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if value, ok = c.t1.Peek(key); ok {
+		return value, ok
+	}
+	return c.t2.Peek(key)
+}
+
+// Remove removes the provided key from the cache.
+func (c *ARCCache[K, V]) Remove(key K) (present bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.t1.Remove(key) {
+		return true
+	}
+	if c.t2.Remove(key) {
+		return true
+	}
+	if c.b1.Remove(key) {
+		return false
+	}
+	c.b2.Remove(key)
+	return false
+}
+
+// Purge is used to completely clear the cache.
+func (c *ARCCache[K, V]) Purge() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.t1.Purge()
+	c.t2.Purge()
+	c.b1.Purge()
+	c.b2.Purge()
+	c.p = 0
+}
+
+// Len returns the number of items in the cache.
+func (c *ARCCache[K, V]) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.t1.Len() + c.t2.Len()
+}
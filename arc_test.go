@@ -0,0 +1,130 @@
+package lru
+
+import (
+	"testing"
+)
+
+func TestARC(t *testing.T) {
+	l, err := NewARC[int, int](128)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for i := 0; i < 256; i++ {
+		l.Add(i, i)
+	}
+	if l.Len() != 128 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+	for i := 0; i < 256; i++ {
+		if v, ok := l.Peek(i); ok && v != i {
+			t.Fatalf("bad key %v: %v", i, v)
+		}
+	}
+
+	for i := 0; i < 256; i++ {
+		if l.Contains(i) && !l.Remove(i) {
+			t.Fatalf("%v should have been removed", i)
+		}
+	}
+	if l.Len() != 0 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+
+	l.Add(1, 1)
+	l.Purge()
+	if l.Len() != 0 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+	if _, ok := l.Get(1); ok {
+		t.Fatalf("should contain nothing")
+	}
+
+	if _, err := NewARC[int, int](0); err == nil {
+		t.Errorf("expected zero sizes to be rejected")
+	}
+}
+
+// Test that entries move from t1 to t2
+func TestARC_Promotion(t *testing.T) {
+	l, err := NewARC[int, int](128)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for i := 0; i < 128; i++ {
+		l.Add(i, i)
+	}
+	if n := l.t1.Len(); n != 128 {
+		t.Fatalf("bad: %d", n)
+	}
+
+	// Get moves entries to t2
+	for i := 0; i < 32; i++ {
+		if v, ok := l.Get(i); !ok || v != i {
+			t.Fatalf("bad key %v: %v, %v", i, v, ok)
+		}
+	}
+	// so does adding again
+	for i := 32; i < 64; i++ {
+		l.Add(i, i)
+	}
+	if n := l.t1.Len(); n != 64 {
+		t.Fatalf("bad: %d", n)
+	}
+	if n := l.t2.Len(); n != 64 {
+		t.Fatalf("bad: %d", n)
+	}
+}
+
+// Test that ghost hits adapt p
+func TestARC_Adaptive(t *testing.T) {
+	l, err := NewARC[int, int](4)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Fill t1, then move two entries to t2
+	for i := 0; i < 4; i++ {
+		l.Add(i, i)
+	}
+	l.Get(0)
+	l.Get(1)
+	if n := l.t2.Len(); n != 2 {
+		t.Fatalf("bad: %d", n)
+	}
+
+	// Evict from t1 into b1; lists this small are probed exhaustively,
+	// so eviction is exact
+	l.Add(4, 4)
+	if !l.b1.Contains(2) {
+		t.Fatalf("expected 2 to be evicted to b1")
+	}
+
+	// A b1 hit grows p and lands in t2
+	l.Add(2, 2)
+	if l.p != 1 {
+		t.Errorf("bad p: %d", l.p)
+	}
+	if !l.t2.Contains(2) || l.b1.Contains(2) {
+		t.Errorf("expected 2 to be promoted to t2")
+	}
+
+	// With t1 at its target size, room is made by evicting from t2
+	l.Add(5, 5)
+	if !l.b2.Contains(0) {
+		t.Fatalf("expected 0 to be evicted to b2")
+	}
+
+	// A b2 hit shrinks p
+	l.Add(0, 0)
+	if l.p != 0 {
+		t.Errorf("bad p: %d", l.p)
+	}
+	if !l.t2.Contains(0) {
+		t.Errorf("expected 0 to be in t2")
+	}
+	if l.Len() != 4 {
+		t.Errorf("bad len: %v", l.Len())
+	}
+}
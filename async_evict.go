@@ -0,0 +1,222 @@
+package lru
+
+import (
+	"sync"
+
+	"github.com/bpowers/approx-lru/internal/approxlru"
+)
+
+// evictEvent is an eviction waiting to be passed to the eviction callback.
+type evictEvent[K comparable, V any] struct {
+	key    K
+	value  V
+	reason approxlru.EvictReason
+}
+
+// AsyncEvictPolicy decides what happens to evictions that don't fit in the
+// queue of a cache with Options.AsyncEvictQueue.
+type AsyncEvictPolicy uint8
+
+const (
+	// AsyncEvictBlock queues every eviction, making operations that
+	// overfill the queue wait for room once they have released the cache
+	// lock.  It is the default.
+	AsyncEvictBlock AsyncEvictPolicy = iota
+	// AsyncEvictDrop drops evictions that don't fit, without calling the
+	// eviction callback for them.  They are counted in
+	// RuntimeReport.EvictDropped.
+	AsyncEvictDrop
+	// AsyncEvictSpill passes evictions that don't fit to
+	// Options.AsyncEvictSpill instead, for example to write them to disk
+	// for later processing.  It is called on the goroutine that evicted,
+	// once it has released the cache lock.  Spilled evictions are counted
+	// in RuntimeReport.EvictSpilled.
+	AsyncEvictSpill
+)
+
+// asyncEvicter runs a cache's eviction callback on a background goroutine.
+// Evictions are queued with the cache lock held, which never waits: the
+// queue may briefly exceed its size, and goroutines that evicted wait for
+// it to drain back down only once they have released the cache lock, so a
+// callback calling back into the cache can't deadlock with them.
+type asyncEvicter[K comparable, V any] struct {
+	onEvict approxlru.EvictReasonCallback[K, V]
+	size    int
+	policy  AsyncEvictPolicy
+	spill   func(key K, value V, reason EvictReason)
+	// overfull is set, under the cache lock, once an eviction overfills
+	// the queue, for the evicting goroutine to wait for room, and spilled
+	// holds the evictions waiting for it to spill them.
+	overfull bool
+	spilled  []evictEvent[K, V]
+
+	// mu guards the fields below, and cond signals changes to them.
+	mu    sync.Mutex
+	cond  *sync.Cond
+	queue []evictEvent[K, V]
+	// evictions counts the evictions queued, and handled those the
+	// callback has returned from, for Flush.
+	evictions uint64
+	handled   uint64
+	closed    bool
+	// dropped and spills count the evictions that didn't fit in the
+	// queue.
+	dropped uint64
+	spills  uint64
+
+	// done is closed once the goroutine has handled every event.
+	done chan struct{}
+}
+
+func newAsyncEvicter[K comparable, V any](size int, policy AsyncEvictPolicy, spill func(key K, value V, reason EvictReason), onEvict approxlru.EvictReasonCallback[K, V]) *asyncEvicter[K, V] {
+	a := &asyncEvicter[K, V]{
+		onEvict: onEvict,
+		size:    size,
+		policy:  policy,
+		spill:   spill,
+		done:    make(chan struct{}),
+	}
+	a.cond = sync.NewCond(&a.mu)
+	go a.run()
+	return a
+}
+
+func (a *asyncEvicter[K, V]) run() {
+	defer close(a.done)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for {
+		for len(a.queue) == 0 && !a.closed {
+			a.cond.Wait()
+		}
+		if len(a.queue) == 0 {
+			return
+		}
+		batch := a.queue
+		a.queue = nil
+		a.cond.Broadcast()
+		a.mu.Unlock()
+		for _, ev := range batch {
+			a.onEvict(ev.key, ev.value, ev.reason)
+		}
+		a.mu.Lock()
+		a.handled += uint64(len(batch))
+		a.cond.Broadcast()
+	}
+}
+
+// evicted queues an eviction, or drops or spills it if the queue is full.
+// It is called with the cache lock held, so it never waits for room; see
+// wait.
+func (a *asyncEvicter[K, V]) evicted(key K, value V, reason approxlru.EvictReason) {
+	ev := evictEvent[K, V]{key: key, value: value, reason: reason}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.queue) >= a.size {
+		switch a.policy {
+		case AsyncEvictDrop:
+			a.dropped++
+			return
+		case AsyncEvictSpill:
+			a.spills++
+			a.spilled = append(a.spilled, ev)
+			return
+		}
+	}
+	a.queue = append(a.queue, ev)
+	a.evictions++
+	if len(a.queue) > a.size {
+		a.overfull = true
+	}
+	a.cond.Broadcast()
+}
+
+// wait waits until the queue has room again, or the goroutine is stopped.
+// The cache lock must not be held.
+func (a *asyncEvicter[K, V]) wait() {
+	a.mu.Lock()
+	for len(a.queue) > a.size && !a.closed {
+		a.cond.Wait()
+	}
+	a.mu.Unlock()
+}
+
+// stop stops the goroutine once it has handled the queued evictions,
+// returning a channel closed when it has.
+func (a *asyncEvicter[K, V]) stop() (done <-chan struct{}) {
+	a.mu.Lock()
+	a.closed = true
+	a.cond.Broadcast()
+	a.mu.Unlock()
+	return a.done
+}
+
+// unlock releases c.lock, then spills the evictions made while it was held
+// that didn't fit in the background eviction queue, or waits for room in
+// the queue if they overfilled it.  Only the
+// goroutine that evicted waits, so callbacks that merely read the cache
+// never wait on themselves.  Cache methods that may evict release the
+// lock with it rather than c.lock.Unlock.
+func (c *Cache[K, V]) unlock() {
+	var a *asyncEvicter[K, V]
+	var overfull bool
+	var spilled []evictEvent[K, V]
+	if c.ext != nil && c.ext.async != nil {
+		a = c.ext.async
+		overfull, spilled = a.overfull, a.spilled
+		a.overfull, a.spilled = false, nil
+	}
+	c.lock.Unlock()
+	for _, ev := range spilled {
+		a.spill(ev.key, ev.value, EvictReason(ev.reason))
+	}
+	if overfull {
+		a.wait()
+	}
+}
+
+// startAsyncEvict moves the cache's eviction callback onto a background
+// goroutine, fed by a queue of the given size and overflow policy.
+func (c *Cache[K, V]) startAsyncEvict(size int, policy AsyncEvictPolicy, spill func(key K, value V, reason EvictReason)) {
+	e := c.extension()
+	if e.onEvict == nil {
+		return
+	}
+	e.async = newAsyncEvicter(size, policy, spill, e.onEvict)
+	e.onEvict = e.async.evicted
+}
+
+// stopAsyncEvict switches the eviction callback back to running in line,
+// returning a channel closed once the queued evictions have been handled,
+// or nil if the callback wasn't running in the background.  c.lock must be
+// held, but needn't be while waiting on the channel.
+func (c *Cache[K, V]) stopAsyncEvict() (done <-chan struct{}) {
+	if c.ext == nil || c.ext.async == nil {
+		return nil
+	}
+	a := c.ext.async
+	c.ext.async = nil
+	c.ext.onEvict = a.onEvict
+	return a.stop()
+}
+
+// Flush waits until the eviction callback has handled every eviction that
+// happened before the call, for caches created with
+// Options.AsyncEvictQueue.  It returns immediately for other caches.
+func (c *Cache[K, V]) Flush() {
+	c.lock.Lock()
+	var a *asyncEvicter[K, V]
+	if c.ext != nil {
+		a = c.ext.async
+	}
+	c.lock.Unlock()
+	if a == nil {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for target := a.evictions; a.handled < target; {
+		a.cond.Wait()
+	}
+}
@@ -0,0 +1,140 @@
+package lru
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRUAsyncEvict(t *testing.T) {
+	release := make(chan struct{})
+	var evicted []int
+	l, err := NewWithOptions[int, int](2, Options[int, int]{
+		OnEvict: func(k, v int) {
+			<-release
+			evicted = append(evicted, k)
+		},
+		AsyncEvictQueue: 8,
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		// evicting doesn't wait for the blocked callback
+		l.Add(i, i)
+	}
+	if l.Runtime().Goroutines != 1 {
+		t.Errorf("expected a background eviction goroutine")
+	}
+	close(release)
+	l.Flush()
+	if len(evicted) != 3 {
+		t.Errorf("expected 3 evictions to be handled after Flush: %v", evicted)
+	}
+
+	l.Remove(3)
+	l.Close()
+	if len(evicted) != 4 || evicted[3] != 3 {
+		t.Errorf("expected Close to drain queued evictions: %v", evicted)
+	}
+	if l.Runtime().Goroutines != 0 {
+		t.Errorf("expected Close to stop the eviction goroutine")
+	}
+
+	// once closed, callbacks run in line again
+	l.Remove(4)
+	if len(evicted) != 5 {
+		t.Errorf("expected the callback to run in line: %v", evicted)
+	}
+}
+
+func TestLRUAsyncEvictReentrant(t *testing.T) {
+	var l *Cache[int, int]
+	lens := 0
+	l, err := NewWithOptions[int, int](2, Options[int, int]{
+		OnEvict: func(k, v int) {
+			// callbacks run without the lock, so they can use the cache
+			if l.Len() > 2 {
+				t.Errorf("bad len: %d", l.Len())
+			}
+			lens++
+		},
+		AsyncEvictQueue: 1,
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			l.Add(i, i)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("adds deadlocked with the eviction callback")
+	}
+	l.Flush()
+	if lens != 998 {
+		t.Errorf("expected every eviction to be handled: %d", lens)
+	}
+	l.Close()
+}
+
+func TestLRUAsyncEvictPolicy(t *testing.T) {
+	if _, err := NewWithOptions[int, int](2, Options[int, int]{
+		OnEvict:          func(k, v int) {},
+		AsyncEvictQueue:  1,
+		AsyncEvictPolicy: AsyncEvictSpill,
+	}); err == nil {
+		t.Errorf("expected spilling without a function to be rejected")
+	}
+
+	for _, policy := range []AsyncEvictPolicy{AsyncEvictDrop, AsyncEvictSpill} {
+		release := make(chan struct{})
+		var handled, spilled []int
+		l, err := NewWithOptions[int, int](2, Options[int, int]{
+			OnEvict: func(k, v int) {
+				<-release
+				handled = append(handled, k)
+			},
+			AsyncEvictQueue:  2,
+			AsyncEvictPolicy: policy,
+			AsyncEvictSpill: func(k, v int, reason EvictReason) {
+				if reason != ReasonEvicted {
+					t.Errorf("bad reason: %v", reason)
+				}
+				spilled = append(spilled, k)
+			},
+		})
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		// the callback holds on to the first eviction, and the queue
+		// takes two more: the rest don't wait for it
+		for i := 0; i < 10; i++ {
+			l.Add(i, i)
+		}
+		close(release)
+		l.Flush()
+		r := l.Runtime()
+		l.Close()
+
+		overflow := 8 - len(handled)
+		if len(handled) < 2 || overflow == 0 {
+			t.Errorf("%d: expected some evictions to overflow: %v", policy, handled)
+		}
+		switch policy {
+		case AsyncEvictDrop:
+			if r.EvictDropped != uint64(overflow) || len(spilled) != 0 {
+				t.Errorf("expected %d drops: %+v, %v", overflow, r, spilled)
+			}
+		case AsyncEvictSpill:
+			if r.EvictSpilled != uint64(overflow) || len(spilled) != overflow || r.EvictDropped != 0 {
+				t.Errorf("expected %d spills: %+v, %v", overflow, r, spilled)
+			}
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package lru
+
+import (
+	"runtime"
+	"time"
+)
+
+// autoSizer periodically resizes a cache to the size picked by a policy.
+type autoSizer struct {
+	stop chan struct{}
+	// done is closed once the goroutine has exited.
+	done chan struct{}
+}
+
+// SetTargetSizeFunc resizes the cache every interval to the size returned
+// by target, which is passed the cache's current size (zero if it is
+// unbounded) and number of entries.  Results below one leave the size
+// unchanged.  Entries evicted by shrinking are passed to the eviction
+// callback with ReasonResized.
+// Calling it again replaces the previous policy, and a nil target or Close
+// stops resizing.  target is called without the cache lock held, so it
+// may do expensive work like reading runtime statistics.
+func (c *Cache[K, V]) SetTargetSizeFunc(interval time.Duration, target func(size, len int) int) {
+	c.lock.Lock()
+	done := c.stopAutoSize()
+	if target != nil {
+		a := &autoSizer{stop: make(chan struct{}), done: make(chan struct{})}
+		c.extension().autoSize = a
+		go c.autoSize(a, interval, target)
+	}
+	c.unlock()
+
+	if done != nil {
+		<-done
+	}
+}
+
+func (c *Cache[K, V]) autoSize(a *autoSizer, interval time.Duration, target func(size, len int) int) {
+	defer close(a.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-a.stop:
+			return
+		}
+
+		c.lock.Lock()
+		size, n := c.lru.Size(), c.lru.Len()
+		c.unlock()
+
+		newSize := target(size, n)
+		if newSize < 1 || newSize == size {
+			continue
+		}
+		c.lock.Lock()
+		// don't race a concurrent SetTargetSizeFunc or Close
+		select {
+		case <-a.stop:
+		default:
+			c.stats.evicted(c.lru.Resize(newSize))
+		}
+		c.unlock()
+	}
+}
+
+// stopAutoSize stops resizing the cache, returning a channel closed once
+// the resizing goroutine has exited, or nil if there wasn't one.  c.lock
+// must be held.
+func (c *Cache[K, V]) stopAutoSize() (done <-chan struct{}) {
+	if c.ext == nil || c.ext.autoSize == nil {
+		return nil
+	}
+	a := c.ext.autoSize
+	c.ext.autoSize = nil
+	close(a.stop)
+	return a.done
+}
+
+// HeapTargetSize returns a policy for SetTargetSizeFunc that shrinks the
+// cache by a quarter, down to minSize, while the Go heap is larger than
+// heapLimit bytes, and grows it by a quarter, up to maxSize, while the
+// heap is below three quarters of heapLimit.  heapLimit is typically set
+// just under the limit passed to runtime/debug.SetMemoryLimit, or under
+// the memory available to the process.
+func HeapTargetSize(heapLimit uint64, minSize, maxSize int) func(size, len int) int {
+	return func(size, _ int) int {
+		var stats runtime.MemStats
+		runtime.ReadMemStats(&stats)
+		return heapTargetSize(stats.HeapAlloc, heapLimit, size, minSize, maxSize)
+	}
+}
+
+func heapTargetSize(heap, heapLimit uint64, size, minSize, maxSize int) int {
+	step := size / 4
+	if step < 1 {
+		step = 1
+	}
+	switch {
+	case heap > heapLimit:
+		size -= step
+		if size < minSize {
+			size = minSize
+		}
+	case heap < heapLimit/4*3:
+		size += step
+		if size > maxSize {
+			size = maxSize
+		}
+	}
+	return size
+}
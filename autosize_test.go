@@ -0,0 +1,81 @@
+package lru
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRUSetTargetSizeFunc(t *testing.T) {
+	var reasons []EvictReason
+	l, err := NewWithOptions[int, int](8, Options[int, int]{
+		OnEvictReason: func(key int, value int, reason EvictReason) {
+			reasons = append(reasons, reason)
+		},
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 8; i++ {
+		l.Add(i, i)
+	}
+
+	l.SetTargetSizeFunc(time.Millisecond, func(size, n int) int {
+		if size != 8 && size != 4 {
+			t.Errorf("unexpected size %d", size)
+		}
+		return 4
+	})
+	for deadline := time.Now().Add(5 * time.Second); l.Len() != 4; {
+		if time.Now().After(deadline) {
+			t.Fatalf("cache wasn't resized: %d entries", l.Len())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if n := l.Runtime().Goroutines; n != 1 {
+		t.Errorf("expected the resizing goroutine to be reported, not %d", n)
+	}
+	l.Close()
+	if n := l.Runtime().Goroutines; n != 0 {
+		t.Errorf("expected Close to stop the resizing goroutine, not %d", n)
+	}
+
+	if len(reasons) != 4 {
+		t.Fatalf("expected 4 evictions, not %d", len(reasons))
+	}
+	for _, reason := range reasons {
+		if reason != ReasonResized {
+			t.Errorf("bad eviction reason: %v", reason)
+		}
+	}
+	if stats := l.Stats(); stats.Evictions != 4 {
+		t.Errorf("expected 4 evictions in the stats: %+v", stats)
+	}
+
+	// after Close, the cache can grow back past its old target
+	l.Resize(8)
+	for i := 0; i < 8; i++ {
+		l.Add(i, i)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if l.Len() != 8 {
+		t.Errorf("expected Close to stop resizing: %d entries", l.Len())
+	}
+}
+
+func TestHeapTargetSize(t *testing.T) {
+	for _, tc := range []struct {
+		heap uint64
+		size int
+		want int
+	}{
+		{heap: 200, size: 100, want: 75},
+		{heap: 200, size: 12, want: 10},
+		{heap: 90, size: 100, want: 100},
+		{heap: 10, size: 100, want: 125},
+		{heap: 10, size: 190, want: 200},
+	} {
+		if got := heapTargetSize(tc.heap, 100, tc.size, 10, 200); got != tc.want {
+			t.Errorf("heapTargetSize(%d, size %d) = %d, not %d", tc.heap, tc.size, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package lru
+
+// AddMany adds each of the entries to the cache, while taking the cache
+// lock only once.  Entries are added in no particular order.  Returns the
+// number of evictions that occurred.
+func (c *Cache[K, V]) AddMany(entries map[K]V) (evicted int) {
+	c.lock.Lock()
+	defer c.unlock()
+
+	for key, value := range entries {
+		if c.add(key, value) {
+			evicted++
+		}
+	}
+	return evicted
+}
+
+// GetMany looks up each of the keys, updating their "recently used"-ness,
+// while taking the cache lock only once.  The result holds the keys that
+// were found.
+func (c *Cache[K, V]) GetMany(keys []K) map[K]V {
+	found := make(map[K]V, len(keys))
+
+	c.lock.Lock()
+	defer c.unlock()
+
+	for _, key := range keys {
+		if value, ok := c.get(key); ok {
+			found[key] = value
+		}
+	}
+	return found
+}
+
+// RemoveMany removes each of the keys from the cache, while taking the
+// cache lock only once.  Returns the number of keys that were present.
+func (c *Cache[K, V]) RemoveMany(keys []K) (removed int) {
+	c.lock.Lock()
+	defer c.unlock()
+
+	for _, key := range keys {
+		if _, ok := c.remove(key); ok {
+			removed++
+		}
+	}
+	return removed
+}
+
+// AddMany adds each of the entries to the cache, while taking each shard's
+// lock at most once.  Returns the number of evictions that occurred.
+func (c *ShardedCache[V]) AddMany(entries map[string]V) (evicted int) {
+	byShard := make(map[*shard[V]][]string)
+	for key := range entries {
+		shard := c.getShard(key)
+		byShard[shard] = append(byShard[shard], key)
+	}
+
+	for shard, keys := range byShard {
+		shard.mu.Lock()
+		for _, key := range keys {
+			if shard.add(key, entries[key]) {
+				evicted++
+			}
+		}
+		shard.mu.Unlock()
+	}
+	return evicted
+}
+
+// GetMany looks up each of the keys, updating their "recently used"-ness,
+// while taking each shard's lock at most once.  The result holds the keys
+// that were found.
+func (c *ShardedCache[V]) GetMany(keys []string) map[string]V {
+	found := make(map[string]V, len(keys))
+	for shard, keys := range c.groupByShard(keys) {
+		shard.mu.Lock()
+		for _, key := range keys {
+			if value, ok := shard.get(key); ok {
+				found[key] = value
+			}
+		}
+		shard.mu.Unlock()
+	}
+	return found
+}
+
+// RemoveMany removes each of the keys from the cache, while taking each
+// shard's lock at most once.  Returns the number of keys that were present.
+func (c *ShardedCache[V]) RemoveMany(keys []string) (removed int) {
+	for shard, keys := range c.groupByShard(keys) {
+		shard.mu.Lock()
+		for _, key := range keys {
+			if shard.lru.Remove(key) {
+				removed++
+			}
+		}
+		shard.mu.Unlock()
+	}
+	return removed
+}
+
+// groupByShard groups keys by the shard they live in.
+func (c *ShardedCache[V]) groupByShard(keys []string) map[*shard[V]][]string {
+	byShard := make(map[*shard[V]][]string)
+	for _, key := range keys {
+		shard := c.getShard(key)
+		byShard[shard] = append(byShard[shard], key)
+	}
+	return byShard
+}
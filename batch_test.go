@@ -0,0 +1,76 @@
+package lru
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestLRUBatch(t *testing.T) {
+	l, err := New[string, int](100)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	entries := make(map[string]int)
+	for i := 0; i < 150; i++ {
+		entries[strconv.Itoa(i)] = i
+	}
+	if evicted := l.AddMany(entries); evicted != 50 {
+		t.Errorf("expected 50 evictions, not %d", evicted)
+	}
+	if l.Len() != 100 {
+		t.Errorf("bad len: %v", l.Len())
+	}
+
+	keys := l.Keys()
+	found := l.GetMany(append(keys, "missing"))
+	if len(found) != 100 {
+		t.Errorf("expected all 100 keys to be found: %d", len(found))
+	}
+	for key, v := range found {
+		if strconv.Itoa(v) != key {
+			t.Errorf("bad value for %s: %d", key, v)
+		}
+	}
+
+	if removed := l.RemoveMany(append(keys[:60], "missing")); removed != 60 {
+		t.Errorf("expected 60 removals, not %d", removed)
+	}
+	if l.Len() != 40 {
+		t.Errorf("bad len: %v", l.Len())
+	}
+}
+
+func TestShardedBatch(t *testing.T) {
+	l, err := NewSharded[int](1024, 4)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	entries := make(map[string]int)
+	keys := make([]string, 0, 100)
+	for i := 0; i < 100; i++ {
+		entries[strconv.Itoa(i)] = i
+		keys = append(keys, strconv.Itoa(i))
+	}
+	if evicted := l.AddMany(entries); evicted != 0 {
+		t.Errorf("expected no evictions, not %d", evicted)
+	}
+
+	found := l.GetMany(append(keys, "missing"))
+	if len(found) != 100 {
+		t.Errorf("expected all 100 keys to be found: %d", len(found))
+	}
+	for key, v := range found {
+		if strconv.Itoa(v) != key {
+			t.Errorf("bad value for %s: %d", key, v)
+		}
+	}
+
+	if removed := l.RemoveMany(append(keys[:60], "missing")); removed != 60 {
+		t.Errorf("expected 60 removals, not %d", removed)
+	}
+	if l.Len() != 40 {
+		t.Errorf("bad len: %v", l.Len())
+	}
+}
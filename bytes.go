@@ -0,0 +1,17 @@
+package lru
+
+// GetAppend looks up a key's value in a cache of byte slices, updating the
+// "recently used"-ness of the key, and appends a copy of it to dst.  This
+// lets hot read paths reuse a caller-owned (or pooled) buffer instead of
+// allocating a copy per lookup.  Returns the extended buffer, or dst
+// unchanged if the key wasn't found.
+func GetAppend[K comparable](c *Cache[K, []byte], dst []byte, key K) ([]byte, bool) {
+	c.lock.Lock()
+	defer c.unlock()
+
+	value, ok := c.get(key)
+	if !ok {
+		return dst, false
+	}
+	return append(dst, value...), true
+}
@@ -0,0 +1,28 @@
+package lru
+
+import (
+	"testing"
+)
+
+func TestGetAppend(t *testing.T) {
+	l, err := New[string, []byte](2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add("1", []byte("one"))
+
+	buf := make([]byte, 0, 16)
+	buf, ok := GetAppend(l, buf[:0], "1")
+	if !ok || string(buf) != "one" {
+		t.Fatalf("bad value: %q, %v", buf, ok)
+	}
+	buf[0] = 'O'
+	if v, _ := l.Peek("1"); string(v) != "one" {
+		t.Errorf("GetAppend should have copied the value: %q", v)
+	}
+
+	buf, ok = GetAppend(l, buf, "2")
+	if ok || string(buf) != "One" {
+		t.Errorf("misses should leave dst unchanged: %q, %v", buf, ok)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build lrucacheline64 || (!lrucacheline128 && !arm64 && !ppc64 && !ppc64le)
+
+package lru
+
+// cacheLineSize is the size of a CPU cache line.  Caches and shards are
+// padded to a multiple of it, so that goroutines working on neighbouring
+// shards don't contend on the same line.  The lrucacheline64 and
+// lrucacheline128 build tags override the per-architecture default.
+const cacheLineSize = 64
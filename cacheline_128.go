@@ -0,0 +1,6 @@
+//go:build !lrucacheline64 && (lrucacheline128 || arm64 || ppc64 || ppc64le)
+
+package lru
+
+// cacheLineSize is the size of a CPU cache line -- see cacheline.go.
+const cacheLineSize = 128
@@ -0,0 +1,37 @@
+package lru
+
+// Cacher is the set of operations shared by the caches in this package.
+type Cacher[K comparable, V any] interface {
+	// Adds a value to the cache, returns true if an eviction occurred and
+	// updates the "recently used"-ness of the key.
+	Add(key K, value V) (evicted bool)
+
+	// Returns key's value from the cache and
+	// updates the "recently used"-ness of the key. #value, isFound
+	Get(key K) (value V, ok bool)
+
+	// Checks if a key exists in cache without updating the recent-ness.
+	Contains(key K) bool
+
+	// Returns key's value without updating the "recently used"-ness of the key.
+	Peek(key K) (value V, ok bool)
+
+	// Removes a key from the cache.
+	Remove(key K) (present bool)
+
+	// Returns the number of items in the cache.
+	Len() int
+
+	// Clears all cache entries.
+	Purge()
+}
+
+var (
+	_ Cacher[string, int] = (*Cache[string, int])(nil)
+	_ Cacher[string, int] = (*ShardedCache[int])(nil)
+	_ Cacher[string, int] = (*TwoQueueCache[string, int])(nil)
+	_ Cacher[string, int] = (*ARCCache[string, int])(nil)
+	_ Cacher[string, int] = (*ReadOptimizedCache[string, int])(nil)
+	_ Cacher[string, int] = (*MiddlewareCache[string, int])(nil)
+	_ Cacher[string, int] = (*SieveCache[string, int])(nil)
+)
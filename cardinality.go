@@ -0,0 +1,69 @@
+package lru
+
+// cardinalityGuard watches a full cache's hit ratio for collapse, the sign
+// of a keyspace far larger than the cache, where caching only burns CPU.
+type cardinalityGuard[K comparable] struct {
+	window     int
+	minRatio   float64
+	onCollapse func(hitRatio float64)
+	// filter, if non-nil, is installed as the cache's admission filter
+	// while the hit ratio is collapsed.  It learns key frequencies all
+	// along, so it is warm when installed.
+	filter *tinyLFU[K]
+
+	lookups, hits int
+	collapsed     bool
+}
+
+// defaultCardinalityMinHitRatio is the hit ratio below which a full cache
+// is considered collapsed, unless Options.CardinalityMinHitRatio is set.
+const defaultCardinalityMinHitRatio = 0.01
+
+// cardinality records a lookup with the cardinality guard, checking the hit
+// ratio at the end of each window.  c.lock must be held.
+func (c *Cache[K, V]) cardinality(key K, hit bool) {
+	e := c.ext
+	g := e.cardinality
+	if g.filter != nil && e.admission != g.filter {
+		g.filter.used(key)
+	}
+	g.lookups++
+	if hit {
+		g.hits++
+	}
+	if g.lookups < g.window {
+		return
+	}
+	ratio := float64(g.hits) / float64(g.lookups)
+	g.lookups, g.hits = 0, 0
+
+	// a cache still filling up misses for reasons other than cardinality
+	full := c.lru.Size() > 0 && c.lru.Len() >= c.lru.Size()
+	switch {
+	case ratio < g.minRatio && full:
+		c.stats.collapsed()
+		if !g.collapsed && g.filter != nil && e.admission == nil {
+			e.admission = g.filter
+		}
+		g.collapsed = true
+		if g.onCollapse != nil {
+			g.onCollapse(ratio)
+		}
+	case ratio >= 2*g.minRatio && g.collapsed:
+		// recovering well clear of the threshold keeps the guard from
+		// flapping
+		g.collapsed = false
+		if g.filter != nil && e.admission == g.filter {
+			e.admission = nil
+		}
+	}
+}
+
+// CardinalityCollapsed reports whether the cardinality guard enabled with
+// Options.CardinalityWindow last found the cache's hit ratio collapsed.
+func (c *Cache[K, V]) CardinalityCollapsed() bool {
+	c.lock.Lock()
+	defer c.unlock()
+
+	return c.ext != nil && c.ext.cardinality != nil && c.ext.cardinality.collapsed
+}
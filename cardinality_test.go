@@ -0,0 +1,78 @@
+package lru
+
+import (
+	"testing"
+)
+
+func TestLRUCardinalityGuard(t *testing.T) {
+	var ratios []float64
+	l, err := NewWithOptions[int, int](16, Options[int, int]{
+		CardinalityWindow:     100,
+		CardinalityThrottle:   true,
+		OnCardinalityCollapse: func(hitRatio float64) { ratios = append(ratios, hitRatio) },
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// a scan of unique keys never hits once the cache is full
+	for i := 0; i < 1000; i++ {
+		if !hit(l, i) {
+			l.Add(i, i)
+		}
+	}
+	if !l.CardinalityCollapsed() {
+		t.Fatalf("expected the scan to collapse the hit ratio")
+	}
+	if len(ratios) == 0 || ratios[0] != 0 {
+		t.Errorf("expected collapses to be reported with a zero hit ratio: %v", ratios)
+	}
+	if stats := l.Stats(); stats.Collapses != uint64(len(ratios)) {
+		t.Errorf("expected %d collapses in the stats: %+v", len(ratios), stats)
+	}
+
+	// while collapsed, one-off keys no longer displace entries
+	before := l.Stats().Rejected
+	for i := 1000; i < 1100; i++ {
+		l.Add(i, i)
+	}
+	if rejected := l.Stats().Rejected - before; rejected == 0 {
+		t.Errorf("expected one-off keys to be rejected while collapsed")
+	}
+
+	// a working set that fits is admitted once looked up repeatedly, and
+	// recovers
+	for n := 0; n < 500; n++ {
+		if key := -(n % 16) - 1; !hit(l, key) {
+			l.Add(key, n)
+		}
+	}
+	if l.CardinalityCollapsed() {
+		t.Errorf("expected the hit ratio to recover")
+	}
+	if l.ext.admission != nil {
+		t.Errorf("expected throttling to stop after recovering")
+	}
+}
+
+func TestLRUCardinalityGuardFilling(t *testing.T) {
+	l, err := NewWithOptions[int, int](1000, Options[int, int]{
+		CardinalityWindow: 10,
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	// a cache still filling up isn't collapsed, however often it misses
+	for i := 0; i < 500; i++ {
+		l.Get(i)
+		l.Add(i, i)
+	}
+	if l.CardinalityCollapsed() || l.Stats().Collapses != 0 {
+		t.Errorf("a filling cache shouldn't be collapsed")
+	}
+}
+
+func hit(l *Cache[int, int], key int) bool {
+	_, ok := l.Get(key)
+	return ok
+}
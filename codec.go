@@ -0,0 +1,141 @@
+package lru
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"reflect"
+)
+
+// Codec converts values to and from a serialized form.
+type Codec[V any] interface {
+	// Encode serializes value.
+	Encode(value V) ([]byte, error)
+	// Decode deserializes a value previously serialized with Encode.
+	Decode(data []byte) (V, error)
+}
+
+// GobCodec is a Codec that serializes values with encoding/gob.
+type GobCodec[V any] struct{}
+
+// Encode serializes value with encoding/gob.
+func (GobCodec[V]) Encode(value V) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode deserializes a gob-encoded value.
+func (GobCodec[V]) Decode(data []byte) (value V, err error) {
+	err = gob.NewDecoder(bytes.NewReader(data)).Decode(&value)
+	return value, err
+}
+
+// JSONCodec is a Codec that serializes values with encoding/json.
+type JSONCodec[V any] struct{}
+
+// Encode serializes value with encoding/json.
+func (JSONCodec[V]) Encode(value V) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+// Decode deserializes a JSON-encoded value.
+func (JSONCodec[V]) Decode(data []byte) (value V, err error) {
+	err = json.Unmarshal(data, &value)
+	return value, err
+}
+
+// AutoCodec is a Codec that serializes values with their own MarshalBinary
+// or MarshalText methods when they have them, and with encoding/gob
+// otherwise.  SaveTo and LoadFrom use it when not given a codec.  Each
+// serialized value starts with a byte recording which encoding was used.
+type AutoCodec[V any] struct{}
+
+// The encodings AutoCodec tags serialized values with.
+const (
+	autoBinary = 'b'
+	autoText   = 't'
+	autoGob    = 'g'
+)
+
+var errAutoCodec = errors.New("lru: value not serialized by AutoCodec")
+
+// Encode serializes value with MarshalBinary, MarshalText, or
+// encoding/gob, in that order of preference.  Values of interface types
+// are serialized with gob, which requires their dynamic types to be
+// registered with gob.Register.
+func (AutoCodec[V]) Encode(value V) ([]byte, error) {
+	var (
+		data []byte
+		err  error
+		tag  byte
+	)
+	// methods may be declared on either V or *V
+	switch m := marshaler(&value).(type) {
+	case encoding.BinaryMarshaler:
+		tag = autoBinary
+		data, err = m.MarshalBinary()
+	case encoding.TextMarshaler:
+		tag = autoText
+		data, err = m.MarshalText()
+	default:
+		tag = autoGob
+		data, err = GobCodec[V]{}.Encode(value)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{tag}, data...), nil
+}
+
+// marshaler returns the value or pointer holding the marshaling methods
+// of *value, if any.  Values of interface types never have any: Decode
+// can't unmarshal into an interface, whatever the dynamic type encoded, so
+// they are always serialized with gob.
+func marshaler[V any](value *V) any {
+	if reflect.TypeOf(value).Elem().Kind() == reflect.Interface {
+		return value
+	}
+	switch v := any(*value).(type) {
+	case encoding.BinaryMarshaler, encoding.TextMarshaler:
+		return v
+	}
+	return value
+}
+
+// Decode deserializes a value serialized by Encode.
+func (AutoCodec[V]) Decode(data []byte) (value V, err error) {
+	if len(data) == 0 {
+		return value, errAutoCodec
+	}
+	tag, data := data[0], data[1:]
+	if tag == autoGob {
+		return GobCodec[V]{}.Decode(data)
+	}
+
+	// unmarshal into a fresh value for pointer types, and into value
+	// itself otherwise
+	target := any(&value)
+	if t := reflect.TypeOf((*V)(nil)).Elem(); t.Kind() == reflect.Pointer {
+		fresh := reflect.New(t.Elem())
+		value = fresh.Interface().(V)
+		target = fresh.Interface()
+	}
+	switch u := target.(type) {
+	case encoding.BinaryUnmarshaler:
+		if tag == autoBinary {
+			return value, u.UnmarshalBinary(data)
+		}
+	}
+	switch u := target.(type) {
+	case encoding.TextUnmarshaler:
+		if tag == autoText {
+			return value, u.UnmarshalText(data)
+		}
+	}
+	return value, errAutoCodec
+}
@@ -0,0 +1,50 @@
+package lru
+
+// Compute atomically updates the entry for key: fn is called with the
+// current value and whether the key is present, and returns the new value
+// and whether to keep it.  If keep is true the new value is added,
+// otherwise the key is removed if present.  fn runs with the cache lock
+// held, so it must be quick and must not use the cache.  Returns the value
+// now stored and whether the key is present afterwards.
+func (c *Cache[K, V]) Compute(key K, fn func(old V, exists bool) (value V, keep bool)) (value V, ok bool) {
+	c.lock.Lock()
+	defer c.unlock()
+
+	old, exists := c.peek(key)
+	value, keep := fn(old, exists)
+	if !keep {
+		if exists {
+			c.remove(key)
+		}
+		var zero V
+		return zero, false
+	}
+	c.add(key, value)
+	// the add may have been dropped, e.g. by a tombstone
+	value, ok = c.lru.Peek(key)
+	return value, ok
+}
+
+// Compute atomically updates the entry for key: fn is called with the
+// current value and whether the key is present, and returns the new value
+// and whether to keep it.  If keep is true the new value is added,
+// otherwise the key is removed if present.  fn runs with the shard's lock
+// held, so it must be quick and must not use the cache.  Returns the value
+// now stored and whether the key is present afterwards.
+func (c *ShardedCache[V]) Compute(key string, fn func(old V, exists bool) (value V, keep bool)) (value V, ok bool) {
+	shard := c.getShard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	old, exists := shard.lru.Peek(key)
+	value, keep := fn(old, exists)
+	if !keep {
+		if exists {
+			shard.lru.Remove(key)
+		}
+		var zero V
+		return zero, false
+	}
+	shard.add(key, value)
+	return value, true
+}
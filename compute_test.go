@@ -0,0 +1,70 @@
+package lru
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestLRUCompute(t *testing.T) {
+	l, err := New[string, int](8)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	incr := func(old int, exists bool) (int, bool) {
+		return old + 1, true
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				l.Compute("counter", incr)
+			}
+		}()
+	}
+	wg.Wait()
+	if v, ok := l.Get("counter"); !ok || v != 800 {
+		t.Errorf("expected 800 increments: %v, %v", v, ok)
+	}
+
+	// conditional delete
+	v, ok := l.Compute("counter", func(old int, exists bool) (int, bool) {
+		return old, old < 100
+	})
+	if ok || v != 0 || l.Contains("counter") {
+		t.Errorf("counter should have been removed: %v, %v", v, ok)
+	}
+	v, ok = l.Compute("missing", func(old int, exists bool) (int, bool) {
+		if exists {
+			t.Errorf("missing shouldn't exist")
+		}
+		return 0, false
+	})
+	if ok || l.Contains("missing") {
+		t.Errorf("missing shouldn't have been added")
+	}
+}
+
+func TestShardedCompute(t *testing.T) {
+	l, err := NewSharded[int](256, 4)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		l.Compute("counter", func(old int, exists bool) (int, bool) {
+			if exists != (i > 0) {
+				t.Errorf("%d: bad exists %v", i, exists)
+			}
+			return old + 1, true
+		})
+	}
+	if v, ok := l.Get("counter"); !ok || v != 10 {
+		t.Errorf("expected 10 increments: %v, %v", v, ok)
+	}
+	if _, ok := l.Compute("counter", func(old int, exists bool) (int, bool) { return 0, false }); ok || l.Contains("counter") {
+		t.Errorf("counter should have been removed")
+	}
+}
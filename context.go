@@ -0,0 +1,66 @@
+package lru
+
+import (
+	"context"
+	"time"
+)
+
+// GetCtx is like Get, but returns ctx.Err() without looking the key up if
+// ctx is already done, so a request that has timed out doesn't update the
+// recent-ness of, or count a hit or miss for, the entries it would have
+// used.  A refresh the lookup starts is passed a context carrying ctx's
+// values and deadline; see Options.RefreshCtx.
+func (c *Cache[K, V]) GetCtx(ctx context.Context, key K) (value V, ok bool, err error) {
+	if err := ctx.Err(); err != nil {
+		return value, false, err
+	}
+	c.lock.Lock()
+	defer c.unlock()
+
+	value, ok = c.getCtx(ctx, key)
+	return value, ok, nil
+}
+
+// AddCtx is like Add, but returns ctx.Err() without adding the value if ctx
+// is already done, so a request that has timed out doesn't fill the cache
+// with work nobody is waiting for.
+func (c *Cache[K, V]) AddCtx(ctx context.Context, key K, value V) (evicted bool, err error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	return c.Add(key, value), nil
+}
+
+// getCtx is get for lookups made with a ctx, which the refreshes they
+// start inherit.  c.lock must be held.
+func (c *Cache[K, V]) getCtx(ctx context.Context, key K) (V, bool) {
+	if c.ext == nil || c.ext.revalidator == nil {
+		return c.get(key)
+	}
+	r := c.ext.revalidator
+	r.ctx = ctx
+	defer func() { r.ctx = nil }()
+	return c.get(key)
+}
+
+// detachedContext carries the values of its parent, but not its deadline
+// or cancelation.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+func (d detachedContext) Value(key any) any         { return d.parent.Value(key) }
+
+// detach returns a context carrying ctx's values and deadline, for work
+// that outlives the call ctx belongs to: it isn't canceled when that call
+// returns, only when the deadline passes or cancel is called.
+func detach(ctx context.Context) (detached context.Context, cancel context.CancelFunc) {
+	detached = detachedContext{parent: ctx}
+	if deadline, ok := ctx.Deadline(); ok {
+		return context.WithDeadline(detached, deadline)
+	}
+	return detached, func() {}
+}
@@ -0,0 +1,47 @@
+package lru
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestLRUContextCanceled(t *testing.T) {
+	l, err := New[int, int](2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	ctx := context.Background()
+	if _, err := l.AddCtx(ctx, 1, 1); err != nil {
+		t.Fatalf("AddCtx: %v", err)
+	}
+	l.Add(2, 2)
+	if v, ok, err := l.GetCtx(ctx, 1); err != nil || !ok || v != 1 {
+		t.Errorf("bad GetCtx: %v, %v, %v", v, ok, err)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	before := l.Stats()
+	if _, ok, err := l.GetCtx(canceled, 2); err != context.Canceled || ok {
+		t.Errorf("expected GetCtx to be canceled: %v, %v", ok, err)
+	}
+	_, err = l.GetOrLoadCtx(canceled, 2, func(ctx context.Context, key int) (int, error) {
+		t.Errorf("the loader shouldn't be called")
+		return 0, nil
+	})
+	if err != context.Canceled {
+		t.Errorf("expected GetOrLoadCtx to be canceled: %v", err)
+	}
+	if _, err := l.AddCtx(canceled, 3, 3); err != context.Canceled || l.Contains(3) {
+		t.Errorf("expected AddCtx to be canceled: %v", err)
+	}
+
+	// canceled lookups don't make 2 recently used, or count in the stats
+	if keys := l.Keys(); !reflect.DeepEqual(keys, []int{2, 1}) {
+		t.Errorf("bad recency order: %v", keys)
+	}
+	if stats := l.Stats(); stats != before {
+		t.Errorf("canceled calls shouldn't be counted: %+v", stats)
+	}
+}
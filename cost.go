@@ -0,0 +1,83 @@
+package lru
+
+import (
+	"errors"
+)
+
+var errMaxCost = errors.New("must provide a positive max cost")
+
+// costState tracks the total cost of a cache's entries, for caches bounded
+// by cost rather than (or as well as) by number of entries.
+type costState[K comparable, V any] struct {
+	max   int64
+	total int64
+	fn    func(key K, value V) int64
+	costs map[K]int64
+	// sketch tracks the distribution of costs, for Stats.
+	sketch *costSketch
+}
+
+// NewWithCost constructs a cache bounded by the total cost of its entries,
+// as computed by costFn, rather than by their number.  Adding an entry
+// evicts old entries until the total cost is at most maxCost again.
+func NewWithCost[K comparable, V any](maxCost int64, costFn func(key K, value V) int64) (*Cache[K, V], error) {
+	if maxCost <= 0 {
+		return nil, errMaxCost
+	}
+	return NewWithOptions[K, V](0, Options[K, V]{
+		MaxCost: maxCost,
+		Cost:    costFn,
+	})
+}
+
+func newCostState[K comparable, V any](maxCost int64, costFn func(key K, value V) int64) (*costState[K, V], error) {
+	if maxCost <= 0 {
+		return nil, errMaxCost
+	}
+	if costFn == nil {
+		return nil, errors.New("must provide a cost function")
+	}
+	return &costState[K, V]{
+		max:    maxCost,
+		fn:     costFn,
+		costs:  make(map[K]int64),
+		sketch: new(costSketch),
+	}, nil
+}
+
+// charge records the cost of a just added entry, evicting old entries until
+// the total cost fits again.  Returns whether any entry was evicted.
+// c.lock must be held.
+func (c *Cache[K, V]) charge(key K, cost int64) (evicted bool) {
+	s := c.ext.cost
+	if old, ok := s.costs[key]; ok {
+		s.sketch.remove(old)
+	}
+	s.sketch.add(cost)
+	s.total += cost - s.costs[key]
+	s.costs[key] = cost
+
+	n := 0
+	// the just added entry is the most recently used, so it is only
+	// picked once it is the last one left, and it fits on its own.
+	for s.total > s.max {
+		if _, _, ok := c.lru.RemoveOldest(); !ok {
+			break
+		}
+		n++
+	}
+	c.stats.evicted(n)
+	return n > 0
+}
+
+// Cost returns the total cost of the cache's entries, or 0 if it isn't
+// bounded by cost.
+func (c *Cache[K, V]) Cost() int64 {
+	c.lock.Lock()
+	defer c.unlock()
+
+	if c.ext == nil || c.ext.cost == nil {
+		return 0
+	}
+	return c.ext.cost.total
+}
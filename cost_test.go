@@ -0,0 +1,100 @@
+package lru
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRUCost(t *testing.T) {
+	l, err := NewWithCost[string, []byte](10, func(key string, value []byte) int64 {
+		return int64(len(value))
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for i, key := range []string{"1", "2", "3"} {
+		if l.Add(key, make([]byte, 3)) {
+			t.Errorf("%d: should not have an eviction", i)
+		}
+	}
+	if l.Cost() != 9 {
+		t.Errorf("bad cost: %d", l.Cost())
+	}
+
+	// a large value evicts several old ones
+	if !l.Add("4", make([]byte, 6)) {
+		t.Errorf("should have an eviction")
+	}
+	if l.Len() != 2 || !l.Contains("3") || !l.Contains("4") || l.Cost() != 9 {
+		t.Errorf("expected 1 and 2 to be evicted: len %d, cost %d", l.Len(), l.Cost())
+	}
+
+	// replacing a value charges only the difference
+	l.Add("4", make([]byte, 7))
+	if l.Len() != 2 || l.Cost() != 10 {
+		t.Errorf("bad len %d or cost %d", l.Len(), l.Cost())
+	}
+
+	// values that can't fit aren't cached, and don't leave stale values
+	if l.Add("3", make([]byte, 11)) {
+		t.Errorf("should not have an eviction")
+	}
+	if l.Contains("3") || l.Cost() != 7 {
+		t.Errorf("3 shouldn't be cached: cost %d", l.Cost())
+	}
+
+	l.Remove("4")
+	if l.Cost() != 0 {
+		t.Errorf("bad cost: %d", l.Cost())
+	}
+	l.Add("5", make([]byte, 5))
+	l.Purge()
+	if l.Cost() != 0 {
+		t.Errorf("bad cost: %d", l.Cost())
+	}
+
+	if _, err := NewWithCost[string, []byte](0, nil); err == nil {
+		t.Errorf("expected a non-positive max cost to be rejected")
+	}
+}
+
+func TestLRUCostTTL(t *testing.T) {
+	now := time.Unix(1000, 0)
+	var reasons []EvictReason
+	l, err := NewWithOptions[string, []byte](0, Options[string, []byte]{
+		OnEvictReason: func(k string, v []byte, reason EvictReason) {
+			reasons = append(reasons, reason)
+		},
+		TTL:     time.Minute,
+		MaxCost: 10,
+		Cost: func(key string, value []byte) int64 {
+			return int64(len(value))
+		},
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.ext.ttl.now = func() time.Time { return now }
+
+	l.Add("1", make([]byte, 5))
+	l.Add("1", make([]byte, 11))
+	l.Add("2", make([]byte, 11))
+	if l.Len() != 0 {
+		t.Errorf("values that can't fit shouldn't be cached: %v", l.Keys())
+	}
+	if len(reasons) != 1 || reasons[0] != ReasonEvicted {
+		t.Errorf("expected the old value to be evicted: %v", reasons)
+	}
+	if len(l.ext.ttl.expires) != 0 {
+		t.Errorf("rejected values shouldn't be given an expiry time: %v", l.ext.ttl.expires)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if removed := l.RemoveExpired(); removed != 0 {
+		t.Errorf("nothing should have expired: %d", removed)
+	}
+	if stats := l.Stats(); stats.Expirations != 0 || stats.Evictions != 1 {
+		t.Errorf("bad stats: %+v", stats)
+	}
+}
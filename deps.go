@@ -0,0 +1,89 @@
+package lru
+
+// depGraph tracks which entries were derived from which others.
+type depGraph[K comparable] struct {
+	parents  map[K][]K
+	children map[K]map[K]struct{}
+}
+
+func newDepGraph[K comparable]() *depGraph[K] {
+	return &depGraph[K]{
+		parents:  make(map[K][]K),
+		children: make(map[K]map[K]struct{}),
+	}
+}
+
+// set replaces the parents key was derived from.
+func (g *depGraph[K]) set(key K, parents []K) {
+	g.unlinkParents(key)
+	if len(parents) == 0 {
+		return
+	}
+
+	g.parents[key] = append([]K(nil), parents...)
+	for _, parent := range parents {
+		children, ok := g.children[parent]
+		if !ok {
+			children = make(map[K]struct{})
+			g.children[parent] = children
+		}
+		children[key] = struct{}{}
+	}
+}
+
+// derived returns the keys directly derived from key.
+func (g *depGraph[K]) derived(key K) []K {
+	children := g.children[key]
+	if len(children) == 0 {
+		return nil
+	}
+	keys := make([]K, 0, len(children))
+	for child := range children {
+		keys = append(keys, child)
+	}
+	return keys
+}
+
+// remove drops key, which has left the cache, from the graph.  Entries
+// derived from key stay in the cache, but are no longer linked to it.
+func (g *depGraph[K]) remove(key K) {
+	g.unlinkParents(key)
+	delete(g.children, key)
+}
+
+func (g *depGraph[K]) unlinkParents(key K) {
+	parents, ok := g.parents[key]
+	if !ok {
+		return
+	}
+	delete(g.parents, key)
+	for _, parent := range parents {
+		children := g.children[parent]
+		delete(children, key)
+		if len(children) == 0 {
+			delete(g.children, parent)
+		}
+	}
+}
+
+// AddDerived adds a value to the cache that was derived from the entries
+// for parents.  Removing or replacing the value of any of the parents
+// removes the derived entry too, cascading to entries derived from it in
+// turn.  A parent being evicted to make room for other entries doesn't
+// invalidate what was derived from it.  Returns true if an eviction
+// occurred.
+func (c *Cache[K, V]) AddDerived(key K, value V, parents ...K) (evicted bool) {
+	c.lock.Lock()
+	defer c.unlock()
+
+	e := c.extension()
+	if e.deps == nil {
+		e.deps = newDepGraph[K]()
+	}
+	evicted = c.add(key, value)
+	// the add may have been dropped, e.g. by a tombstone
+	if c.lru.Contains(key) {
+		e.deps.set(key, parents)
+	}
+	return evicted
+}
@@ -0,0 +1,46 @@
+package lru
+
+import (
+	"testing"
+)
+
+func TestLRUAddDerived(t *testing.T) {
+	l, err := New[string, int](8)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add("a", 1)
+	l.Add("b", 2)
+	l.AddDerived("a+b", 3, "a", "b")
+	l.AddDerived("(a+b)*2", 6, "a+b")
+	l.AddDerived("b*2", 4, "b")
+
+	// updating a invalidates everything derived from it, transitively
+	l.Add("a", 10)
+	if l.Contains("a+b") || l.Contains("(a+b)*2") {
+		t.Errorf("entries derived from a should have been removed")
+	}
+	if !l.Contains("b*2") {
+		t.Errorf("b*2 isn't derived from a and should remain")
+	}
+
+	// removing b invalidates what's derived from it
+	l.Remove("b")
+	if l.Contains("b*2") {
+		t.Errorf("b*2 should have been removed with b")
+	}
+
+	// unlinked once derived entries leave the cache
+	l.Add("b", 2)
+	l.AddDerived("a+b", 12, "a", "b")
+	l.Remove("a+b")
+	l.Add("a+b", 12)
+	l.Remove("a")
+	if !l.Contains("a+b") {
+		t.Errorf("a+b was re-added without parents and should remain")
+	}
+	if len(l.ext.deps.parents) != 0 || len(l.ext.deps.children) != 0 {
+		t.Errorf("dependency graph should be empty: %v %v", l.ext.deps.parents, l.ext.deps.children)
+	}
+}
@@ -1,4 +1,4 @@
-// Package lru provides three different LRU caches of varying sophistication.
+// Package lru provides several different caches of varying sophistication.
 //
 // Cache is a simple LRU cache. It is based on the
 // LRU implementation in groupcache:
@@ -16,6 +16,12 @@
 // ARC has been patented by IBM, so do not use it if that is problematic for
 // your program.
 //
+// SieveCache evicts with the SIEVE algorithm rather than LRU. Lookups only
+// set a bit on the entry, so they share a read lock, and on many web
+// workloads its hit ratio is higher than LRU's.
+//
 // All caches in this package take locks while operating, and are therefore
-// thread-safe for consumers.
+// thread-safe for consumers.  Calling back into a cache from one of its own
+// callbacks deadlocks; building with the lrudebug tag (go test -tags
+// lrudebug) turns such reentrant calls into a panic with a clear message.
 package lru
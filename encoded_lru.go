@@ -0,0 +1,114 @@
+package lru
+
+import (
+	"errors"
+)
+
+// EncodedCache is a thread-safe fixed size LRU cache that stores values in
+// serialized form, encoding them on Add and decoding them on Get.  This
+// trades CPU for precise accounting of the memory used by values, and
+// avoids retaining pointers into the values' object graphs.
+type EncodedCache[K comparable, V any] struct {
+	cache *Cache[K, []byte]
+	codec Codec[V]
+	// bytes is protected by cache.lock
+	bytes int
+}
+
+// NewEncoded creates an EncodedCache of the given size, using codec to
+// serialize values.
+func NewEncoded[K comparable, V any](size int, codec Codec[V]) (*EncodedCache[K, V], error) {
+	if codec == nil {
+		return nil, errors.New("must provide a codec")
+	}
+	c := &EncodedCache[K, V]{
+		codec: codec,
+	}
+	cache, err := NewWithEvict[K, []byte](size, func(key K, data []byte) {
+		c.bytes -= len(data)
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.cache = cache
+	return c, nil
+}
+
+// Purge is used to completely clear the cache.
+func (c *EncodedCache[K, V]) Purge() {
+	c.cache.Purge()
+}
+
+// Add encodes a value and adds it to the cache.  Returns true if an
+// eviction occurred, or an error if the value couldn't be encoded.
+func (c *EncodedCache[K, V]) Add(key K, value V) (evicted bool, err error) {
+	data, err := c.codec.Encode(value)
+	if err != nil {
+		return false, err
+	}
+
+	c.cache.lock.Lock()
+	defer c.cache.unlock()
+
+	if old, ok := c.cache.lru.Peek(key); ok {
+		c.bytes -= len(old)
+	}
+	c.bytes += len(data)
+	return c.cache.add(key, data), nil
+}
+
+// Get looks up a key's value from the cache, decoding it.
+func (c *EncodedCache[K, V]) Get(key K) (value V, ok bool, err error) {
+	data, ok := c.cache.Get(key)
+	if !ok {
+		return value, false, nil
+	}
+	value, err = c.codec.Decode(data)
+	return value, err == nil, err
+}
+
+// Peek returns the key's decoded value without updating the "recently
+// used"-ness of the key.
+func (c *EncodedCache[K, V]) Peek(key K) (value V, ok bool, err error) {
+	data, ok := c.cache.Peek(key)
+	if !ok {
+		return value, false, nil
+	}
+	value, err = c.codec.Decode(data)
+	return value, err == nil, err
+}
+
+// GetBytes returns the key's serialized value, updating the "recently
+// used"-ness of the key.  The returned slice must not be modified.
+func (c *EncodedCache[K, V]) GetBytes(key K) (data []byte, ok bool) {
+	return c.cache.Get(key)
+}
+
+// Contains checks if a key is in the cache, without updating the
+// recent-ness or deleting it for being stale.
+func (c *EncodedCache[K, V]) Contains(key K) bool {
+	return c.cache.Contains(key)
+}
+
+// Remove removes the provided key from the cache.
+func (c *EncodedCache[K, V]) Remove(key K) (present bool) {
+	return c.cache.Remove(key)
+}
+
+// Resize changes the cache size.
+func (c *EncodedCache[K, V]) Resize(size int) (evicted int) {
+	return c.cache.Resize(size)
+}
+
+// Len returns the number of items in the cache.
+func (c *EncodedCache[K, V]) Len() int {
+	return c.cache.Len()
+}
+
+// Bytes returns the total size of the serialized values in the cache.
+func (c *EncodedCache[K, V]) Bytes() int {
+	c.cache.lock.Lock()
+	defer c.cache.unlock()
+
+	return c.bytes
+}
@@ -0,0 +1,65 @@
+package lru
+
+import (
+	"testing"
+)
+
+type encodedValue struct {
+	Name  string
+	Count int
+}
+
+func TestEncodedCache(t *testing.T) {
+	for name, codec := range map[string]Codec[encodedValue]{
+		"gob":  GobCodec[encodedValue]{},
+		"json": JSONCodec[encodedValue]{},
+	} {
+		t.Run(name, func(t *testing.T) {
+			l, err := NewEncoded[string, encodedValue](2, codec)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+
+			if _, err := l.Add("1", encodedValue{"one", 1}); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			v, ok, err := l.Get("1")
+			if err != nil || !ok || v.Name != "one" || v.Count != 1 {
+				t.Fatalf("bad value: %v, %v, %v", v, ok, err)
+			}
+			data, _ := l.GetBytes("1")
+			if l.Bytes() != len(data) {
+				t.Errorf("expected %d bytes, not %d", len(data), l.Bytes())
+			}
+
+			// overwriting a key replaces its bytes
+			if _, err := l.Add("1", encodedValue{"uno", 1}); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			data, _ = l.GetBytes("1")
+			if l.Bytes() != len(data) {
+				t.Errorf("expected %d bytes, not %d", len(data), l.Bytes())
+			}
+
+			l.Add("2", encodedValue{"two", 2})
+			l.Add("3", encodedValue{"three", 3})
+			if l.Len() != 2 {
+				t.Fatalf("bad len: %v", l.Len())
+			}
+			total := 0
+			for _, k := range []string{"1", "2", "3"} {
+				if data, ok := l.cache.Peek(k); ok {
+					total += len(data)
+				}
+			}
+			if l.Bytes() != total {
+				t.Errorf("expected %d bytes after eviction, not %d", total, l.Bytes())
+			}
+
+			l.Purge()
+			if l.Bytes() != 0 {
+				t.Errorf("expected 0 bytes after Purge, not %d", l.Bytes())
+			}
+		})
+	}
+}
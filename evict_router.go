@@ -0,0 +1,49 @@
+package lru
+
+import (
+	"sort"
+	"strings"
+)
+
+type evictRoute[V any] struct {
+	prefix string
+	fn     func(key string, value V)
+}
+
+// EvictRouter dispatches evicted entries to callbacks registered for key
+// prefixes, so a cache shared by several subsystems can route cleanup to
+// the subsystem owning each key.  Its OnEvict method is the eviction
+// callback to construct the cache with.  All routes must be registered
+// before the cache is used.
+type EvictRouter[V any] struct {
+	// routes is sorted longest prefix first, so the most specific
+	// registered prefix wins.
+	routes []evictRoute[V]
+}
+
+// Handle registers fn to be called for evicted keys starting with prefix.
+// An empty prefix matches every key not matched by a longer prefix.
+// Registering the same prefix again replaces its callback.
+func (r *EvictRouter[V]) Handle(prefix string, fn func(key string, value V)) {
+	for i := range r.routes {
+		if r.routes[i].prefix == prefix {
+			r.routes[i].fn = fn
+			return
+		}
+	}
+	r.routes = append(r.routes, evictRoute[V]{prefix, fn})
+	sort.SliceStable(r.routes, func(i, j int) bool {
+		return len(r.routes[i].prefix) > len(r.routes[j].prefix)
+	})
+}
+
+// OnEvict calls the callback registered for the longest prefix of key, if
+// any.
+func (r *EvictRouter[V]) OnEvict(key string, value V) {
+	for _, route := range r.routes {
+		if strings.HasPrefix(key, route.prefix) {
+			route.fn(key, value)
+			return
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package lru
+
+import (
+	"testing"
+)
+
+func TestEvictRouter(t *testing.T) {
+	var sessions, users, other []string
+	var router EvictRouter[int]
+	router.Handle("session:", func(k string, v int) {
+		sessions = append(sessions, k)
+	})
+	router.Handle("user:", func(k string, v int) {
+		users = append(users, k)
+	})
+	router.Handle("", func(k string, v int) {
+		other = append(other, k)
+	})
+	// the more specific prefix wins, regardless of registration order
+	var admins []string
+	router.Handle("user:admin:", func(k string, v int) {
+		admins = append(admins, k)
+	})
+
+	l, err := NewWithEvict[string, int](8, router.OnEvict)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add("session:1", 1)
+	l.Add("user:1", 1)
+	l.Add("user:admin:1", 1)
+	l.Add("misc", 1)
+	l.Purge()
+
+	if len(sessions) != 1 || sessions[0] != "session:1" {
+		t.Errorf("bad session evictions: %v", sessions)
+	}
+	if len(users) != 1 || users[0] != "user:1" {
+		t.Errorf("bad user evictions: %v", users)
+	}
+	if len(admins) != 1 || admins[0] != "user:admin:1" {
+		t.Errorf("bad admin evictions: %v", admins)
+	}
+	if len(other) != 1 || other[0] != "misc" {
+		t.Errorf("bad fallback evictions: %v", other)
+	}
+}
@@ -0,0 +1,104 @@
+package lru
+
+import (
+	"time"
+)
+
+// Expiration describes an entry that outlived its time-to-live.
+type Expiration[K comparable, V any] struct {
+	Key       K
+	Value     V
+	ExpiredAt time.Time
+}
+
+// expirationQueue batches a cache's expired entries for Expirations.  Like
+// mirror, entries are only collected and batches sent with the cache lock
+// held, so the channel can't be closed while being sent to.
+type expirationQueue[K comparable, V any] struct {
+	events  chan []Expiration[K, V]
+	pending []Expiration[K, V]
+	// dropped counts batches dropped because the channel was full.
+	dropped uint64
+	// closed is set once Close has closed events.
+	closed bool
+}
+
+func newExpirationQueue[K comparable, V any](size int) *expirationQueue[K, V] {
+	return &expirationQueue[K, V]{
+		events: make(chan []Expiration[K, V], size),
+	}
+}
+
+// expired records an entry that expired at the UnixNano time expires.
+func (q *expirationQueue[K, V]) expired(key K, value V, expires int64) {
+	if q.closed {
+		return
+	}
+	q.pending = append(q.pending, Expiration[K, V]{
+		Key:       key,
+		Value:     value,
+		ExpiredAt: time.Unix(0, expires),
+	})
+}
+
+// flush sends the pending entries as one batch, dropping it if the channel
+// is full: notifications are best effort, and must never hold up the
+// reaper.
+func (q *expirationQueue[K, V]) flush() {
+	if q.closed || len(q.pending) == 0 {
+		return
+	}
+	select {
+	case q.events <- q.pending:
+	default:
+		q.dropped++
+	}
+	q.pending = nil
+}
+
+// Expirations returns the channel that entries expiring in the cache are
+// sent to, one batch per reaper tick, or nil unless the cache was created
+// with Options.ExpirationQueue.  Batches include entries removed by the
+// reaper as well as those expired lazily by lookups or RemoveExpired since
+// the previous tick.  The channel is closed by Close.
+func (c *Cache[K, V]) Expirations() <-chan []Expiration[K, V] {
+	c.lock.Lock()
+	defer c.unlock()
+
+	if c.ext == nil || c.ext.expirations == nil {
+		return nil
+	}
+	return c.ext.expirations.events
+}
+
+// DroppedExpirations returns the number of batches of expired entries
+// dropped because the Expirations channel was full.
+func (c *Cache[K, V]) DroppedExpirations() uint64 {
+	c.lock.Lock()
+	defer c.unlock()
+
+	if c.ext == nil || c.ext.expirations == nil {
+		return 0
+	}
+	return c.ext.expirations.dropped
+}
+
+// flushExpirations sends the expired entries collected since the last reaper
+// tick.  c.lock must be held.
+func (c *Cache[K, V]) flushExpirations() {
+	if c.ext != nil && c.ext.expirations != nil {
+		c.ext.expirations.flush()
+	}
+}
+
+// stopExpirations closes the Expirations channel, if any, sending any
+// entries still pending first.  c.lock must be held.
+func (c *Cache[K, V]) stopExpirations() {
+	if c.ext == nil || c.ext.expirations == nil || c.ext.expirations.closed {
+		return
+	}
+	q := c.ext.expirations
+	q.flush()
+	close(q.events)
+	q.closed = true
+}
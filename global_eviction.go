@@ -0,0 +1,103 @@
+package lru
+
+import (
+	"sync/atomic"
+
+	"github.com/bpowers/approx-lru/internal/approxlru"
+)
+
+// globalProbes is the number of other shards sampled for an older victim
+// when a shard of a cache approximating a global eviction order fills up.
+const globalProbes = 4
+
+// globalEviction approximates a global eviction order across a sharded
+// cache's shards.  Shards stamp their entries with a shared logical clock,
+// so their recency can be compared, and a full shard evicts from whichever
+// sampled shard holds the oldest entry, taking over that shard's room.
+type globalEviction[V any] struct {
+	// clock is the shared logical time, advanced by every add.  It is
+	// first in the struct to keep it 64-bit aligned on 32-bit platforms.
+	clock  int64
+	shards []shard[V]
+}
+
+// NewShardedGlobal is like NewShardedWithEvict, but approximates a global
+// eviction order across shards rather than evicting from each shard
+// independently.  The shards share one logical clock, and a full shard
+// adding a new key samples a few other shards, evicting the oldest entry
+// it finds among them and itself.  Room moves with the evictions, so hot
+// shards grow at the expense of cold ones, which keep at least one entry,
+// and stale entries in a cold shard don't outlive far more recently used
+// ones in a hot shard.  In exchange, adds update a counter shared by every
+// shard, and lookups read it, so entries looked up between two adds rank
+// as equally recent.  Unbounded caches evict nothing, so for a size of
+// zero it is the same as NewShardedWithEvict.
+func NewShardedGlobal[V any](size, shardCount int, onEvicted func(key string, value V)) (*ShardedCache[V], error) {
+	c, err := NewShardedWithEvict[V](size, shardCount, onEvicted)
+	if err != nil || c.size == 0 {
+		return c, err
+	}
+	g := &globalEviction[V]{
+		clock:  1,
+		shards: c.shards,
+	}
+	for i := range c.shards {
+		c.shards[i].global = g
+	}
+	return c, nil
+}
+
+// tick advances the clock for an add to s, returning the new time.  s.mu
+// must be held.
+func (g *globalEviction[V]) tick(s *shard[V]) int64 {
+	now := atomic.AddInt64(&g.clock, 1)
+	s.lru.SetCounter(now)
+	return now
+}
+
+// read stamps a lookup in s with the current time, without advancing the
+// clock.  s.mu must be held.
+func (g *globalEviction[V]) read(s *shard[V]) {
+	s.lru.SetCounter(atomic.LoadInt64(&g.clock))
+}
+
+// makeRoom makes room in s, if it is full, for adding key, by evicting the
+// oldest entry found in a few other shards, if it is older than s's own
+// victim, and moving the victim's room to s.  Shards are picked from now, a
+// time unique to this add.  Contended shards are skipped rather than
+// waited for, so two shards making room at once can't deadlock.  s.mu
+// must be held.  Returns whether an entry was evicted.
+func (g *globalEviction[V]) makeRoom(s *shard[V], key string, now int64) (evicted bool) {
+	if s.lru.Len() < s.lru.Size() || s.lru.Contains(key) {
+		return false
+	}
+	_, best, ok := s.lru.OldestRank()
+	if !ok {
+		return false
+	}
+	var victim *shard[V]
+	var victimKey string
+	for i := 0; i < globalProbes; i++ {
+		o := &g.shards[mix64(uint64(now)+uint64(i))%uint64(len(g.shards))]
+		if o == s || o == victim || !o.mu.TryLock() {
+			continue
+		}
+		if key, rank, ok := o.lru.OldestRank(); ok && rank < best && o.lru.Size() > 1 {
+			if victim != nil {
+				victim.mu.Unlock()
+			}
+			victim, victimKey, best = o, key, rank
+			continue
+		}
+		o.mu.Unlock()
+	}
+	if victim == nil {
+		return false
+	}
+	victim.lru.RemoveWithReason(victimKey, approxlru.ReasonEvicted)
+	victim.lru.AdjustSize(-1)
+	victim.stats.evicted(1)
+	victim.mu.Unlock()
+	s.lru.AdjustSize(1)
+	return true
+}
@@ -0,0 +1,112 @@
+package lru
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// shardKeys returns n keys that hash to the given shard of c.
+func shardKeys[V any](c *ShardedCache[V], shard, n int, prefix string) []string {
+	var keys []string
+	for i := 0; len(keys) < n; i++ {
+		if key := prefix + strconv.Itoa(i); c.shardIndex(key) == shard {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func TestShardedGlobalEviction(t *testing.T) {
+	fill := func(c *ShardedCache[int]) (hot []string) {
+		// shards 1 through 7 fill up with entries that then go cold,
+		// while shard 0 keeps getting new ones
+		for shard := 1; shard < 8; shard++ {
+			for _, key := range shardKeys(c, shard, 8, "cold") {
+				c.Add(key, 0)
+			}
+		}
+		hot = shardKeys(c, 0, 100, "hot")
+		for _, key := range hot {
+			c.Add(key, 1)
+		}
+		return hot
+	}
+
+	local, err := NewShardedWithEvict[int](64, 8, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	fill(local)
+	if n := local.shards[0].lru.Len(); n != 8 {
+		t.Errorf("expected independent shards to keep their size: %d", n)
+	}
+
+	var evicted []string
+	c, err := NewShardedGlobal[int](64, 8, func(key string, value int) {
+		evicted = append(evicted, key)
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	hot := fill(c)
+	if n := c.shards[0].lru.Len(); n < 40 {
+		t.Errorf("expected the hot shard to take over the cold shards' room: %d", n)
+	}
+	size := 0
+	for i := range c.shards {
+		if n, s := c.shards[i].lru.Len(), c.shards[i].lru.Size(); s < 1 || n > s {
+			t.Errorf("bad shard %d: len %d, size %d", i, n, s)
+		}
+		size += c.shards[i].lru.Size()
+	}
+	if size != 64 || c.Len() != 64 {
+		t.Errorf("the total size should be unchanged: size %d, len %d", size, c.Len())
+	}
+	for _, key := range hot[len(hot)-8:] {
+		if !c.Contains(key) {
+			t.Errorf("expected recently added key %s to be kept", key)
+		}
+	}
+	if stats := c.Stats(); stats.Evictions != uint64(len(evicted)) || len(evicted) != 56+100-64 {
+		t.Errorf("bad evictions: %d counted, %d evicted", stats.Evictions, len(evicted))
+	}
+
+	// recently looked up entries in cold shards are kept
+	kept := shardKeys(c, 1, 1, "kept")[0]
+	c.Add(kept, 2)
+	for _, key := range shardKeys(c, 0, 200, "hotter")[100:] {
+		c.Get(kept)
+		c.Add(key, 3)
+	}
+	if !c.Contains(kept) {
+		t.Errorf("expected %s to be kept", kept)
+	}
+
+	if c, err := NewShardedGlobal[int](0, 8, nil); err != nil || c.shards[0].global != nil {
+		t.Errorf("unbounded caches shouldn't evict globally: %v", err)
+	}
+}
+
+func TestShardedGlobalEvictionConcurrent(t *testing.T) {
+	c, err := NewShardedGlobal[int](64, 8, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 2000; i++ {
+				key := strconv.Itoa(g*2000 + i)
+				c.Add(key, i)
+				c.Get(key)
+			}
+		}(g)
+	}
+	wg.Wait()
+	if n := c.Len(); n > 64 {
+		t.Errorf("the cache grew beyond its size: %d", n)
+	}
+}
@@ -0,0 +1,66 @@
+package lru
+
+import (
+	"context"
+	"time"
+)
+
+// timedValue is a loaded value and its time-to-live.
+type timedValue[V any] struct {
+	value V
+	ttl   time.Duration
+}
+
+type hedgeResult[V any] struct {
+	value V
+	err   error
+	// panicked is the value a panicking attempt was recovered with.
+	panicked interface{}
+}
+
+// hedgedLoad calls loader, and if it hasn't returned after delay, calls it
+// again concurrently, returning the first attempt to succeed, or the error
+// of the last to fail.  The ctx passed to the attempts is canceled once
+// one succeeds, so the other can give up.  A panic in either attempt is
+// repanicked in the caller.
+func hedgedLoad[K comparable, V any](ctx context.Context, key K, delay time.Duration, loader func(ctx context.Context, key K) (V, error)) (V, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan hedgeResult[V], 2)
+	attempt := func() {
+		r := hedgeResult[V]{err: errLoaderPanicked}
+		defer func() {
+			if p := recover(); p != nil {
+				r.panicked = p
+			}
+			results <- r
+		}()
+		r.value, r.err = loader(ctx, key)
+	}
+	go attempt()
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	// a first attempt failing before the delay isn't hedged: hedging
+	// works around slow loads, not failed ones
+	attempts := 1
+	var last hedgeResult[V]
+	for finished := 0; finished < attempts; {
+		select {
+		case <-timer.C:
+			attempts++
+			go attempt()
+		case r := <-results:
+			finished++
+			if r.panicked != nil {
+				panic(r.panicked)
+			}
+			if r.err == nil {
+				return r.value, nil
+			}
+			last = r
+		}
+	}
+	return last.value, last.err
+}
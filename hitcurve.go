@@ -0,0 +1,165 @@
+package lru
+
+import (
+	"container/list"
+	"errors"
+	"sort"
+)
+
+// HitRatioPoint is an estimate of the hit ratio a cache would have at a
+// given size.
+type HitRatioPoint struct {
+	Size     int
+	HitRatio float64
+}
+
+// ghostCache is an exact LRU of keys alone, simulating a cache of a given
+// size on a sample of the keyspace.
+type ghostCache[K comparable] struct {
+	size int
+	// capacity is the number of sampled keys a cache of size holds.
+	capacity int
+	order    *list.List // of K, most recently used first
+	elems    map[K]*list.Element
+
+	hits    uint64
+	lookups uint64
+}
+
+// touch moves key to the front of the ghost cache, adding it and evicting
+// the least recently used key if needed.  Returns whether it was present.
+func (g *ghostCache[K]) touch(key K) bool {
+	if elem, ok := g.elems[key]; ok {
+		g.order.MoveToFront(elem)
+		return true
+	}
+	g.elems[key] = g.order.PushFront(key)
+	if g.order.Len() > g.capacity {
+		delete(g.elems, g.order.Remove(g.order.Back()).(K))
+	}
+	return false
+}
+
+func (g *ghostCache[K]) remove(key K) {
+	if elem, ok := g.elems[key]; ok {
+		g.order.Remove(elem)
+		delete(g.elems, key)
+	}
+}
+
+// hitRatioCurve estimates a cache's hit ratio at other sizes by running
+// miniature simulations of them: ghost caches scaled down by sampleRate,
+// fed with the lookups and adds of the keys whose hash is a multiple of
+// sampleRate.
+type hitRatioCurve[K comparable] struct {
+	hash       func(key K) uint64
+	sampleRate uint64
+	ghosts     []*ghostCache[K]
+}
+
+func newHitRatioCurve[K comparable](sizes []int, sampleRate int, hash func(key K) uint64) (*hitRatioCurve[K], error) {
+	if sampleRate <= 0 {
+		sampleRate = 1
+	}
+	if sampleRate > 1 && hash == nil {
+		if hash = defaultKeyHash[K](); hash == nil {
+			return nil, errors.New("must provide a KeyHash with HitRatioCurveSampleRate for this key type")
+		}
+	}
+	h := &hitRatioCurve[K]{
+		hash:       hash,
+		sampleRate: uint64(sampleRate),
+	}
+	sizes = append([]int(nil), sizes...)
+	sort.Ints(sizes)
+	for _, size := range sizes {
+		if size <= 0 {
+			return nil, errors.New("must provide positive HitRatioCurve sizes")
+		}
+		capacity := size / sampleRate
+		if capacity == 0 {
+			capacity = 1
+		}
+		h.ghosts = append(h.ghosts, &ghostCache[K]{
+			size:     size,
+			capacity: capacity,
+			order:    list.New(),
+			elems:    make(map[K]*list.Element),
+		})
+	}
+	return h, nil
+}
+
+func (h *hitRatioCurve[K]) sampled(key K) bool {
+	return h.sampleRate == 1 || h.hash(key)%h.sampleRate == 0
+}
+
+// used records a lookup of key.  Like a cache filled on misses, the ghost
+// caches add keys they miss.
+func (h *hitRatioCurve[K]) used(key K) {
+	if !h.sampled(key) {
+		return
+	}
+	for _, g := range h.ghosts {
+		g.lookups++
+		if g.touch(key) {
+			g.hits++
+		}
+	}
+}
+
+// added records that key was added to the cache.
+func (h *hitRatioCurve[K]) added(key K) {
+	if !h.sampled(key) {
+		return
+	}
+	for _, g := range h.ghosts {
+		g.touch(key)
+	}
+}
+
+// removed records that key was removed from the cache other than to make
+// room, for example when invalidated, so that the ghost caches miss it
+// too.
+func (h *hitRatioCurve[K]) removed(key K) {
+	if !h.sampled(key) {
+		return
+	}
+	for _, g := range h.ghosts {
+		g.remove(key)
+	}
+}
+
+// points returns the estimated hit ratio at each simulated size, smallest
+// first.
+func (h *hitRatioCurve[K]) points() []HitRatioPoint {
+	points := make([]HitRatioPoint, len(h.ghosts))
+	for i, g := range h.ghosts {
+		points[i].Size = g.size
+		if g.lookups > 0 {
+			points[i].HitRatio = float64(g.hits) / float64(g.lookups)
+		}
+	}
+	return points
+}
+
+func (h *hitRatioCurve[K]) reset() {
+	for _, g := range h.ghosts {
+		g.hits, g.lookups = 0, 0
+	}
+}
+
+// HitRatioCurve estimates the hit ratio the cache would have had at each of
+// the sizes in Options.HitRatioCurve, smallest first, since it was created
+// or its stats were last reset.  Comparing it against Stats.HitRatio shows
+// whether growing the cache would actually help.  It returns nil for other
+// caches.
+func (c *Cache[K, V]) HitRatioCurve() []HitRatioPoint {
+	c.lock.Lock()
+	defer c.unlock()
+
+	if c.ext == nil || c.ext.curve == nil {
+		return nil
+	}
+	return c.ext.curve.points()
+}
@@ -0,0 +1,78 @@
+package lru
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLRUHitRatioCurve(t *testing.T) {
+	if _, err := NewWithOptions[string, int](8, Options[string, int]{HitRatioCurve: []int{0}}); err == nil {
+		t.Errorf("expected a non-positive size to be rejected")
+	}
+
+	l, err := NewWithOptions[int, int](200, Options[int, int]{
+		HitRatioCurve: []int{200, 50, 100},
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	// loop over 100 keys 10 times: an LRU smaller than the loop never
+	// hits, and a larger one only misses on the first pass
+	for pass := 0; pass < 10; pass++ {
+		for i := 0; i < 100; i++ {
+			if _, ok := l.Get(i); !ok {
+				l.Add(i, i)
+			}
+		}
+	}
+	curve := l.HitRatioCurve()
+	want := []HitRatioPoint{{50, 0}, {100, 0.9}, {200, 0.9}}
+	if len(curve) != len(want) {
+		t.Fatalf("bad curve: %v", curve)
+	}
+	for i := range want {
+		if curve[i].Size != want[i].Size || math.Abs(curve[i].HitRatio-want[i].HitRatio) > 1e-9 {
+			t.Errorf("bad curve: %v", curve)
+		}
+	}
+	if ratio := l.Stats().HitRatio(); math.Abs(ratio-0.9) > 1e-9 {
+		t.Errorf("the curve should agree with the cache itself: %v", ratio)
+	}
+
+	// removed keys miss in the ghost caches too
+	l.ResetStats()
+	l.Remove(0)
+	l.Get(0)
+	if curve := l.HitRatioCurve(); curve[2].HitRatio != 0 {
+		t.Errorf("expected a miss: %v", curve)
+	}
+
+	if curve := (&Cache[int, int]{}).HitRatioCurve(); curve != nil {
+		t.Errorf("expected no curve: %v", curve)
+	}
+}
+
+func TestLRUHitRatioCurveSampled(t *testing.T) {
+	l, err := NewWithOptions[int, int](8, Options[int, int]{
+		HitRatioCurve:           []int{40, 4000},
+		HitRatioCurveSampleRate: 4,
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for pass := 0; pass < 10; pass++ {
+		for i := 0; i < 1000; i++ {
+			l.Get(i)
+		}
+	}
+	curve := l.HitRatioCurve()
+	if curve[0].HitRatio != 0 {
+		t.Errorf("a sampled cache smaller than the loop shouldn't hit: %v", curve)
+	}
+	if math.Abs(curve[1].HitRatio-0.9) > 1e-9 {
+		t.Errorf("a sampled cache larger than the loop should hit after one pass: %v", curve)
+	}
+	if sampled := len(l.ext.curve.ghosts[1].elems); sampled < 150 || sampled > 350 {
+		t.Errorf("expected about a quarter of the keys to be sampled: %d", sampled)
+	}
+}
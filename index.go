@@ -0,0 +1,68 @@
+package lru
+
+// secondaryIndex keeps the entries of a cache findable by a secondary key
+// extracted from each of them.
+type secondaryIndex[K comparable, V any] struct {
+	extract func(key K, value V) string
+	keys    *tagIndex[K]
+}
+
+// index updates the secondary indexes for a just added entry.  c.lock must
+// be held.
+func (e *cacheExt[K, V]) index(key K, value V) {
+	for _, idx := range e.indexes {
+		if secondary := idx.extract(key, value); secondary != "" {
+			idx.keys.set(key, []string{secondary})
+		} else {
+			idx.keys.remove(key)
+		}
+	}
+}
+
+// GetBySecondary looks up the entries whose secondary key in the named
+// index is secondary, updating their "recently used"-ness.  Indexes are
+// registered with Options.Indexes.  Returns the matching entries in no
+// particular order.
+func (c *Cache[K, V]) GetBySecondary(index, secondary string) []Entry[K, V] {
+	c.lock.Lock()
+	defer c.unlock()
+
+	if c.ext == nil {
+		return nil
+	}
+	idx, ok := c.ext.indexes[index]
+	if !ok {
+		return nil
+	}
+	var entries []Entry[K, V]
+	for _, key := range idx.keys.keys(secondary) {
+		if value, ok := c.get(key); ok {
+			entries = append(entries, Entry[K, V]{Key: key, Value: value})
+		}
+	}
+	return entries
+}
+
+// RemoveBySecondary removes the entries whose secondary key in the named
+// index is secondary, returning the number of entries removed.  Unlike
+// RemovePrefix, it only visits the matching entries, so an index
+// extracting, say, the tenant of each key makes invalidating a tenant's
+// entries cheap.
+func (c *Cache[K, V]) RemoveBySecondary(index, secondary string) (removed int) {
+	c.lock.Lock()
+	defer c.unlock()
+
+	if c.ext == nil {
+		return 0
+	}
+	idx, ok := c.ext.indexes[index]
+	if !ok {
+		return 0
+	}
+	for _, key := range idx.keys.keys(secondary) {
+		if _, ok := c.remove(key); ok {
+			removed++
+		}
+	}
+	return removed
+}
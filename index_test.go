@@ -0,0 +1,95 @@
+package lru
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+type session struct {
+	user string
+}
+
+func TestLRUGetBySecondary(t *testing.T) {
+	l, err := NewWithOptions[string, session](3, Options[string, session]{
+		Indexes: map[string]func(key string, value session) string{
+			"user": func(key string, value session) string {
+				return value.user
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	userSessions := func(user string) []string {
+		var ids []string
+		for _, e := range l.GetBySecondary("user", user) {
+			if e.Value.user != user {
+				t.Errorf("session %s belongs to %q, not %q", e.Key, e.Value.user, user)
+			}
+			ids = append(ids, e.Key)
+		}
+		sort.Strings(ids)
+		return ids
+	}
+
+	l.Add("s1", session{"alice"})
+	l.Add("s2", session{"alice"})
+	l.Add("s3", session{"bob"})
+	if ids := userSessions("alice"); len(ids) != 2 || ids[0] != "s1" || ids[1] != "s2" {
+		t.Errorf("bad sessions for alice: %v", ids)
+	}
+
+	// updates move entries between secondary keys
+	l.Add("s2", session{"bob"})
+	if ids := userSessions("alice"); len(ids) != 1 || ids[0] != "s1" {
+		t.Errorf("bad sessions for alice: %v", ids)
+	}
+	if ids := userSessions("bob"); len(ids) != 2 {
+		t.Errorf("bad sessions for bob: %v", ids)
+	}
+
+	// unindexed entries, removals and evictions leave the index
+	l.Add("s3", session{})
+	l.Remove("s2")
+	if ids := userSessions("bob"); len(ids) != 0 {
+		t.Errorf("bob should have no sessions: %v", ids)
+	}
+	l.Add("s4", session{"carol"})
+	l.Add("s5", session{"carol"}) // evicts s1
+	if ids := userSessions("alice"); len(ids) != 0 {
+		t.Errorf("alice should have no sessions: %v", ids)
+	}
+
+	if entries := l.GetBySecondary("missing", "carol"); entries != nil {
+		t.Errorf("unknown indexes should find nothing: %v", entries)
+	}
+}
+
+func TestLRURemoveBySecondary(t *testing.T) {
+	l, err := NewWithOptions[string, int](8, Options[string, int]{
+		Indexes: map[string]func(key string, value int) string{
+			"tenant": func(key string, value int) string {
+				tenant, _, _ := strings.Cut(strings.TrimPrefix(key, "tenant:"), ":")
+				return tenant
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for _, key := range []string{"tenant:1:/a", "tenant:1:/b", "tenant:2:/a", "tenant:10:/a"} {
+		l.Add(key, 0)
+	}
+
+	if removed := l.RemoveBySecondary("tenant", "1"); removed != 2 {
+		t.Errorf("expected 2 removals, not %d", removed)
+	}
+	if l.Len() != 2 || l.Contains("tenant:1:/a") || !l.Contains("tenant:10:/a") {
+		t.Errorf("only tenant 1's entries should be removed: %v", l.Keys())
+	}
+	if removed := l.RemoveBySecondary("missing", "2"); removed != 0 {
+		t.Errorf("unknown indexes should remove nothing: %d", removed)
+	}
+}
@@ -4,7 +4,9 @@ import (
 	crand "crypto/rand"
 	"encoding/binary"
 	"errors"
+	"math"
 	"math/rand"
+	"sync/atomic"
 
 	"golang.org/x/exp/slices"
 )
@@ -23,6 +25,28 @@ func newRand() *rand.Rand {
 // EvictCallback is used to get a callback when a cache entry is evicted
 type EvictCallback[K comparable, V any] func(key K, value V)
 
+// EvictReason describes why an entry left the cache.
+type EvictReason uint8
+
+const (
+	// ReasonEvicted means the entry was evicted to make room for another.
+	ReasonEvicted EvictReason = iota
+	// ReasonRemoved means the entry was explicitly removed.
+	ReasonRemoved
+	// ReasonPurged means the entry was removed by Purge.
+	ReasonPurged
+	// ReasonResized means the entry was evicted by Resize shrinking the cache.
+	ReasonResized
+	// ReasonExpired means the entry outlived its time-to-live.
+	ReasonExpired
+	// ReasonInvalid means the entry failed validation when looked up.
+	ReasonInvalid
+)
+
+// EvictReasonCallback is used to get a callback, along with the reason,
+// when a cache entry is evicted
+type EvictReasonCallback[K comparable, V any] func(key K, value V, reason EvictReason)
+
 // LRUStructSize is the size of the LRU struct -- there is a unit test to ensure
 // this const matches the size measured with `unsafe.Sizeof`.
 // TODO: move this to a file that is built only on 64-bit architectures and
@@ -38,7 +62,7 @@ type LRU[K comparable, V any] struct {
 	counter int64
 	size    int64
 	rng     rand.Rand
-	onEvict EvictCallback[K, V]
+	onEvict EvictReasonCallback[K, V]
 }
 
 // randomProbes is the number of elements we consider for eviction at a time,
@@ -53,10 +77,23 @@ type entry[K comparable, V any] struct {
 }
 
 // NewLRU constructs an LRU of the given size.  Memory for the full capacity of the
-// LRU cache is allocated upfront.
+// LRU cache is allocated upfront.  A size of zero creates an unbounded cache
+// that never evicts entries to make room for new ones.
 func NewLRU[K comparable, V any](size int, onEvict EvictCallback[K, V]) (*LRU[K, V], error) {
-	if size <= 0 {
-		return nil, errors.New("must provide a positive size")
+	var onEvictReason EvictReasonCallback[K, V]
+	if onEvict != nil {
+		onEvictReason = func(key K, value V, _ EvictReason) {
+			onEvict(key, value)
+		}
+	}
+	return NewLRUWithReason(size, onEvictReason)
+}
+
+// NewLRUWithReason constructs an LRU of the given size, whose eviction
+// callback is told why each entry left the cache.
+func NewLRUWithReason[K comparable, V any](size int, onEvict EvictReasonCallback[K, V]) (*LRU[K, V], error) {
+	if size < 0 {
+		return nil, errors.New("must provide a non-negative size")
 	}
 	c := &LRU[K, V]{
 		data:    make([]entry[K, V], 0, size),
@@ -66,9 +103,33 @@ func NewLRU[K comparable, V any](size int, onEvict EvictCallback[K, V]) (*LRU[K,
 		rng:     *newRand(),
 		onEvict: onEvict,
 	}
+	if size == 0 {
+		c.size = unboundedSize
+	}
 	return c, nil
 }
 
+// unboundedSize is the size of unbounded caches, which never fill up.
+const unboundedSize = math.MaxInt64
+
+// capacity returns how many entries to allocate room for up front.
+func (c *LRU[K, V]) capacity() int {
+	if c.size == unboundedSize {
+		return 0
+	}
+	return int(c.size)
+}
+
+// EvictCallback returns the cache's eviction callback.
+func (c *LRU[K, V]) EvictCallback() EvictReasonCallback[K, V] {
+	return c.onEvict
+}
+
+// SetEvictCallback replaces the cache's eviction callback.
+func (c *LRU[K, V]) SetEvictCallback(onEvict EvictReasonCallback[K, V]) {
+	c.onEvict = onEvict
+}
+
 func (c *LRU[K, V]) getCounter() int64 {
 	// if someone initializes a LRU as `&simplelru.LRU` directly, c.counter will
 	// be initialized to zero.  increment it to 1 to avoid Problems (we use 0 as
@@ -82,19 +143,26 @@ func (c *LRU[K, V]) getCounter() int64 {
 	return n
 }
 
+// SetCounter sets the logical time the cache's next add or lookup is
+// stamped with.  LRUs sharing a clock set it before each operation, so
+// that the recency of their entries can be compared.
+func (c *LRU[K, V]) SetCounter(n int64) {
+	c.counter = n
+}
+
 // Purge is used to completely clear the cache.
 func (c *LRU[K, V]) Purge() {
 	// only iterate through the items if we have an eviction callback registered.
 	if c.onEvict != nil {
 		for k, i := range c.items {
 			if entry := &c.data[i]; entry.lastUsed > 0 {
-				c.onEvict(k, entry.value)
+				c.onEvict(k, entry.value, ReasonPurged)
 			}
 		}
 	}
 
 	c.data = c.data[:0]
-	c.items = make(map[K]int, c.size)
+	c.items = make(map[K]int, c.capacity())
 }
 
 //go:noinline
@@ -104,46 +172,75 @@ func (c *LRU[K, V]) shuffle() {
 
 // Add adds a value to the cache.  Returns true if an eviction occurred.
 func (c *LRU[K, V]) Add(key K, value V) (evicted bool) {
-	now := c.getCounter()
+	_, evicted = c.AddIfAdmitted(key, value, nil)
+	return evicted
+}
+
+// AddIfAdmitted adds a value to the cache like Add, except that when a new
+// key can only be added by evicting another entry, admit is first asked
+// whether the new key should replace that victim.  If it returns false,
+// the cache is left unchanged.  A nil admit admits every key.  Returns
+// whether the value was added and whether an eviction occurred.
+func (c *LRU[K, V]) AddIfAdmitted(key K, value V, admit func(victim K) bool) (added, evicted bool) {
 	// Check for existing item
 	if i, ok := c.items[key]; ok {
 		entry := &c.data[i]
-		entry.lastUsed = now
+		entry.lastUsed = c.getCounter() | entry.lastUsed&pinnedBit
 		entry.value = value
-		return false
-	}
-
-	// if we were asked to be a zero-sized cache, return early
-	if c.size == 0 {
-		return
+		return true, false
 	}
 
 	// Add new item
-	ent := entry[K, V]{now, key, value}
+	ent := entry[K, V]{0, key, value}
 
+	// a cache resized to zero stores nothing
+	if c.size == 0 {
+		return false, false
+	}
 	if int64(len(c.data)) == c.size {
-		evicted = true
 		if i, ok := c.findOldest(); ok {
-			c.removeElement(i, c.data[i], false)
+			if admit != nil && !admit(c.data[i].key) {
+				return false, false
+			}
+			ent.lastUsed = c.getCounter()
+			c.removeElement(i, c.data[i], false, ReasonEvicted)
 			c.data[i] = ent
 			c.items[ent.key] = i
-		} else {
-			panic("invariant broken")
+			return true, true
 		}
-		return
+	}
+	ent.lastUsed = c.getCounter()
+
+	// we can only be over capacity if entries were pinned: evict
+	// unpinned entries to get back under it, or if every entry is pinned,
+	// exceed it until they are unpinned.
+	for int64(len(c.data)) >= c.size {
+		i, ok := c.findOldest()
+		if !ok {
+			break
+		}
+		c.removeElement(i, c.data[i], true, ReasonEvicted)
+		evicted = true
 	}
 
 	c.addShuffled(ent)
 
-	return
+	return true, evicted
 }
 
-// invarant: must have space in the array
-func (c *LRU[K, V]) addShuffled(ent entry[K, V]) {
-	if int64(len(c.data)) == c.size {
-		panic("invariant broken")
-	}
+// AddWithoutCallback adds a value to the cache like Add, but does not invoke
+// the eviction callback for any entry evicted to make room for it.
+func (c *LRU[K, V]) AddWithoutCallback(key K, value V) (evicted bool) {
+	onEvict := c.onEvict
+	c.onEvict = nil
+	evicted = c.Add(key, value)
+	c.onEvict = onEvict
+	return evicted
+}
 
+// addShuffled appends ent at a random position.  The array must have space,
+// unless every entry is pinned.
+func (c *LRU[K, V]) addShuffled(ent entry[K, V]) {
 	i := len(c.data)
 
 	c.data = append(c.data, ent)
@@ -174,7 +271,23 @@ func (c *LRU[K, V]) Get(key K) (value V, ok bool) {
 			var d V
 			return d, false
 		}
-		entry.lastUsed = c.getCounter()
+		entry.lastUsed = c.getCounter() | entry.lastUsed&pinnedBit
+		return entry.value, true
+	}
+	return
+}
+
+// GetConcurrent looks up a key's value from the cache like Get, but may be
+// called concurrently with other calls to GetConcurrent, Peek, Contains and
+// Len, as long as nothing else modifies the cache at the same time (for
+// example, under the read side of a sync.RWMutex).  Rather than advancing
+// the counter, it stamps the entry with the counter's current value, so
+// entries read between the same two writes share a timestamp.
+func (c *LRU[K, V]) GetConcurrent(key K) (value V, ok bool) {
+	if i, ok := c.items[key]; ok {
+		entry := &c.data[i]
+		lastUsed := atomic.LoadInt64(&entry.lastUsed)
+		atomic.StoreInt64(&entry.lastUsed, atomic.LoadInt64(&c.counter)|lastUsed&pinnedBit)
 		return entry.value, true
 	}
 	return
@@ -200,18 +313,204 @@ func (c *LRU[K, V]) Peek(key K) (value V, ok bool) {
 // key was contained.
 func (c *LRU[K, V]) Remove(key K) (present bool) {
 	if i, ok := c.items[key]; ok {
-		c.removeElement(i, c.data[i], true)
+		c.removeElement(i, c.data[i], true, ReasonRemoved)
 		return true
 	}
 	return false
 }
 
+// RemoveAndGet removes the provided key from the cache, returning the
+// removed value and if the key was contained.
+func (c *LRU[K, V]) RemoveAndGet(key K) (value V, present bool) {
+	return c.RemoveWithReason(key, ReasonRemoved)
+}
+
+// RemoveWithReason removes the provided key from the cache, telling the
+// eviction callback it left for the given reason.  Returns the removed value
+// and if the key was contained.
+func (c *LRU[K, V]) RemoveWithReason(key K, reason EvictReason) (value V, present bool) {
+	if i, ok := c.items[key]; ok {
+		ent := c.data[i]
+		c.removeElement(i, ent, true, reason)
+		return ent.value, true
+	}
+	return value, false
+}
+
+// RemoveOldest removes an old entry from the cache (approximately _the_
+// oldest), as if it were evicted to make room for another.  Returns the
+// removed entry and whether the cache had any entries.
+func (c *LRU[K, V]) RemoveOldest() (key K, value V, ok bool) {
+	i, ok := c.findOldest()
+	if !ok {
+		return key, value, false
+	}
+	ent := c.data[i]
+	c.removeElement(i, ent, true, ReasonEvicted)
+	return ent.key, ent.value, true
+}
+
+// OldestRank returns an old, unpinned entry's key and rank, as RemoveOldest
+// would remove it, without updating its recent-ness.  Ranks are comparable
+// as in AppendRanked.  If every entry is pinned, ok is false.
+func (c *LRU[K, V]) OldestRank() (key K, rank int64, ok bool) {
+	i, ok := c.findOldest()
+	if !ok {
+		return key, 0, false
+	}
+	return c.data[i].key, c.data[i].lastUsed, true
+}
+
+// pinnedBit is set in the lastUsed of pinned entries.  It makes them look
+// more recently used than any unpinned entry to the eviction probes, and
+// survives updates to their recent-ness.
+const pinnedBit = 1 << 62
+
+// SetPinned pins or unpins the entry for key, returning whether it is
+// present.  Pinned entries are never evicted, by Add or Resize; if every
+// entry is pinned, Add grows the cache beyond its size until entries are
+// unpinned.
+func (c *LRU[K, V]) SetPinned(key K, pinned bool) (present bool) {
+	i, ok := c.items[key]
+	if !ok {
+		return false
+	}
+	if pinned {
+		c.data[i].lastUsed |= pinnedBit
+	} else {
+		c.data[i].lastUsed &^= pinnedBit
+	}
+	return true
+}
+
+// Oldest returns the least recently used entry, without updating its
+// recent-ness.  Unlike RemoveOldest it is exact, and so O(n) expensive.
+// Pinned entries are only returned if every entry is pinned.
+func (c *LRU[K, V]) Oldest() (key K, value V, ok bool) {
+	if len(c.data) == 0 {
+		return key, value, false
+	}
+	oldest := &c.data[0]
+	for i := 1; i < len(c.data); i++ {
+		if c.data[i].lastUsed < oldest.lastUsed {
+			oldest = &c.data[i]
+		}
+	}
+	return oldest.key, oldest.value, true
+}
+
+// OldestUnpinned returns the least recently used unpinned entry, without
+// updating its recent-ness.  Like Oldest it is exact, and O(n) expensive.
+// If every entry is pinned, ok is false.
+func (c *LRU[K, V]) OldestUnpinned() (key K, value V, ok bool) {
+	i, ok := c.oldestUnpinned()
+	if !ok {
+		return key, value, false
+	}
+	return c.data[i].key, c.data[i].value, true
+}
+
+// KeysPage appends up to limit keys to dst, starting from the entry at
+// offset in the cache's internal storage, returning the offset to resume
+// from and whether any entries remain.  Entries move around as the cache
+// is modified, so paging over a changing cache may skip or repeat keys.
+func (c *LRU[K, V]) KeysPage(dst []K, offset, limit int) (keys []K, next int, more bool) {
+	if offset < 0 {
+		offset = 0
+	}
+	end := len(c.data)
+	if limit > 0 && offset+limit < end {
+		end = offset + limit
+	}
+	for i := offset; i < end; i++ {
+		dst = append(dst, c.data[i].key)
+	}
+	if end < offset {
+		end = offset
+	}
+	return dst, end, end < len(c.data)
+}
+
+// Range calls fn for each entry in the cache, from least to most recently
+// used, until fn returns false.  It doesn't update the recent-ness of
+// entries, and ranges over a copy, so fn may modify the cache.  It is
+// O(n * log(n)) expensive.
+func (c *LRU[K, V]) Range(fn func(key K, value V) bool) {
+	ordered := c.AppendRanked(make([]RankedEntry[K, V], 0, len(c.data)))
+	SortRanked(ordered)
+	for _, ent := range ordered {
+		if !fn(ent.Key, ent.Value) {
+			return
+		}
+	}
+}
+
+// RankedEntry is an entry along with its rank in the cache's recency
+// order: entries with lower ranks were used less recently.
+type RankedEntry[K comparable, V any] struct {
+	Key   K
+	Value V
+	Rank  int64
+}
+
+// AppendRanked appends the cache's entries to dst, in no particular order.
+// Unlike Range it is only O(n) expensive, so callers holding a lock can
+// copy the entries quickly and order them with SortRanked once it is
+// released.
+func (c *LRU[K, V]) AppendRanked(dst []RankedEntry[K, V]) []RankedEntry[K, V] {
+	for i := range c.data {
+		ent := &c.data[i]
+		dst = append(dst, RankedEntry[K, V]{Key: ent.key, Value: ent.value, Rank: ent.lastUsed &^ pinnedBit})
+	}
+	return dst
+}
+
+// SortRanked sorts entries from least to most recently used.
+func SortRanked[K comparable, V any](entries []RankedEntry[K, V]) {
+	slices.SortFunc(entries, func(a, b RankedEntry[K, V]) bool {
+		return a.Rank < b.Rank
+	})
+}
+
 // Len returns the number of items in the cache.
 func (c *LRU[K, V]) Len() int {
 	return len(c.items)
 }
 
+// Size returns the maximum number of items in the cache, or zero if it is
+// unbounded.
+func (c *LRU[K, V]) Size() int {
+	return c.capacity()
+}
+
+// Unbounded reports whether the cache is unbounded.
+func (c *LRU[K, V]) Unbounded() bool {
+	return c.size == unboundedSize
+}
+
+// SetUnbounded removes the cache's size limit, so it never evicts entries
+// to make room for new ones.  Resize bounds it again.
+func (c *LRU[K, V]) SetUnbounded() {
+	c.size = unboundedSize
+}
+
+// AdjustSize changes the size of a bounded cache by delta.  Unlike Resize
+// it is O(1), never evicting entries or reallocating storage, so it must
+// not shrink the cache below its length.
+func (c *LRU[K, V]) AdjustSize(delta int) {
+	if c.size == unboundedSize {
+		return
+	}
+	if c.size+int64(delta) < int64(len(c.data)) {
+		panic("invariant broken")
+	}
+	c.size += int64(delta)
+}
+
 // Resize changes the cache size -- it is O(n * log(n)) expensive, and is best avoided.
+// Resizing to zero evicts every unpinned entry, and leaves a cache that
+// stores nothing until resized again; use SetUnbounded to remove the
+// size limit.
 func (c *LRU[K, V]) Resize(size int) (evicted int) {
 	diff := c.Len() - size
 	if diff < 0 {
@@ -233,15 +532,24 @@ func (c *LRU[K, V]) Resize(size int) (evicted int) {
 	}
 
 	// we may be downsizing the cache -- remove the oldest entries if so.
+	// pinned entries sort first, and are never removed.
 	oldSize := len(c.data)
 	for i := 0; i < diff; i++ {
 		j := oldSize - 1 - i
-		c.removeElement(j, c.data[j], true)
+		if c.data[j].lastUsed&pinnedBit != 0 {
+			diff = i
+			break
+		}
+		c.removeElement(j, c.data[j], true, ReasonResized)
 	}
 
 	c.size = int64(size)
 	if size < oldSize {
-		c.data = c.data[:size]
+		// if we shrunk substantially, don't hold on to the old (large)
+		// backing array and map buckets.
+		if size < cap(c.data)/compactRatio {
+			c.Compact()
+		}
 	} else {
 		oldData := c.data
 		c.data = make([]entry[K, V], oldSize, size)
@@ -251,8 +559,61 @@ func (c *LRU[K, V]) Resize(size int) (evicted int) {
 	return diff
 }
 
-// findOldest identifies an old item from the cache (approximately _the_ oldest).
+// compactRatio is how much larger than the cache's size its internal storage
+// must be before Resize automatically compacts it.
+const compactRatio = 4
+
+// Compact reallocates the cache's internal storage to fit its current size,
+// releasing memory retained after shrinking the cache with Resize.  It is
+// O(n) expensive.
+func (c *LRU[K, V]) Compact() {
+	oldData := c.data
+	capacity := c.capacity()
+	if capacity < len(oldData) {
+		// unbounded caches are compacted down to their current length
+		capacity = len(oldData)
+	}
+	c.data = make([]entry[K, V], len(oldData), capacity)
+	copy(c.data, oldData)
+
+	c.items = make(map[K]int, capacity)
+	for i, entry := range c.data {
+		// if lastUsed is zero, the entry is actually empty/not-set.
+		if entry.lastUsed == 0 {
+			continue
+		}
+		c.items[entry.key] = i
+	}
+}
+
+// findOldest identifies an old, unpinned item from the cache (approximately
+// _the_ oldest).  If every entry is pinned, ok is false.
 func (c *LRU[K, V]) findOldest() (off int, ok bool) {
+	off, ok = c.probeOldest()
+	if ok && c.data[off].lastUsed&pinnedBit != 0 {
+		// all the probed entries were pinned, so fall back to scanning
+		// for the oldest unpinned entry.
+		return c.oldestUnpinned()
+	}
+	return off, ok
+}
+
+// oldestUnpinned returns the offset of the exact oldest unpinned entry.
+func (c *LRU[K, V]) oldestUnpinned() (off int, ok bool) {
+	off = -1
+	for i := range c.data {
+		if c.data[i].lastUsed&pinnedBit != 0 {
+			continue
+		}
+		if off < 0 || c.data[i].lastUsed < c.data[off].lastUsed {
+			off = i
+		}
+	}
+	return off, off >= 0
+}
+
+// probeOldest probes randomProbes entries, returning the oldest of them.
+func (c *LRU[K, V]) probeOldest() (off int, ok bool) {
 	size := c.Len()
 	if size <= 0 {
 		return -1, false
@@ -292,8 +653,8 @@ func (c *LRU[K, V]) findOldest() (off int, ok bool) {
 }
 
 // removeElement is used to remove a given list element from the cache
-func (c *LRU[K, V]) removeElement(i int, ent entry[K, V], doSwap bool) {
-	if int64(i) >= c.size || len(c.data) == 0 {
+func (c *LRU[K, V]) removeElement(i int, ent entry[K, V], doSwap bool, reason EvictReason) {
+	if i >= len(c.data) {
 		panic("invariant broken")
 	}
 
@@ -309,6 +670,6 @@ func (c *LRU[K, V]) removeElement(i int, ent entry[K, V], doSwap bool) {
 	delete(c.items, ent.key)
 
 	if c.onEvict != nil {
-		c.onEvict(ent.key, ent.value)
+		c.onEvict(ent.key, ent.value, reason)
 	}
 }
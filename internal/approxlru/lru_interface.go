@@ -7,6 +7,11 @@ type LRUCache[K comparable, V any] interface {
 	// updates the "recently used"-ness of the key.
 	Add(key K, value V) bool
 
+	// Adds a value to the cache, unless admit rejects replacing the entry
+	// it would evict.  Returns whether it was added and whether an
+	// eviction occurred.
+	AddIfAdmitted(key K, value V, admit func(victim K) bool) (added, evicted bool)
+
 	// Returns key's value from the cache and
 	// updates the "recently used"-ness of the key. #value, isFound
 	Get(key K) (value V, ok bool)
@@ -20,6 +25,24 @@ type LRUCache[K comparable, V any] interface {
 	// Removes a key from the cache.
 	Remove(key K) bool
 
+	// Removes a key from the cache, returning its value.
+	RemoveAndGet(key K) (value V, ok bool)
+
+	// Removes an approximately oldest entry from the cache.
+	RemoveOldest() (key K, value V, ok bool)
+
+	// Returns the oldest entry without updating its recent-ness.
+	Oldest() (key K, value V, ok bool)
+
+	// Returns the oldest unpinned entry without updating its recent-ness.
+	OldestUnpinned() (key K, value V, ok bool)
+
+	// Calls fn for each entry, from least to most recently used.
+	Range(fn func(key K, value V) bool)
+
+	// Pins or unpins an entry, protecting it from eviction.
+	SetPinned(key K, pinned bool) bool
+
 	// Returns the number of items in the cache.
 	Len() int
 
@@ -28,4 +51,7 @@ type LRUCache[K comparable, V any] interface {
 
 	// Resizes cache, returning number evicted
 	Resize(int) int
+
+	// Releases internal storage beyond the cache's current size.
+	Compact()
 }
@@ -3,6 +3,8 @@ package approxlru
 import (
 	"testing"
 	"unsafe"
+
+	"golang.org/x/exp/slices"
 )
 
 func TestSize(t *testing.T) {
@@ -129,6 +131,35 @@ func TestLRU_Add(t *testing.T) {
 	}
 }
 
+func TestLRU_AddIfAdmitted(t *testing.T) {
+	l, err := NewLRU[string, int](1, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	reject := func(victim string) bool {
+		if victim != "1" {
+			t.Errorf("unexpected victim %s", victim)
+		}
+		return false
+	}
+
+	if added, evicted := l.AddIfAdmitted("1", 1, reject); !added || evicted {
+		t.Errorf("adding without evicting shouldn't need admission")
+	}
+	if added, evicted := l.AddIfAdmitted("2", 2, reject); added || evicted {
+		t.Errorf("2 should have been rejected")
+	}
+	if !l.Contains("1") || l.Contains("2") {
+		t.Errorf("a rejected add should leave the cache unchanged")
+	}
+	if added, _ := l.AddIfAdmitted("1", 10, reject); !added {
+		t.Errorf("replacing a value shouldn't need admission")
+	}
+	if added, evicted := l.AddIfAdmitted("2", 2, func(string) bool { return true }); !added || !evicted {
+		t.Errorf("2 should have been admitted")
+	}
+}
+
 // Test that Contains doesn't update recent-ness
 func TestLRU_Contains(t *testing.T) {
 	l, err := NewLRU[string, int](2, nil)
@@ -208,3 +239,254 @@ func TestLRU_Resize(t *testing.T) {
 		t.Errorf("Cache should have contained 2 elements")
 	}
 }
+
+// Test that Compact releases storage after a Resize down
+func TestLRU_Compact(t *testing.T) {
+	l, err := NewLRU[int, int](1024, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 1024; i++ {
+		l.Add(i, i)
+	}
+
+	l.Resize(512)
+	if cap(l.data) != 1024 {
+		t.Errorf("small shrinks shouldn't reallocate: %d", cap(l.data))
+	}
+	l.Compact()
+	if cap(l.data) != 512 {
+		t.Errorf("expected capacity of 512 after Compact, not %d", cap(l.data))
+	}
+
+	l.Resize(16)
+	if cap(l.data) != 16 {
+		t.Errorf("expected large shrinks to compact automatically: %d", cap(l.data))
+	}
+	if l.Len() != 16 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+	for k, i := range l.items {
+		if l.data[i].key != k {
+			t.Fatalf("items out of sync with data for key %d", k)
+		}
+		if v, ok := l.Get(k); !ok || v != k {
+			t.Fatalf("bad key: %v", k)
+		}
+	}
+}
+
+// Test that a zero-sized LRU is unbounded
+func TestLRU_Unbounded(t *testing.T) {
+	evictCounter := 0
+	onEvicted := func(k, v int) {
+		evictCounter++
+	}
+	l, err := NewLRU[int, int](0, onEvicted)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := NewLRU[int, int](-1, nil); err == nil {
+		t.Fatalf("expected negative sizes to be rejected")
+	}
+
+	for i := 0; i < 1000; i++ {
+		if l.Add(i, i) {
+			t.Fatalf("unbounded caches shouldn't evict")
+		}
+	}
+	if l.Len() != 1000 || evictCounter != 0 {
+		t.Fatalf("bad len: %v (%d evicted)", l.Len(), evictCounter)
+	}
+	for i := 0; i < 1000; i += 2 {
+		l.Remove(i)
+	}
+	for i := 1; i < 1000; i += 2 {
+		if v, ok := l.Get(i); !ok || v != i {
+			t.Fatalf("bad key: %v", i)
+		}
+	}
+
+	// bounding it again evicts the oldest entries
+	if evicted := l.Resize(100); evicted != 400 {
+		t.Errorf("expected 400 evictions, not %d", evicted)
+	}
+	if l.Len() != 100 {
+		t.Errorf("bad len: %v", l.Len())
+	}
+	l.SetUnbounded()
+	if !l.Unbounded() || l.Size() != 0 {
+		t.Errorf("expected the cache to be unbounded: size %d", l.Size())
+	}
+	for i := 0; i < 1000; i++ {
+		l.Add(-i-1, i)
+	}
+	if l.Len() != 1100 {
+		t.Errorf("bad len: %v", l.Len())
+	}
+
+	// resizing to zero evicts everything, and stores nothing
+	if evicted := l.Resize(0); evicted != 1100 || l.Len() != 0 {
+		t.Errorf("expected Resize(0) to evict everything: %d evicted, len %d", evicted, l.Len())
+	}
+	if l.Unbounded() {
+		t.Errorf("Resize(0) shouldn't leave the cache unbounded")
+	}
+	if l.Add(1, 1) || l.Len() != 0 {
+		t.Errorf("a zero-sized cache should store nothing: len %d", l.Len())
+	}
+	l.Compact()
+	l.Purge()
+}
+
+// Test that RemoveOldest removes old entries
+func TestLRU_RemoveOldest(t *testing.T) {
+	evicted := 0
+	l, err := NewLRUWithReason[int, int](8, func(k, v int, reason EvictReason) {
+		if reason != ReasonEvicted {
+			t.Errorf("unexpected reason %v", reason)
+		}
+		evicted++
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, _, ok := l.RemoveOldest(); ok {
+		t.Fatalf("an empty cache has nothing to remove")
+	}
+
+	for i := 0; i < 8; i++ {
+		l.Add(i, i)
+	}
+	// with only 8 entries every one is probed, so this is exact
+	k, v, ok := l.RemoveOldest()
+	if !ok || k != 0 || v != 0 {
+		t.Errorf("expected 0 to be removed: %v, %v, %v", k, v, ok)
+	}
+	if l.Len() != 7 || l.Contains(0) || evicted != 1 {
+		t.Errorf("0 should have been evicted")
+	}
+}
+
+// Test that Range visits entries from least to most recently used
+func TestLRU_Range(t *testing.T) {
+	l, err := NewLRU[int, int](8, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 8; i++ {
+		l.Add(i, i)
+	}
+	l.Get(0)
+
+	var keys []int
+	l.Range(func(k, v int) bool {
+		if k != v {
+			t.Fatalf("bad value for %d: %d", k, v)
+		}
+		keys = append(keys, k)
+		// ranging over a copy allows modifying the cache
+		l.Remove(k)
+		return len(keys) < 4
+	})
+	if expected := []int{1, 2, 3, 4}; !slices.Equal(keys, expected) {
+		t.Errorf("expected %v, not %v", expected, keys)
+	}
+	if l.Len() != 4 {
+		t.Errorf("bad len: %v", l.Len())
+	}
+}
+
+// Test that Oldest finds the least recently used entry
+func TestLRU_Oldest(t *testing.T) {
+	l, err := NewLRU[int, int](128, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, _, ok := l.Oldest(); ok {
+		t.Fatalf("an empty cache has no oldest entry")
+	}
+	for i := 0; i < 128; i++ {
+		l.Add(i, i)
+	}
+	l.Get(0)
+	if k, v, ok := l.Oldest(); !ok || k != 1 || v != 1 {
+		t.Errorf("expected 1 to be the oldest: %v, %v, %v", k, v, ok)
+	}
+	if l.Len() != 128 {
+		t.Errorf("Oldest shouldn't remove anything")
+	}
+}
+
+// Test that pinned entries are never evicted
+func TestLRU_Pinned(t *testing.T) {
+	l, err := NewLRU[int, int](8, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 8; i++ {
+		l.Add(i, i)
+	}
+	if !l.SetPinned(0, true) || !l.SetPinned(1, true) {
+		t.Fatalf("0 and 1 should be present")
+	}
+	if l.SetPinned(100, true) {
+		t.Fatalf("100 isn't present")
+	}
+
+	for i := 8; i < 100; i++ {
+		l.Add(i, i)
+		l.Get(0)
+	}
+	if !l.Contains(0) || !l.Contains(1) || l.Len() != 8 {
+		t.Fatalf("pinned entries shouldn't be evicted (len %d)", l.Len())
+	}
+	if l.Resize(1) != 6 || !l.Contains(0) || !l.Contains(1) {
+		t.Fatalf("pinned entries shouldn't be resized away")
+	}
+
+	// with every entry pinned, the cache grows past its size
+	l.Resize(2)
+	if l.Add(100, 100) {
+		t.Errorf("there was nothing to evict")
+	}
+	if l.Len() != 3 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+	// and shrinks back once entries are unpinned
+	l.SetPinned(0, false)
+	l.SetPinned(1, false)
+	if !l.Add(101, 101) || l.Len() != 2 {
+		t.Fatalf("expected to shrink back to size: %v", l.Len())
+	}
+	if !l.Contains(101) || !l.Contains(100) {
+		t.Errorf("expected the newest entries to be kept")
+	}
+}
+
+func TestLRU_SharedClock(t *testing.T) {
+	a, _ := NewLRU[int, int](2, nil)
+	b, _ := NewLRU[int, int](2, nil)
+	a.SetCounter(10)
+	a.Add(1, 1)
+	b.SetCounter(5)
+	b.Add(2, 2)
+	if key, rank, ok := b.OldestRank(); !ok || key != 2 || rank != 5 {
+		t.Errorf("bad oldest: %v, %v, %v", key, rank, ok)
+	}
+	if _, rank, _ := a.OldestRank(); rank != 10 {
+		t.Errorf("expected the counter to stamp the add: %v", rank)
+	}
+
+	a.Add(3, 3)
+	a.AdjustSize(1)
+	if a.Add(4, 4) || a.Len() != 3 || a.Size() != 3 {
+		t.Errorf("expected room for a third entry: len %d, size %d", a.Len(), a.Size())
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected shrinking below the length to panic")
+		}
+	}()
+	a.AdjustSize(-1)
+}
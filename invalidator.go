@@ -0,0 +1,79 @@
+package lru
+
+import (
+	"sync"
+	"time"
+)
+
+// BatchInvalidator coalesces invalidations of a cache's keys received
+// within a short window, removing them all while taking the cache lock only
+// once.  It suits invalidation feeds that deliver many per-key messages in
+// bursts, such as during bulk updates, where removing each key as it
+// arrives would contend heavily for the cache lock.  Invalidations are
+// applied at most window after they are received, so lookups may still
+// find an invalidated key until then.
+type BatchInvalidator[K comparable, V any] struct {
+	cache  *Cache[K, V]
+	window time.Duration
+
+	mu      sync.Mutex
+	pending map[K]struct{}
+	timer   *time.Timer
+}
+
+// NewBatchInvalidator returns a BatchInvalidator for c, applying
+// invalidations in batches every window.
+func NewBatchInvalidator[K comparable, V any](c *Cache[K, V], window time.Duration) *BatchInvalidator[K, V] {
+	return &BatchInvalidator[K, V]{
+		cache:   c,
+		window:  window,
+		pending: make(map[K]struct{}),
+	}
+}
+
+// Invalidate schedules key to be removed from the cache with the current
+// batch.
+func (b *BatchInvalidator[K, V]) Invalidate(key K) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.pending[key] = struct{}{}
+	if b.timer == nil {
+		b.timer = time.AfterFunc(b.window, func() { b.Flush() })
+	}
+}
+
+// Flush applies the pending invalidations now, returning the number of
+// keys removed.
+func (b *BatchInvalidator[K, V]) Flush() (removed int) {
+	b.mu.Lock()
+	pending := b.pending
+	b.pending = make(map[K]struct{})
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	b.mu.Unlock()
+
+	if len(pending) == 0 {
+		return 0
+	}
+	c := b.cache
+	c.lock.Lock()
+	defer c.unlock()
+
+	for key := range pending {
+		if _, ok := c.remove(key); ok {
+			removed++
+		}
+	}
+	return removed
+}
+
+// Pending returns the number of invalidations waiting for the next batch.
+func (b *BatchInvalidator[K, V]) Pending() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return len(b.pending)
+}
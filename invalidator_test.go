@@ -0,0 +1,49 @@
+package lru
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBatchInvalidator(t *testing.T) {
+	l, err := New[int, int](128)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 100; i++ {
+		l.Add(i, i)
+	}
+
+	b := NewBatchInvalidator(l, time.Hour)
+	for i := 0; i < 50; i++ {
+		b.Invalidate(i)
+		b.Invalidate(i)
+	}
+	b.Invalidate(1000)
+	if b.Pending() != 51 {
+		t.Errorf("expected duplicate invalidations to coalesce: %d", b.Pending())
+	}
+	if l.Len() != 100 {
+		t.Errorf("invalidations shouldn't apply before the batch: %d", l.Len())
+	}
+	if removed := b.Flush(); removed != 50 {
+		t.Errorf("expected 50 removals, not %d", removed)
+	}
+	if l.Len() != 50 || l.Contains(0) || !l.Contains(50) {
+		t.Errorf("expected the first 50 keys to be removed")
+	}
+	if b.Pending() != 0 || b.Flush() != 0 {
+		t.Errorf("nothing should be pending")
+	}
+
+	// batches are applied after the window
+	b = NewBatchInvalidator(l, time.Millisecond)
+	b.Invalidate(50)
+	deadline := time.Now().Add(5 * time.Second)
+	for l.Contains(50) {
+		if time.Now().After(deadline) {
+			t.Fatalf("the batch should have been applied")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,58 @@
+package lru
+
+import (
+	"reflect"
+)
+
+// AddHook can be implemented by values stored in a Cache to be told when
+// they are added to it.  Re-adding the value already cached for a key
+// calls neither AddHook nor EvictHook.  Only Cache calls the hooks:
+// ShardedCache, TwoQueueCache, ARCCache, ReadOptimizedCache and
+// SieveCache ignore them.
+type AddHook interface {
+	OnCacheAdd()
+}
+
+// EvictHook can be implemented by values stored in a Cache to be told when
+// they leave it -- whether evicted, removed, purged or replaced by Add by
+// a different value.  It is called with the cache lock held.
+type EvictHook interface {
+	OnCacheEvict()
+}
+
+// valueHooks reports which lifecycle hooks values of type V may implement.
+// Concrete types are checked once, up front; values of interface types
+// have to be checked one by one.
+func valueHooks[V any]() (add, evict bool) {
+	if reflect.TypeOf((*V)(nil)).Elem().Kind() == reflect.Interface {
+		return true, true
+	}
+	var zero V
+	_, add = any(zero).(AddHook)
+	_, evict = any(zero).(EvictHook)
+	return add, evict
+}
+
+func callAddHook[V any](value V) {
+	if h, ok := any(value).(AddHook); ok {
+		h.OnCacheAdd()
+	}
+}
+
+func callEvictHook[V any](value V) {
+	if h, ok := any(value).(EvictHook); ok {
+		h.OnCacheEvict()
+	}
+}
+
+// sameValue reports whether a and b are the same value, for values that
+// can be compared.  Values whose dynamic types can't be, such as slices in
+// interfaces, are never the same.
+func sameValue[V any](a, b V) (same bool) {
+	defer func() {
+		if recover() != nil {
+			same = false
+		}
+	}()
+	return any(a) == any(b)
+}
@@ -0,0 +1,99 @@
+package lru
+
+import (
+	"testing"
+)
+
+type refCounted struct {
+	refs int
+}
+
+func (r *refCounted) OnCacheAdd() {
+	r.refs++
+}
+
+func (r *refCounted) OnCacheEvict() {
+	r.refs--
+}
+
+func TestLRULifecycleHooks(t *testing.T) {
+	l, err := New[string, *refCounted](2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	a, b, c := &refCounted{}, &refCounted{}, &refCounted{}
+	l.Add("a", a)
+	l.Add("b", b)
+	if a.refs != 1 || b.refs != 1 {
+		t.Fatalf("adds should have been counted: %d %d", a.refs, b.refs)
+	}
+	l.Add("c", c) // evicts a
+	if a.refs != 0 || c.refs != 1 {
+		t.Errorf("eviction should have been counted: %d %d", a.refs, c.refs)
+	}
+	l.Add("b", a) // replaces b
+	if b.refs != 0 || a.refs != 1 {
+		t.Errorf("replacement should have been counted: %d %d", b.refs, a.refs)
+	}
+	l.Add("b", a) // re-adds the cached value
+	if a.refs != 1 {
+		t.Errorf("re-adding the cached value shouldn't call its hooks: %d", a.refs)
+	}
+	l.Remove("c")
+	l.Purge()
+	if a.refs != 0 || b.refs != 0 || c.refs != 0 {
+		t.Errorf("every value should have left the cache: %d %d %d", a.refs, b.refs, c.refs)
+	}
+
+	// values stored behind an interface type are checked one by one
+	li, err := New[string, any](2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	li.Add("a", a)
+	li.Add("a", a)
+	li.Add("1", 1)
+	li.Add("1", []int{1}) // can't be compared, but mustn't panic
+	li.Add("1", []int{1})
+	li.Remove("a")
+	if a.refs != 0 {
+		t.Errorf("hooks should be called for interface values: %d", a.refs)
+	}
+
+	// plain values don't need any bookkeeping
+	lp, err := New[string, int](2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if lp.ext != nil {
+		t.Errorf("caches of values without hooks shouldn't allocate extensions")
+	}
+}
+
+// addCounted only implements AddHook.
+type addCounted struct {
+	adds int
+}
+
+func (a *addCounted) OnCacheAdd() {
+	a.adds++
+}
+
+func TestLRUAddHookOnly(t *testing.T) {
+	l, err := New[string, *addCounted](2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	a := &addCounted{}
+	l.Add("a", a)
+	l.Add("a", a)
+	if a.adds != 1 {
+		t.Errorf("re-adding the cached value shouldn't call OnCacheAdd again: %d", a.adds)
+	}
+	l.Add("a", &addCounted{})
+	l.Add("a", a)
+	if a.adds != 2 {
+		t.Errorf("adding the value back should call OnCacheAdd: %d", a.adds)
+	}
+}
@@ -0,0 +1,160 @@
+package lru
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// errLoaderPanicked is returned to callers waiting on a load whose loader
+// panicked.
+var errLoaderPanicked = errors.New("lru: loader panicked")
+
+// loadCall is an in-flight load, shared by every caller that misses on its
+// key while it runs.
+type loadCall[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+	// ttl is the time-to-live the loader returned for value, if withTTL.
+	ttl     time.Duration
+	withTTL bool
+	// waiters counts the callers waiting on the load, other than the one
+	// running it.  It is guarded by the cache lock.
+	waiters int
+}
+
+// GetOrLoad looks up a key's value from the cache, and on a miss calls
+// loader to load it and adds the result.  Concurrent misses on the same key
+// share a single call to loader rather than each loading it.  Errors are
+// returned to every caller sharing the load, and aren't cached, except
+// for ErrNotFound in caches with Options.NegativeTTL.
+func (c *Cache[K, V]) GetOrLoad(key K, loader func(key K) (V, error)) (V, error) {
+	return c.GetOrLoadCtx(context.Background(), key, func(_ context.Context, key K) (V, error) {
+		return loader(key)
+	})
+}
+
+// GetOrLoadCtx is like GetOrLoad, but passes ctx to loader.  Like GetCtx,
+// it returns ctx.Err() without looking the key up if ctx is already done.
+// A caller waiting on a load started by another caller stops waiting when
+// its ctx is done, returning ctx.Err(); the load itself only observes the
+// ctx of the caller that started it, and if that ctx fails it, waiters
+// whose own ctx is still alive retry rather than returning its error.
+// Refreshes started by Options.StaleWhileRevalidate outlive the lookups
+// that cause them, so they are passed a context carrying ctx's values and
+// deadline, but not its cancelation; see Options.RefreshCtx.  Eviction
+// callbacks aren't passed a ctx at all.
+func (c *Cache[K, V]) GetOrLoadCtx(ctx context.Context, key K, loader func(ctx context.Context, key K) (V, error)) (V, error) {
+	return c.getOrLoadRetry(ctx, key, false, func(ctx context.Context, key K) (V, time.Duration, error) {
+		value, err := loader(ctx, key)
+		return value, 0, err
+	})
+}
+
+// GetOrLoadWithTTL is like GetOrLoadCtx, but loader also returns the
+// time-to-live of the value it loads, overriding the cache's default TTL
+// as in AddWithTTL, for values whose freshness is dictated by where they
+// were loaded from, such as an HTTP Cache-Control header or a DNS record's
+// TTL.  A non-positive ttl means the value never expires.
+func (c *Cache[K, V]) GetOrLoadWithTTL(ctx context.Context, key K, loader func(ctx context.Context, key K) (value V, ttl time.Duration, err error)) (V, error) {
+	return c.getOrLoadRetry(ctx, key, true, loader)
+}
+
+// getOrLoadRetry does the work of GetOrLoadCtx and GetOrLoadWithTTL,
+// using the ttl returned by loader if withTTL.
+func (c *Cache[K, V]) getOrLoadRetry(ctx context.Context, key K, withTTL bool, loader func(ctx context.Context, key K) (V, time.Duration, error)) (V, error) {
+	for {
+		if err := ctx.Err(); err != nil {
+			var zero V
+			return zero, err
+		}
+		value, shared, err := c.getOrLoad(ctx, key, withTTL, loader)
+		// a shared load canceled by the ctx of the caller that started
+		// it says nothing about the key, so retry while ours is alive
+		if shared && isContextErr(err) && ctx.Err() == nil {
+			continue
+		}
+		return value, err
+	}
+}
+
+// isContextErr reports whether err is from a canceled or expired context.
+func isContextErr(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// getOrLoad makes a single attempt at getOrLoadRetry, reporting whether
+// the result came from a load started by another caller.
+func (c *Cache[K, V]) getOrLoad(ctx context.Context, key K, withTTL bool, loader func(ctx context.Context, key K) (V, time.Duration, error)) (value V, shared bool, err error) {
+	c.lock.Lock()
+	if c.negative(key) {
+		c.unlock()
+		return value, false, ErrNotFound
+	}
+	if value, ok := c.getCtx(ctx, key); ok {
+		c.unlock()
+		return value, false, nil
+	}
+	e := c.extension()
+	if e.loads == nil {
+		e.loads = make(map[K]*loadCall[V])
+	}
+	if call, ok := e.loads[key]; ok {
+		call.waiters++
+		c.unlock()
+		select {
+		case <-call.done:
+			return call.value, true, call.err
+		case <-ctx.Done():
+			return value, false, ctx.Err()
+		}
+	}
+	call := &loadCall[V]{
+		done:    make(chan struct{}),
+		err:     errLoaderPanicked,
+		withTTL: withTTL,
+	}
+	e.loads[key] = call
+	if e.loadRates != nil {
+		e.loadRates.loading(key)
+	}
+	c.unlock()
+
+	defer c.finishLoad(key, call)
+	start := time.Now()
+	if e.hedgeDelay > 0 {
+		var loaded timedValue[V]
+		loaded, call.err = hedgedLoad(ctx, key, e.hedgeDelay, func(ctx context.Context, key K) (timedValue[V], error) {
+			value, ttl, err := loader(ctx, key)
+			return timedValue[V]{value, ttl}, err
+		})
+		call.value, call.ttl = loaded.value, loaded.ttl
+	} else {
+		call.value, call.ttl, call.err = loader(ctx, key)
+	}
+	c.stats.loaded(time.Since(start))
+	return call.value, false, call.err
+}
+
+// finishLoad caches the result of a load and wakes the callers waiting on
+// it.  It is deferred, so that they are woken and the lock released even if
+// the loader, or a hook run by adding its result, panics.
+func (c *Cache[K, V]) finishLoad(key K, call *loadCall[V]) {
+	defer close(call.done)
+	c.lock.Lock()
+	defer c.unlock()
+
+	e := c.ext
+	delete(e.loads, key)
+	c.stats.loadWaited(call.waiters)
+	if call.err == nil {
+		c.add(key, call.value)
+		// the add may have been dropped, e.g. by a tombstone
+		if call.withTTL && c.lru.Contains(key) {
+			c.extension().ttlState().set(key, call.ttl)
+		}
+	} else if e.negatives != nil && errors.Is(call.err, ErrNotFound) {
+		e.negatives.add(key)
+	}
+}
@@ -0,0 +1,338 @@
+package lru
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLRUGetOrLoad(t *testing.T) {
+	l, err := New[string, int](8)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	var calls int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	loader := func(key string) (int, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(started)
+		}
+		<-release
+		return 1, nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]int, 8)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		results[0], _ = l.GetOrLoad("1", loader)
+	}()
+	<-started
+	for i := 1; i < len(results); i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = l.GetOrLoad("1", loader)
+		}(i)
+	}
+	close(release)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("expected a single call to the loader, not %d", calls)
+	}
+	for i, v := range results {
+		if v != 1 {
+			t.Errorf("bad result %d: %v", i, v)
+		}
+	}
+	if v, ok := l.Peek("1"); !ok || v != 1 {
+		t.Errorf("the loaded value should be cached: %v, %v", v, ok)
+	}
+	if loads := l.Stats().Loads; loads != 1 {
+		t.Errorf("expected the load to be counted: %d", loads)
+	}
+
+	// errors aren't cached
+	errLoad := errors.New("load failed")
+	if _, err := l.GetOrLoad("2", func(string) (int, error) { return 0, errLoad }); err != errLoad {
+		t.Errorf("expected the loader's error, not %v", err)
+	}
+	if l.Contains("2") {
+		t.Errorf("failed loads shouldn't be cached")
+	}
+}
+
+func TestLRUGetOrLoadCtx(t *testing.T) {
+	l, err := New[string, int](8)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		l.GetOrLoadCtx(context.Background(), "1", func(ctx context.Context, key string) (int, error) {
+			close(started)
+			<-release
+			return 1, nil
+		})
+	}()
+	<-started
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = l.GetOrLoadCtx(ctx, "1", func(ctx context.Context, key string) (int, error) {
+		t.Errorf("the load should be shared")
+		return 0, nil
+	})
+	if err != context.Canceled {
+		t.Errorf("expected waiting to be canceled, not %v", err)
+	}
+
+	close(release)
+	<-done
+	if v, ok := l.Get("1"); !ok || v != 1 {
+		t.Errorf("the loaded value should be cached: %v, %v", v, ok)
+	}
+}
+
+func TestLRUGetOrLoadCtxRetry(t *testing.T) {
+	l, err := New[string, int](8)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// the first caller's ctx is canceled mid-load, while a second caller
+	// is waiting on it
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		_, err := l.GetOrLoadCtx(ctx, "1", func(ctx context.Context, key string) (int, error) {
+			close(started)
+			<-ctx.Done()
+			return 0, ctx.Err()
+		})
+		done <- err
+	}()
+	<-started
+
+	result := make(chan int)
+	go func() {
+		v, err := l.GetOrLoadCtx(context.Background(), "1", func(ctx context.Context, key string) (int, error) {
+			return 1, nil
+		})
+		if err != nil {
+			t.Errorf("the waiter should retry rather than fail: %v", err)
+		}
+		result <- v
+	}()
+	// give the waiter a chance to start waiting on the first load
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("expected the first caller to be canceled: %v", err)
+	}
+	if v := <-result; v != 1 {
+		t.Errorf("expected the waiter's own load: %v", v)
+	}
+}
+
+func TestLRUGetOrLoadHookPanic(t *testing.T) {
+	l, err := NewWithOptions[string, int](8, Options[string, int]{
+		OnAdd: func(key string, value int) {
+			panic("boom")
+		},
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("expected the hook's panic")
+			}
+		}()
+		l.GetOrLoad("1", func(key string) (int, error) {
+			return 1, nil
+		})
+	}()
+
+	// the lock was released, and the load forgotten
+	if l.Len() != 1 || len(l.ext.loads) != 0 {
+		t.Errorf("bad state after the panic: %d entries, %d loads", l.Len(), len(l.ext.loads))
+	}
+}
+
+func TestLRUGetOrLoadStampede(t *testing.T) {
+	now := time.Unix(1000, 0)
+	var stampedes []int
+	l, err := NewWithOptions[string, int](8, Options[string, int]{
+		StampedeThreshold: 2,
+		OnStampede: func(key string, loads int) {
+			stampedes = append(stampedes, loads)
+		},
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.ext.loadRates.now = func() time.Time { return now }
+
+	// callers sharing a load count as its waiters
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		l.GetOrLoad("1", func(string) (int, error) {
+			close(started)
+			<-release
+			return 1, nil
+		})
+	}()
+	<-started
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.GetOrLoad("1", func(string) (int, error) { return 1, nil })
+		}()
+	}
+	for {
+		l.lock.Lock()
+		waiters := l.ext.loads["1"].waiters
+		l.lock.Unlock()
+		if waiters == 3 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+	if stats := l.Stats(); stats.LoadWaiters != 3 || stats.MaxLoadWaiters != 3 || stats.MeanLoadWaiters() != 3 {
+		t.Errorf("unexpected waiter stats: %+v", stats)
+	}
+
+	// reloading a key that keeps being removed reports it once a minute
+	for i := 0; i < 4; i++ {
+		l.Remove("1")
+		l.GetOrLoad("1", func(string) (int, error) { return 1, nil })
+	}
+	if rate := l.LoadRate("1"); rate != 5 {
+		t.Errorf("expected 5 loads this minute, not %d", rate)
+	}
+	if len(stampedes) != 1 || stampedes[0] != 3 {
+		t.Errorf("expected a single stampede report at 3 loads: %v", stampedes)
+	}
+	now = now.Add(time.Minute)
+	if rate := l.LoadRate("1"); rate != 0 {
+		t.Errorf("expected the load rate to reset after a minute, not %d", rate)
+	}
+	for i := 0; i < 3; i++ {
+		l.Remove("1")
+		l.GetOrLoad("1", func(string) (int, error) { return 1, nil })
+	}
+	if len(stampedes) != 2 {
+		t.Errorf("expected another stampede report in the next minute: %v", stampedes)
+	}
+}
+
+func TestLRUGetOrLoadHedged(t *testing.T) {
+	l, err := NewWithOptions[string, int](8, Options[string, int]{
+		HedgeDelay: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// the first attempt hangs until the hedge succeeds
+	var calls int32
+	abandoned := make(chan error, 1)
+	v, err := l.GetOrLoadCtx(context.Background(), "1", func(ctx context.Context, key string) (int, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			<-ctx.Done()
+			abandoned <- ctx.Err()
+			return 0, ctx.Err()
+		}
+		return 2, nil
+	})
+	if err != nil || v != 2 {
+		t.Errorf("expected the hedged attempt's value: %v, %v", v, err)
+	}
+	if err := <-abandoned; err != context.Canceled {
+		t.Errorf("expected the slow attempt to be canceled, not %v", err)
+	}
+	if loads := l.Stats().Loads; loads != 1 {
+		t.Errorf("a hedged load should count once: %d", loads)
+	}
+
+	// failing fast isn't hedged
+	calls = 0
+	errLoad := errors.New("load failed")
+	if _, err := l.GetOrLoad("2", func(string) (int, error) {
+		atomic.AddInt32(&calls, 1)
+		return 0, errLoad
+	}); err != errLoad {
+		t.Errorf("expected the loader's error, not %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if calls := atomic.LoadInt32(&calls); calls != 1 {
+		t.Errorf("a failed load shouldn't be hedged: %d calls", calls)
+	}
+
+	// panics reach the caller
+	func() {
+		defer func() {
+			if p := recover(); p != "boom" {
+				t.Errorf("expected the loader's panic, not %v", p)
+			}
+		}()
+		l.GetOrLoad("3", func(string) (int, error) { panic("boom") })
+	}()
+}
+
+func TestLRUGetOrLoadWithTTL(t *testing.T) {
+	now := time.Unix(1000, 0)
+	l, err := NewWithOptions[string, int](8, Options[string, int]{TTL: time.Hour})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.ext.ttl.now = func() time.Time { return now }
+
+	loader := func(ttl time.Duration) func(context.Context, string) (int, time.Duration, error) {
+		return func(context.Context, string) (int, time.Duration, error) {
+			return 1, ttl, nil
+		}
+	}
+	ctx := context.Background()
+	if v, err := l.GetOrLoadWithTTL(ctx, "short", loader(time.Second)); err != nil || v != 1 {
+		t.Errorf("unexpected load: %v, %v", v, err)
+	}
+	l.GetOrLoadWithTTL(ctx, "forever", loader(0))
+	l.GetOrLoad("default", func(string) (int, error) { return 1, nil })
+
+	now = now.Add(time.Minute)
+	if l.Contains("short") {
+		t.Errorf("expected the loader's TTL to override the default")
+	}
+	if !l.Contains("default") {
+		t.Errorf("expected GetOrLoad to keep the default TTL")
+	}
+	now = now.Add(2 * time.Hour)
+	if !l.Contains("forever") {
+		t.Errorf("expected a non-positive TTL to never expire")
+	}
+	if l.Contains("default") {
+		t.Errorf("expected the default TTL to expire")
+	}
+}
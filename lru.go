@@ -1,19 +1,37 @@
 package lru
 
 import (
-	"sync"
+	"strconv"
+	"unsafe"
 
 	"github.com/bpowers/approx-lru/internal/approxlru"
 )
 
 // Cache is a thread-safe fixed size LRU cache.
 type Cache[K comparable, V any] struct {
-	lock sync.Mutex
+	lock mutex
 	lru  approxlru.LRU[K, V]
-	_    [16]byte
+	// ext holds the state of optional features, and is nil for plain
+	// caches.
+	ext *cacheExt[K, V]
+	// the padding isn't the last field, where it would take up space even
+	// when zero-sized.
+	_     [cachePadding]byte
+	stats *statsCounters
 }
 
-// New creates an LRU of the given size.
+// cachePadding pads a Cache out to a multiple of the cache line size.
+const cachePadding = (cacheLineSize - (unsafe.Sizeof(mutex{})+approxlru.LRUStructSize+2*unsafe.Sizeof(uintptr(0)))%cacheLineSize) % cacheLineSize
+
+// Entry is a key/value pair stored in a cache.
+type Entry[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+// New creates an LRU of the given size.  A size of zero creates an
+// unbounded cache, which never evicts entries to make room for new ones;
+// note that Resize(0) instead empties the cache.
 func New[K comparable, V any](size int) (*Cache[K, V], error) {
 	return NewWithEvict[K, V](size, nil)
 }
@@ -26,7 +44,12 @@ func NewWithEvict[K comparable, V any](size int, onEvicted func(key K, value V))
 		return nil, err
 	}
 	c := &Cache[K, V]{
-		lru: *lru,
+		lru:   *lru,
+		stats: new(statsCounters),
+	}
+	if addHook, evictHook := valueHooks[V](); addHook || evictHook {
+		e := c.extension()
+		e.addHook, e.evictHook = addHook, evictHook
 	}
 	return c, nil
 }
@@ -34,43 +57,270 @@ func NewWithEvict[K comparable, V any](size int, onEvicted func(key K, value V))
 // Purge is used to completely clear the cache.
 func (c *Cache[K, V]) Purge() {
 	c.lock.Lock()
-	defer c.lock.Unlock()
+	defer c.unlock()
 
 	c.lru.Purge()
+	if c.ext != nil && c.ext.negatives != nil {
+		c.ext.negatives.entries.Purge()
+	}
+	if c.ext != nil && c.ext.mirror != nil {
+		c.ext.mirror.send(mirrorOp[K, V]{kind: mirrorPurge})
+	}
 }
 
 // Add adds a value to the cache. Returns true if an eviction occurred.
 func (c *Cache[K, V]) Add(key K, value V) (evicted bool) {
 	c.lock.Lock()
-	defer c.lock.Unlock()
+	defer c.unlock()
 
-	return c.lru.Add(key, value)
+	return c.add(key, value)
+}
+
+// WarmFrom adds every key/value pair produced by seq to the cache, in
+// order, treating later pairs as more recently used than earlier ones.  The
+// eviction callback is not invoked for entries evicted while warming.  seq
+// has the same shape as an iter.Seq2[K, V].  The cache lock is held for the
+// whole duration of seq, so WarmFrom is best suited to filling a fresh cache
+// before it serves traffic.  Returns the number of pairs added.
+func (c *Cache[K, V]) WarmFrom(seq func(yield func(K, V) bool)) (added int) {
+	c.lock.Lock()
+	defer c.unlock()
+
+	seq(func(key K, value V) bool {
+		c.addWithoutCallback(key, value)
+		added++
+		return true
+	})
+	return added
+}
+
+// addWithoutCallback adds a value to the cache without invoking the
+// user's eviction callback, while still keeping the state of optional
+// features up to date.  c.lock must be held.
+func (c *Cache[K, V]) addWithoutCallback(key K, value V) {
+	e := c.ext
+	if e == nil {
+		c.stats.added(c.lru.AddWithoutCallback(key, value))
+		return
+	}
+	onEvict := e.onEvict
+	e.onEvict = nil
+	c.add(key, value)
+	e.onEvict = onEvict
+}
+
+// WarmFromChan is like WarmFrom, but reads entries from ch until it is
+// closed.
+func (c *Cache[K, V]) WarmFromChan(ch <-chan Entry[K, V]) (added int) {
+	return c.WarmFrom(func(yield func(K, V) bool) {
+		for e := range ch {
+			if !yield(e.Key, e.Value) {
+				return
+			}
+		}
+	})
+}
+
+// add adds a value to the cache, keeping the state of optional features
+// up to date.  c.lock must be held.
+func (c *Cache[K, V]) add(key K, value V) (evicted bool) {
+	e := c.ext
+	if e == nil {
+		evicted = c.lru.Add(key, value)
+		c.stats.added(evicted)
+		return evicted
+	}
+	if e.tombstones != nil && e.tombstones.buried(key) {
+		return false
+	}
+	if e.rejectNil && isNil(value) {
+		return false
+	}
+	if e.negatives != nil {
+		// the key exists after all
+		e.negatives.entries.Remove(key)
+	}
+	if e.mirror != nil {
+		defer func() {
+			// the add may have been dropped, e.g. for its cost
+			if c.lru.Contains(key) {
+				e.mirror.send(mirrorOp[K, V]{key: key, value: value})
+			}
+		}()
+	}
+	if e.ttl != nil {
+		// an expired entry is expired, rather than replaced
+		c.expire(key)
+		defer func() {
+			// the add may have been dropped, e.g. for its cost, and
+			// expiry times are only kept for cached entries
+			if c.lru.Contains(key) {
+				e.ttl.set(key, e.ttl.defaultTTL)
+			}
+		}()
+	}
+	if e.accessed != nil {
+		defer func() {
+			if c.lru.Contains(key) {
+				e.accessed.touch(key)
+			}
+		}()
+	}
+	if e.curve != nil {
+		// the ghost caches see every add, even those this cache drops
+		e.curve.added(key)
+	}
+	if e.onReplace == nil && e.onAdd == nil && e.thrash == nil && e.deps == nil && e.cost == nil && e.indexes == nil && e.accuracy == nil && e.admission == nil {
+		// lifecycle hooks are common with interface-typed values, so
+		// they don't need the full treatment below
+		var old V
+		var replaced bool
+		if e.addHook || e.evictHook {
+			old, replaced = c.lru.Peek(key)
+		}
+		evicted = c.lru.Add(key, value)
+		c.stats.added(evicted)
+		if replaced && sameValue(old, value) {
+			return evicted
+		}
+		if e.addHook {
+			callAddHook(value)
+		}
+		if replaced {
+			callEvictHook(old)
+		}
+		return evicted
+	}
+
+	var cost int64
+	if e.cost != nil {
+		if cost = e.cost.fn(key, value); cost > e.cost.max {
+			// it can never fit, but don't leave a stale value
+			// behind: the old value is pushed out by the new one
+			if _, ok := c.lru.RemoveWithReason(key, approxlru.ReasonEvicted); ok {
+				c.stats.evicted(1)
+			}
+			return false
+		}
+	}
+
+	old, replaced := c.lru.Peek(key)
+	var derived []K
+	if replaced && e.deps != nil {
+		derived = e.deps.derived(key)
+	}
+	var admit func(victim K) bool
+	if e.admission != nil {
+		admit = func(victim K) bool {
+			return e.admission.admit(key, victim)
+		}
+	}
+	added, evicted := c.lru.AddIfAdmitted(key, value, admit)
+	if !added {
+		// caches resized to zero drop every add
+		if e.admission != nil && c.lru.Size() > 0 {
+			c.stats.rejectedAdd()
+		}
+		return false
+	}
+	if !replaced && e.thrash != nil {
+		e.thrash.added(key)
+	}
+	c.stats.added(evicted)
+	if e.indexes != nil {
+		e.index(key, value)
+	}
+	if e.accuracy != nil {
+		e.accuracy.added(key)
+	}
+	if e.cost != nil && c.charge(key, cost) {
+		evicted = true
+	}
+	// re-adding the value already cached leaves it in the cache, so
+	// neither of its lifecycle hooks is called
+	same := replaced && (e.addHook || e.evictHook) && sameValue(old, value)
+	if e.addHook && !same {
+		callAddHook(value)
+	}
+	if e.onAdd != nil {
+		e.onAdd(key, value)
+	}
+	if replaced {
+		if e.evictHook && !same {
+			callEvictHook(old)
+		}
+		if e.onReplace != nil {
+			e.onReplace(key, old, value)
+		}
+	}
+	for _, child := range derived {
+		c.remove(child)
+	}
+	return evicted
+}
+
+// remove removes the provided key from the cache, along with any entries
+// derived from it.  c.lock must be held.
+func (c *Cache[K, V]) remove(key K) (value V, present bool) {
+	var derived []K
+	if c.ext != nil && c.ext.deps != nil {
+		derived = c.ext.deps.derived(key)
+	}
+	value, present = c.lru.RemoveAndGet(key)
+	if c.ext != nil && c.ext.tombstones != nil {
+		c.ext.tombstones.bury(key)
+	}
+	if c.ext != nil && c.ext.negatives != nil {
+		c.ext.negatives.entries.Remove(key)
+	}
+	if present && c.ext != nil && c.ext.mirror != nil {
+		c.ext.mirror.send(mirrorOp[K, V]{key: key, kind: mirrorRemove})
+	}
+	for _, child := range derived {
+		c.remove(child)
+	}
+	return value, present
 }
 
 // Get looks up a key's value from the cache.
 func (c *Cache[K, V]) Get(key K) (value V, ok bool) {
 	c.lock.Lock()
-	defer c.lock.Unlock()
+	defer c.unlock()
 
-	return c.lru.Get(key)
+	return c.get(key)
 }
 
 // Contains checks if a key is in the cache, without updating the
 // recent-ness or deleting it for being stale.
 func (c *Cache[K, V]) Contains(key K) bool {
 	c.lock.Lock()
-	defer c.lock.Unlock()
+	defer c.unlock()
 
-	return c.lru.Contains(key)
+	return c.contains(key)
+}
+
+// ContainsMulti checks if each of the keys is in the cache, without updating
+// their recent-ness, while taking the cache lock only once.  The result
+// holds one entry per key, in order.
+func (c *Cache[K, V]) ContainsMulti(keys []K) []bool {
+	found := make([]bool, len(keys))
+
+	c.lock.Lock()
+	defer c.unlock()
+
+	for i, key := range keys {
+		found[i] = c.contains(key)
+	}
+	return found
 }
 
 // Peek returns the key value (or undefined if not found) without updating
 // the "recently used"-ness of the key.
 func (c *Cache[K, V]) Peek(key K) (value V, ok bool) {
 	c.lock.Lock()
-	defer c.lock.Unlock()
+	defer c.unlock()
 
-	return c.lru.Peek(key)
+	return c.peek(key)
 }
 
 // ContainsOrAdd checks if a key is in the cache without updating the
@@ -78,12 +328,12 @@ func (c *Cache[K, V]) Peek(key K) (value V, ok bool) {
 // Returns whether found and whether an eviction occurred.
 func (c *Cache[K, V]) ContainsOrAdd(key K, value V) (ok, evicted bool) {
 	c.lock.Lock()
-	defer c.lock.Unlock()
+	defer c.unlock()
 
-	if c.lru.Contains(key) {
+	if c.contains(key) {
 		return true, false
 	}
-	evicted = c.lru.Add(key, value)
+	evicted = c.add(key, value)
 	return false, evicted
 }
 
@@ -92,37 +342,180 @@ func (c *Cache[K, V]) ContainsOrAdd(key K, value V) (ok, evicted bool) {
 // Returns whether found and whether an eviction occurred.
 func (c *Cache[K, V]) PeekOrAdd(key K, value V) (previous V, ok, evicted bool) {
 	c.lock.Lock()
-	defer c.lock.Unlock()
+	defer c.unlock()
 
-	previous, ok = c.lru.Peek(key)
+	previous, ok = c.peek(key)
 	if ok {
 		return previous, true, false
 	}
 
-	evicted = c.lru.Add(key, value)
+	evicted = c.add(key, value)
 	return previous, false, evicted
 }
 
+// GetOrAdd looks up a key's value from the cache, updating the
+// "recently used"-ness of the key if found, and if not, adds the value.
+// Returns the value now stored for the key, whether it was already present
+// and whether an eviction occurred.  If the add was dropped, for example
+// by a tombstone or Options.TinyLFU, nothing is stored for the key and
+// actual is the zero value.
+func (c *Cache[K, V]) GetOrAdd(key K, value V) (actual V, ok, evicted bool) {
+	c.lock.Lock()
+	defer c.unlock()
+
+	actual, ok = c.get(key)
+	if ok {
+		return actual, true, false
+	}
+
+	evicted = c.add(key, value)
+	if !c.lru.Contains(key) {
+		return actual, false, evicted
+	}
+	return value, false, evicted
+}
+
+// LoadOrStore is GetOrAdd with the signature of sync.Map's LoadOrStore: it
+// returns the existing value for key if present, and otherwise adds and
+// returns value.  loaded reports whether the value was already present.
+// Concurrent callers adding the same key all get back the value of
+// whichever of them added it first, and the first value is never replaced,
+// so the eviction callback isn't called for the values that lost.  Like
+// GetOrAdd, it returns the zero value if the add was dropped.
+func (c *Cache[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	actual, loaded, _ = c.GetOrAdd(key, value)
+	return actual, loaded
+}
+
+// AddIfAbsent adds a value to the cache only if key isn't already present,
+// returning whether it was added: it isn't if the add was dropped, for
+// example by a tombstone or Options.TinyLFU.  Unlike LoadOrStore, it
+// doesn't update the "recently used"-ness of an existing key.
+func (c *Cache[K, V]) AddIfAbsent(key K, value V) (added bool) {
+	c.lock.Lock()
+	defer c.unlock()
+
+	if c.contains(key) {
+		return false
+	}
+	c.add(key, value)
+	return c.lru.Contains(key)
+}
+
 // Remove removes the provided key from the cache.
 func (c *Cache[K, V]) Remove(key K) (present bool) {
 	c.lock.Lock()
-	defer c.lock.Unlock()
+	defer c.unlock()
+
+	_, present = c.remove(key)
+	return present
+}
+
+// RemoveAndGet removes the provided key from the cache, returning the
+// removed value.
+func (c *Cache[K, V]) RemoveAndGet(key K) (value V, present bool) {
+	c.lock.Lock()
+	defer c.unlock()
 
-	return c.lru.Remove(key)
+	return c.remove(key)
 }
 
-// Resize changes the cache size.
+// Resize changes the cache size, returning the number of entries evicted
+// to shrink it.  The eviction callback is called for each of them, with
+// ReasonResized.
+//
+// Resize(0) evicts every unpinned entry and leaves a cache that stores
+// nothing until resized again.  Unlike New(0), it does NOT make the cache
+// unbounded: use SetUnbounded for that.
 func (c *Cache[K, V]) Resize(size int) (evicted int) {
 	c.lock.Lock()
-	defer c.lock.Unlock()
+	defer c.unlock()
+
+	evicted = c.lru.Resize(size)
+	c.stats.evicted(evicted)
+	return evicted
+}
+
+// SetUnbounded removes the cache's size limit, like creating it with a size
+// of zero, so that it never evicts entries to make room for new ones.
+// Resize bounds it again.
+func (c *Cache[K, V]) SetUnbounded() {
+	c.lock.Lock()
+	defer c.unlock()
+
+	c.lru.SetUnbounded()
+}
+
+// Compact releases memory held by the cache's internal storage beyond its
+// current size, such as after a large Resize down.
+func (c *Cache[K, V]) Compact() {
+	c.lock.Lock()
+	defer c.unlock()
+
+	c.lru.Compact()
+}
+
+// KeysPage returns up to limit keys from the cache, starting at cursor, and
+// the cursor to pass to get the next page.  Pass an empty cursor to get
+// the first page; an empty next cursor means there are no more keys.
+// A non-positive limit returns all remaining keys.  Expired entries are
+// left out, so pages may be short.  Keys are returned in no particular
+// order, and paging is best-effort: keys added or removed
+// while paging may cause other keys to be skipped or repeated.
+func (c *Cache[K, V]) KeysPage(cursor string, limit int) (keys []K, next string) {
+	offset := 0
+	if cursor != "" {
+		var err error
+		if offset, err = strconv.Atoi(cursor); err != nil || offset < 0 {
+			return nil, ""
+		}
+	}
+
+	c.lock.Lock()
+	defer c.unlock()
 
-	return c.lru.Resize(size)
+	keys, offset, more := c.lru.KeysPage(nil, offset, limit)
+	if c.ext != nil && c.ext.ttl != nil {
+		// leave out expired entries that haven't been removed yet
+		live := keys[:0]
+		for _, key := range keys {
+			if expired, stale := c.ext.ttl.expired(key); !expired || stale {
+				live = append(live, key)
+			}
+		}
+		keys = live
+	}
+	if more {
+		next = strconv.Itoa(offset)
+	}
+	return keys, next
 }
 
 // Len returns the number of items in the cache.
 func (c *Cache[K, V]) Len() int {
 	c.lock.Lock()
-	defer c.lock.Unlock()
+	defer c.unlock()
 
 	return c.lru.Len()
 }
+
+// LenLive returns the number of entries in the cache that haven't expired.
+// Unlike Len, it leaves out expired entries that haven't been removed yet,
+// except for those that expired within the last second: rather than
+// scanning the entries, it counts them by the second they expire in.
+// Entries still served as stale count as live.
+func (c *Cache[K, V]) LenLive() int {
+	c.lock.Lock()
+	defer c.unlock()
+
+	return c.lenLive()
+}
+
+// lenLive is LenLive.  c.lock must be held.
+func (c *Cache[K, V]) lenLive() int {
+	n := c.lru.Len()
+	if c.ext != nil && c.ext.ttl != nil {
+		n -= c.ext.ttl.dead()
+	}
+	return n
+}
@@ -8,6 +8,7 @@ import (
 	"strconv"
 	"sync"
 	"testing"
+	"time"
 	"unsafe"
 )
 
@@ -47,6 +48,9 @@ func TestNonShardSize(t *testing.T) {
 	if 128 != size {
 		t.Fatalf("expected shard to be 128-bytes in size, not %d", size)
 	}
+	if size%cacheLineSize != 0 {
+		t.Fatalf("expected Cache to be a multiple of the %d-byte cache line", cacheLineSize)
+	}
 }
 
 func newRand() *rand.Rand {
@@ -259,6 +263,57 @@ func TestLRU(t *testing.T) {
 	}
 }
 
+// test that WarmFrom fills the cache without invoking the eviction callback
+func TestLRUWarmFrom(t *testing.T) {
+	evictCounter := 0
+	onEvicted := func(k string, v int) {
+		evictCounter++
+	}
+	l, err := NewWithEvict[string, int](128, onEvicted)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	added := l.WarmFrom(func(yield func(string, int) bool) {
+		for i := 0; i < 256; i++ {
+			if !yield(strconv.Itoa(i), i) {
+				return
+			}
+		}
+	})
+	if added != 256 {
+		t.Errorf("expected 256 entries to be added, not %d", added)
+	}
+	if l.Len() != 128 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+	if evictCounter != 0 {
+		t.Errorf("onEvicted should not have been called: %v", evictCounter)
+	}
+	if v, ok := l.Get("255"); !ok || v != 255 {
+		t.Errorf("newest entry should be present: %v, %v", v, ok)
+	}
+
+	ch := make(chan Entry[string, int], 2)
+	ch <- Entry[string, int]{"a", 1}
+	ch <- Entry[string, int]{"b", 2}
+	close(ch)
+	if added := l.WarmFromChan(ch); added != 2 {
+		t.Errorf("expected 2 entries to be added, not %d", added)
+	}
+	if v, ok := l.Get("b"); !ok || v != 2 {
+		t.Errorf("b should be present: %v, %v", v, ok)
+	}
+	if evictCounter != 0 {
+		t.Errorf("onEvicted should not have been called: %v", evictCounter)
+	}
+
+	l.Add("c", 3)
+	if evictCounter != 1 {
+		t.Errorf("onEvicted should be restored after warming: %v", evictCounter)
+	}
+}
+
 // test that Add returns true/false if an eviction occurred
 func TestLRUAdd(t *testing.T) {
 	evictCounter := 0
@@ -298,6 +353,26 @@ func TestLRUContains(t *testing.T) {
 	}
 }
 
+// test that ContainsMulti reports membership per key in order
+func TestLRUContainsMulti(t *testing.T) {
+	l, err := New[string, int](2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add("1", 1)
+	l.Add("2", 2)
+	found := l.ContainsMulti([]string{"2", "3", "1"})
+	if len(found) != 3 || !found[0] || found[1] || !found[2] {
+		t.Errorf("unexpected membership: %v", found)
+	}
+
+	l.Add("3", 3)
+	if l.Contains("1") {
+		t.Errorf("ContainsMulti should not have updated recent-ness of 1")
+	}
+}
+
 // test that ContainsOrAdd doesn't update recent-ness
 func TestLRUContainsOrAdd(t *testing.T) {
 	l, err := New[string, int](2)
@@ -361,6 +436,114 @@ func TestLRUPeekOrAdd(t *testing.T) {
 	}
 }
 
+// test that GetOrAdd updates recent-ness and returns the stored value
+func TestLRUGetOrAdd(t *testing.T) {
+	l, err := New[string, int](2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add("1", 1)
+	l.Add("2", 2)
+	actual, contains, evict := l.GetOrAdd("1", 10)
+	if !contains {
+		t.Errorf("1 should be contained")
+	}
+	if evict {
+		t.Errorf("nothing should be evicted here")
+	}
+	if actual != 1 {
+		t.Errorf("actual is not equal to 1: %v", actual)
+	}
+
+	l.Add("3", 3)
+	if !l.Contains("1") {
+		t.Errorf("GetOrAdd should have updated recent-ness of 1")
+	}
+	if l.Contains("2") {
+		t.Errorf("2 should have been evicted")
+	}
+
+	actual, contains, evict = l.GetOrAdd("2", 2)
+	if contains {
+		t.Errorf("2 should not have been contained")
+	}
+	if !evict {
+		t.Errorf("an eviction should have occurred")
+	}
+	if actual != 2 {
+		t.Errorf("actual is not equal to 2: %v", actual)
+	}
+}
+
+// test that concurrent LoadOrStore and AddIfAbsent calls converge on the
+// first value stored, without evicting the losers
+func TestLRULoadOrStore(t *testing.T) {
+	evictions := 0
+	l, err := NewWithEvict[string, int](8, func(k string, v int) {
+		evictions++
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	results := make([]int, 16)
+	loads := make([]bool, 16)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if i%2 == 0 {
+				results[i], loads[i] = l.LoadOrStore("key", i)
+			} else {
+				l.AddIfAbsent("key", i)
+				results[i], _ = l.Peek("key")
+				loads[i] = true
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	winner, _ := l.Peek("key")
+	stores := 0
+	for i, v := range results {
+		if v != winner {
+			t.Errorf("%d: expected the winning value %d, not %d", i, winner, v)
+		}
+		if !loads[i] {
+			stores++
+		}
+	}
+	if stores > 1 {
+		t.Errorf("at most one LoadOrStore should have stored its value: %d", stores)
+	}
+	if evictions != 0 {
+		t.Errorf("losing values shouldn't be passed to the eviction callback")
+	}
+
+	if l.AddIfAbsent("key", 100) {
+		t.Errorf("key is already present")
+	}
+	if !l.AddIfAbsent("other", 100) {
+		t.Errorf("other should have been added")
+	}
+
+	// dropped adds aren't reported as stored
+	lt, err := NewWithOptions[string, int](8, Options[string, int]{TombstoneTTL: time.Minute})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	lt.Add("key", 1)
+	lt.Remove("key")
+	if lt.AddIfAbsent("key", 2) {
+		t.Errorf("an add dropped for a tombstone shouldn't be reported as added")
+	}
+	if actual, loaded := lt.LoadOrStore("key", 3); loaded || actual != 0 || lt.Contains("key") {
+		t.Errorf("a store dropped for a tombstone shouldn't be reported as stored: %v, %v", actual, loaded)
+	}
+}
+
 // test that Peek doesn't update recent-ness
 func TestLRUPeek(t *testing.T) {
 	l, err := New[string, int](2)
@@ -380,6 +563,103 @@ func TestLRUPeek(t *testing.T) {
 	}
 }
 
+// test that RemoveAndGet returns the removed value
+func TestLRURemoveAndGet(t *testing.T) {
+	evictCounter := 0
+	onEvicted := func(k string, v int) {
+		evictCounter++
+	}
+	l, err := NewWithEvict[string, int](2, onEvicted)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add("1", 1)
+	if v, ok := l.RemoveAndGet("1"); !ok || v != 1 {
+		t.Errorf("1 should have been removed with value 1: %v, %v", v, ok)
+	}
+	if evictCounter != 1 {
+		t.Errorf("onEvicted should have been called 1 time: %v", evictCounter)
+	}
+	if l.Contains("1") {
+		t.Errorf("1 should not be contained")
+	}
+	if _, ok := l.RemoveAndGet("1"); ok {
+		t.Errorf("1 should not have been present")
+	}
+}
+
+// test that a Pipeline executes queued operations in order
+func TestLRUPipeline(t *testing.T) {
+	l, err := New[string, int](2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add("1", 1)
+	p := l.Pipeline()
+	results := p.Get("1").Add("2", 2).Peek("2").Add("3", 3).Remove("1").Get("1").Exec()
+	if len(results) != 6 {
+		t.Fatalf("expected 6 results, not %d", len(results))
+	}
+	if r := results[0]; !r.Ok || r.Value != 1 {
+		t.Errorf("Get 1 should have returned 1: %v", r)
+	}
+	if results[1].Ok {
+		t.Errorf("Add 2 should not have evicted")
+	}
+	if r := results[2]; !r.Ok || r.Value != 2 {
+		t.Errorf("Peek 2 should have returned 2: %v", r)
+	}
+	if !results[3].Ok {
+		t.Errorf("Add 3 should have evicted")
+	}
+	if p.Len() != 0 {
+		t.Errorf("pipeline should be empty after Exec")
+	}
+	if results := p.Add("4", 4).Exec(); len(results) != 1 {
+		t.Errorf("pipeline should be reusable after Exec")
+	}
+}
+
+// test that KeysPage visits every key exactly once on an unchanging cache
+func TestLRUKeysPage(t *testing.T) {
+	l, err := New[string, int](128)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 100; i++ {
+		l.Add(strconv.Itoa(i), i)
+	}
+
+	seen := make(map[string]bool)
+	cursor, pages := "", 0
+	for {
+		keys, next := l.KeysPage(cursor, 30)
+		pages++
+		if len(keys) > 30 {
+			t.Fatalf("page too large: %d", len(keys))
+		}
+		for _, k := range keys {
+			if seen[k] {
+				t.Fatalf("key %s returned twice", k)
+			}
+			seen[k] = true
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	if len(seen) != 100 || pages != 4 {
+		t.Errorf("expected 100 keys over 4 pages, not %d over %d", len(seen), pages)
+	}
+
+	if keys, next := l.KeysPage("bogus", 10); keys != nil || next != "" {
+		t.Errorf("invalid cursors should return nothing")
+	}
+}
+
 // test that Resize can upsize and downsize
 func TestLRUResize(t *testing.T) {
 	onEvictCounter := 0
@@ -480,3 +760,61 @@ func BenchmarkLRU_HotKey(b *testing.B) {
 		// b.Logf("hit: %d miss: %d ratio: %f", hit, miss, float64(hit)/float64(miss))
 	})
 }
+
+func TestLRUPipelinePanic(t *testing.T) {
+	l, err := NewWithOptions[int, int](8, Options[int, int]{
+		OnAdd: func(key int, value int) {
+			if key == 2 {
+				panic("boom")
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("expected the callback's panic")
+			}
+		}()
+		l.Pipeline().Add(1, 1).Add(2, 2).Exec()
+	}()
+
+	// the lock was released
+	if l.Len() != 2 {
+		t.Errorf("bad len: %v", l.Len())
+	}
+}
+
+func TestLRUResizeZero(t *testing.T) {
+	l, err := New[int, int](4)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		l.Add(i, i)
+	}
+
+	// Resize(0) empties the cache, rather than making it unbounded
+	if evicted := l.Resize(0); evicted != 4 || l.Len() != 0 {
+		t.Errorf("expected Resize(0) to evict everything: %d evicted, len %d", evicted, l.Len())
+	}
+	l.Add(5, 5)
+	if l.Len() != 0 {
+		t.Errorf("a cache resized to zero should store nothing: %v", l.Keys())
+	}
+
+	l.SetUnbounded()
+	for i := 0; i < 100; i++ {
+		if l.Add(i, i) {
+			t.Fatalf("unbounded caches shouldn't evict")
+		}
+	}
+	if l.Len() != 100 {
+		t.Errorf("bad len: %v", l.Len())
+	}
+	if evicted := l.Resize(10); evicted != 90 {
+		t.Errorf("expected Resize to bound the cache again: %d evicted", evicted)
+	}
+}
@@ -0,0 +1,69 @@
+// Package lruexpvar publishes the metrics of caches from package lru as
+// expvar variables, so they show up in /debug/vars next to a program's other
+// metrics.
+//
+// Every cache is published under its name in a single expvar.Map called
+// "lru", so a monitoring system scraping /debug/vars sees one object per
+// cache:
+//
+//	"lru": {"sessions": {"hits": 10, "misses": 2, "hit_ratio": 0.83, ...}}
+package lruexpvar
+
+import (
+	"expvar"
+	"sync"
+
+	lru "github.com/bpowers/approx-lru"
+)
+
+// Source is a cache whose metrics can be published.  Cache and ShardedCache
+// implement it.
+type Source interface {
+	Stats() lru.Stats
+	Len() int
+}
+
+var (
+	initOnce sync.Once
+	caches   *expvar.Map
+)
+
+// Publish publishes c's metrics under name.  Like expvar.Publish, it panics
+// if a cache has already been published under name.
+func Publish(name string, c Source) {
+	initOnce.Do(func() {
+		caches = expvar.NewMap("lru")
+	})
+	if caches.Get(name) != nil {
+		panic("lruexpvar: reuse of cache name " + name)
+	}
+	caches.Set(name, Func(c))
+}
+
+// Func returns an expvar.Var reporting c's metrics, for callers publishing
+// them under their own expvar names.
+func Func(c Source) expvar.Func {
+	return func() interface{} {
+		return Metrics(c)
+	}
+}
+
+// Metrics returns a snapshot of c's metrics, keyed by metric name.
+func Metrics(c Source) map[string]interface{} {
+	stats := c.Stats()
+	return map[string]interface{}{
+		"size":               c.Len(),
+		"live":               stats.Live,
+		"hits":               stats.Hits,
+		"misses":             stats.Misses,
+		"hit_ratio":          stats.HitRatio(),
+		"adds":               stats.Adds,
+		"evictions":          stats.Evictions,
+		"expirations":        stats.Expirations,
+		"loads":              stats.Loads,
+		"load_seconds_total": stats.LoadTime.Seconds(),
+		"load_seconds_mean":  stats.MeanLoadTime().Seconds(),
+		"load_waiters":       stats.LoadWaiters,
+		"load_waiters_max":   stats.MaxLoadWaiters,
+	}
+}
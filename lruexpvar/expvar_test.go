@@ -0,0 +1,39 @@
+package lruexpvar
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+
+	lru "github.com/bpowers/approx-lru"
+)
+
+func TestPublish(t *testing.T) {
+	c, err := lru.New[string, int](8)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	c.Add("1", 1)
+	c.Get("1")
+	c.Get("2")
+	if _, err := c.GetOrLoad("3", func(string) (int, error) { return 3, nil }); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	Publish("test", c)
+	var vars map[string]map[string]float64
+	if err := json.Unmarshal([]byte(expvar.Get("lru").String()), &vars); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	m := vars["test"]
+	if m["size"] != 2 || m["hits"] != 1 || m["misses"] != 2 || m["hit_ratio"] != 1.0/3 || m["loads"] != 1 {
+		t.Errorf("unexpected metrics: %v", m)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected reusing a name to panic")
+		}
+	}()
+	Publish("test", c)
+}
@@ -0,0 +1,79 @@
+// Package lrutest provides utilities for testing code that uses the caches
+// of package lru.
+package lrutest
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	lru "github.com/bpowers/approx-lru"
+)
+
+// ErrInjected is the error returned by loads failed by a FaultInjector.
+var ErrInjected = errors.New("lrutest: injected failure")
+
+// FaultInjector degrades a cache and its loaders on purpose, so tests can
+// check how code behaves when they are slow or failing.  Set its fields
+// before using it; it is safe for concurrent use afterwards.
+type FaultInjector[K comparable, V any] struct {
+	// Latency is added to every operation and load.
+	Latency time.Duration
+	// FailureRate is the fraction of operations and loads that fail, from
+	// 0 to 1.  Failed lookups miss, failed adds and removes are dropped,
+	// and failed loads return ErrInjected.
+	FailureRate float64
+	// Seed seeds the failures, making them reproducible.
+	Seed int64
+
+	once sync.Once
+	mu   sync.Mutex
+	rng  *rand.Rand
+}
+
+// fail waits for the injected latency, then reports whether to fail.
+func (f *FaultInjector[K, V]) fail() bool {
+	if f.Latency > 0 {
+		time.Sleep(f.Latency)
+	}
+	if f.FailureRate <= 0 {
+		return false
+	}
+	f.once.Do(func() {
+		f.rng = rand.New(rand.NewSource(f.Seed))
+	})
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.rng.Float64() < f.FailureRate
+}
+
+// Wrap returns c with faults injected into its operations.
+func (f *FaultInjector[K, V]) Wrap(c lru.Cacher[K, V]) *lru.MiddlewareCache[K, V] {
+	return lru.WithMiddleware(c, f.Middleware())
+}
+
+// Middleware returns a middleware injecting faults, for use with
+// lru.WithMiddleware alongside other middleware.  Len and Purge are
+// delayed but never fail.
+func (f *FaultInjector[K, V]) Middleware() lru.Middleware[K, V] {
+	return func(next lru.OpFunc[K, V]) lru.OpFunc[K, V] {
+		return func(op lru.Op[K, V]) lru.OpResult[V] {
+			if f.fail() && op.Kind != lru.OpLen && op.Kind != lru.OpPurge {
+				return lru.OpResult[V]{}
+			}
+			return next(op)
+		}
+	}
+}
+
+// Loader returns loader with faults injected, for use with GetOrLoad.
+func (f *FaultInjector[K, V]) Loader(loader func(key K) (V, error)) func(key K) (V, error) {
+	return func(key K) (V, error) {
+		if f.fail() {
+			var zero V
+			return zero, ErrInjected
+		}
+		return loader(key)
+	}
+}
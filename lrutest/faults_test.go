@@ -0,0 +1,46 @@
+package lrutest
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	lru "github.com/bpowers/approx-lru"
+)
+
+func TestFaultInjector(t *testing.T) {
+	inner, err := lru.New[string, int](256)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	f := &FaultInjector[string, int]{FailureRate: 0.5, Seed: 1}
+	c := f.Wrap(inner)
+
+	for i := 0; i < 200; i++ {
+		c.Add(strconv.Itoa(i), i)
+	}
+	if n := inner.Len(); n < 50 || n > 150 {
+		t.Errorf("expected about half the adds to fail: %d succeeded", n)
+	}
+	if c.Len() != inner.Len() {
+		t.Errorf("Len shouldn't fail")
+	}
+
+	failed := 0
+	loader := f.Loader(func(key string) (int, error) { return 1, nil })
+	for i := 0; i < 100; i++ {
+		if _, err := loader("x"); err == ErrInjected {
+			failed++
+		}
+	}
+	if failed < 25 || failed > 75 {
+		t.Errorf("expected about half the loads to fail: %d did", failed)
+	}
+
+	slow := &FaultInjector[string, int]{Latency: 10 * time.Millisecond}
+	start := time.Now()
+	slow.Wrap(inner).Get("1")
+	if elapsed := time.Since(start); elapsed < 10*time.Millisecond {
+		t.Errorf("expected the lookup to be delayed: %v", elapsed)
+	}
+}
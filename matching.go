@@ -0,0 +1,168 @@
+package lru
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// RemoveFunc removes every entry for which fn returns true, returning the
+// number of entries removed.  fn is called with the cache lock held, so it
+// must be quick and must not use the cache.  It is O(n) expensive.
+func (c *Cache[K, V]) RemoveFunc(fn func(key K, value V) bool) (removed int) {
+	c.lock.Lock()
+	defer c.unlock()
+
+	// removing entries moves others around, so collect the keys up front
+	keys, _, _ := c.lru.KeysPage(nil, 0, 0)
+	for _, key := range keys {
+		// not c.peek: visiting stale entries isn't serving them
+		c.expire(key)
+		value, ok := c.lru.Peek(key)
+		if !ok || !fn(key, value) {
+			continue
+		}
+		if _, ok := c.remove(key); ok {
+			removed++
+		}
+	}
+	return removed
+}
+
+// compiledPatterns caches the regular expressions compiled for
+// RemoveMatching, as invalidation rules tend to reuse a few patterns.
+var compiledPatterns = func() *Cache[string, *regexp.Regexp] {
+	c, err := New[string, *regexp.Regexp](128)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}()
+
+// compilePattern compiles pattern, a glob or a regular expression prefixed
+// with "re:", going through compiledPatterns.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := compiledPatterns.Get(pattern); ok {
+		return re, nil
+	}
+	var expr string
+	if strings.HasPrefix(pattern, "re:") {
+		expr = strings.TrimPrefix(pattern, "re:")
+	} else {
+		var err error
+		if expr, err = globToRegexp(pattern); err != nil {
+			return nil, err
+		}
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	compiledPatterns.Add(pattern, re)
+	return re, nil
+}
+
+var errBadGlob = errors.New("lru: malformed glob pattern")
+
+// globToRegexp translates a glob into an anchored regular expression.  '*'
+// matches any run of characters, '?' any single character, "[...]" a
+// character class (negated with a leading '!' or '^'), and '\' escapes the
+// following character.
+func globToRegexp(glob string) (string, error) {
+	var b strings.Builder
+	b.WriteString(`^`)
+	for i := 0; i < len(glob); i++ {
+		switch ch := glob[i]; ch {
+		case '*':
+			b.WriteString(`.*`)
+		case '?':
+			b.WriteString(`.`)
+		case '\\':
+			if i++; i >= len(glob) {
+				return "", errBadGlob
+			}
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				return "", errBadGlob
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString(`[` + class + `]`)
+			i += end + 1
+		default:
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	b.WriteString(`$`)
+	return b.String(), nil
+}
+
+// RemoveMatching removes every entry of c whose key matches pattern,
+// returning the number of entries removed.  pattern is a glob, where '*'
+// matches any run of characters (including '/'), '?' any single character
+// and "[...]" a character class, or a regular expression if prefixed with
+// "re:".  Compiled patterns are cached, so rule-driven invalidation can
+// reuse patterns cheaply.  It is O(n) expensive.
+func RemoveMatching[V any](c *Cache[string, V], pattern string) (removed int, err error) {
+	re, err := compilePattern(pattern)
+	if err != nil {
+		return 0, err
+	}
+	return c.RemoveFunc(func(key string, _ V) bool {
+		return re.MatchString(key)
+	}), nil
+}
+
+// RemoveFunc removes every entry for which fn returns true, returning the
+// number of entries removed.  Shards are visited one at a time, and fn is
+// called with the shard's lock held, so it must be quick and must not use
+// the cache.  It is O(n) expensive.
+func (c *ShardedCache[V]) RemoveFunc(fn func(key string, value V) bool) (removed int) {
+	for i := range c.shards {
+		shard := &c.shards[i]
+		shard.mu.Lock()
+		keys, _, _ := shard.lru.KeysPage(nil, 0, 0)
+		for _, key := range keys {
+			if value, ok := shard.lru.Peek(key); ok && fn(key, value) {
+				shard.lru.Remove(key)
+				removed++
+			}
+		}
+		shard.mu.Unlock()
+	}
+	return removed
+}
+
+// RemoveMatching removes every entry whose key matches pattern, like the
+// RemoveMatching function for Cache.
+func (c *ShardedCache[V]) RemoveMatching(pattern string) (removed int, err error) {
+	re, err := compilePattern(pattern)
+	if err != nil {
+		return 0, err
+	}
+	return c.RemoveFunc(func(key string, _ V) bool {
+		return re.MatchString(key)
+	}), nil
+}
+
+// RemovePrefix removes every entry of c whose key starts with prefix,
+// returning the number of entries removed.  It is O(n) expensive; to
+// invalidate groups of keys often, tag them with AddWithTags or index them
+// with Options.Indexes, and use InvalidateTag or RemoveBySecondary.
+func RemovePrefix[V any](c *Cache[string, V], prefix string) (removed int) {
+	return c.RemoveFunc(func(key string, _ V) bool {
+		return strings.HasPrefix(key, prefix)
+	})
+}
+
+// RemovePrefix removes every entry whose key starts with prefix, returning
+// the number of entries removed.  It is O(n) expensive.
+func (c *ShardedCache[V]) RemovePrefix(prefix string) (removed int) {
+	return c.RemoveFunc(func(key string, _ V) bool {
+		return strings.HasPrefix(key, prefix)
+	})
+}
@@ -0,0 +1,140 @@
+package lru
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestLRURemoveFunc(t *testing.T) {
+	l, err := New[int, int](128)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 100; i++ {
+		l.Add(i, i*i)
+	}
+	if removed := l.RemoveFunc(func(k, v int) bool { return v%2 == 0 }); removed != 50 {
+		t.Errorf("expected 50 removals, not %d", removed)
+	}
+	if l.Len() != 50 || l.Contains(2) || !l.Contains(3) {
+		t.Errorf("expected only odd squares to remain")
+	}
+}
+
+func TestLRURemoveFuncDegraded(t *testing.T) {
+	now := time.Unix(1000, 0)
+	l, err := NewWithOptions[int, int](8, Options[int, int]{TTL: time.Minute})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.ext.ttl.now = func() time.Time { return now }
+	for i := 0; i < 4; i++ {
+		l.Add(i, i)
+	}
+	l.SetDegraded(time.Hour)
+	now = now.Add(2 * time.Minute)
+
+	if removed := l.RemoveFunc(func(k, v int) bool { return k == 0 }); removed != 1 {
+		t.Errorf("expected 1 removal, not %d", removed)
+	}
+	if served := l.DegradedServes(); served != 0 {
+		t.Errorf("visiting stale entries shouldn't count as serving them: %d", served)
+	}
+}
+
+func TestShardedRemoveMatching(t *testing.T) {
+	l, err := NewSharded[int](256, 4)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 20; i++ {
+		l.Add("user:"+strconv.Itoa(i), i)
+		l.Add("item:"+strconv.Itoa(i), i)
+	}
+
+	if removed, err := l.RemoveMatching("user:1*"); err != nil || removed != 11 {
+		t.Errorf("expected 11 removals, not %d: %v", removed, err)
+	}
+	if removed := l.RemoveFunc(func(k string, v int) bool { return v >= 10 }); removed != 10 {
+		t.Errorf("expected 10 removals, not %d", removed)
+	}
+	if l.Len() != 19 || !l.Contains("item:9") || l.Contains("user:1") {
+		t.Errorf("unexpected entries left: %d", l.Len())
+	}
+	if _, err := l.RemoveMatching("user:[1"); err == nil {
+		t.Errorf("expected a malformed pattern to be rejected")
+	}
+}
+
+func TestRemoveMatching(t *testing.T) {
+	l, err := New[string, int](128)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	reset := func() {
+		l.Purge()
+		for _, key := range []string{"user:1", "user:2", "user:10", "user:1/profile", "item:1", "*star"} {
+			l.Add(key, 0)
+		}
+	}
+
+	for _, tc := range []struct {
+		pattern string
+		removed int
+	}{
+		{"user:*", 4},
+		{"user:?", 2},
+		{"user:[12]", 2},
+		{"user:[!1]", 1},
+		{"*:1*", 4},
+		{`\*star`, 1},
+		{"item:1", 1},
+		{"re:^user:\\d+$", 3},
+		{"re:1$", 2},
+		{"nothing", 0},
+	} {
+		reset()
+		removed, err := RemoveMatching(l, tc.pattern)
+		if err != nil {
+			t.Errorf("%q: %v", tc.pattern, err)
+		}
+		if removed != tc.removed {
+			t.Errorf("%q: expected %d removals, not %d", tc.pattern, tc.removed, removed)
+		}
+	}
+
+	for _, pattern := range []string{"user:[1", `trailing\`, "re:("} {
+		if _, err := RemoveMatching(l, pattern); err == nil {
+			t.Errorf("expected %q to be rejected", pattern)
+		}
+	}
+	if !compiledPatterns.Contains("user:*") {
+		t.Errorf("expected compiled patterns to be cached")
+	}
+}
+
+func TestRemovePrefix(t *testing.T) {
+	l, err := New[string, int](128)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	sharded, err := NewSharded[int](128, 4)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for _, key := range []string{"tenant:1:/a", "tenant:1:/b", "tenant:10:/a", "tenant:2:/a"} {
+		l.Add(key, 0)
+		sharded.Add(key, 0)
+	}
+
+	if removed := RemovePrefix(l, "tenant:1:"); removed != 2 {
+		t.Errorf("expected 2 removals, not %d", removed)
+	}
+	if removed := sharded.RemovePrefix("tenant:1:"); removed != 2 {
+		t.Errorf("expected 2 removals, not %d", removed)
+	}
+	if l.Len() != 2 || !l.Contains("tenant:10:/a") || sharded.Len() != 2 {
+		t.Errorf("only tenant 1's entries should be removed: %v", l.Keys())
+	}
+}
@@ -0,0 +1,117 @@
+package lru
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// Sizer is implemented by values that know how much memory they use.
+// Caches bounded by memory use it in place of estimating a value's size.
+type Sizer interface {
+	// SizeBytes returns the number of bytes the value uses, including
+	// any memory it references.
+	SizeBytes() int64
+}
+
+// mapEntryOverhead approximates the per-entry overhead of a Go map beyond
+// its keys and values.
+const mapEntryOverhead = 16
+
+// maxSizeDepth bounds how deep EstimateSize follows pointers, which also
+// stops it from looping on cyclic values.
+const maxSizeDepth = 8
+
+// NewWithMemoryLimit constructs a cache bounded by the approximate memory
+// used by its entries, as estimated by EstimateSize, rather than by their
+// number.  Adding an entry evicts old entries until the estimated total is
+// at most maxBytes again.  To combine a memory limit with other options,
+// use NewWithOptions with MaxCost set to the limit and Cost set to
+// EstimateSize[K, V].
+func NewWithMemoryLimit[K comparable, V any](maxBytes int64) (*Cache[K, V], error) {
+	return NewWithCost[K, V](maxBytes, EstimateSize[K, V])
+}
+
+// EstimateSize estimates the number of bytes a cache entry uses: the
+// cache's own bookkeeping, plus the key and value along with the memory
+// they reference.  Values implementing Sizer report their own size;
+// otherwise it is estimated with reflection, following pointers, slices,
+// maps and interfaces a few levels deep.  Memory shared between entries is
+// counted once per entry.
+func EstimateSize[K comparable, V any](key K, value V) int64 {
+	var k K
+	var v V
+	// the entry in the LRU's data slice, the items map and the cost map
+	size := int64(8+unsafe.Sizeof(k)+unsafe.Sizeof(v)) +
+		2*int64(unsafe.Sizeof(k)+8+mapEntryOverhead)
+	size += referencedSize(reflect.ValueOf(&key).Elem(), 0)
+	if s, ok := any(value).(Sizer); ok {
+		return size + s.SizeBytes()
+	}
+	return size + referencedSize(reflect.ValueOf(&value).Elem(), 0)
+}
+
+// referencedSize estimates the memory referenced by v, not counting v
+// itself.
+func referencedSize(v reflect.Value, depth int) (size int64) {
+	if depth > maxSizeDepth {
+		return 0
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return int64(v.Len())
+	case reflect.Slice:
+		if v.IsNil() {
+			return 0
+		}
+		size = int64(v.Cap()) * int64(v.Type().Elem().Size())
+		for i := 0; i < v.Len(); i++ {
+			size += referencedSize(v.Index(i), depth+1)
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			size += referencedSize(v.Index(i), depth+1)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			size += referencedSize(v.Field(i), depth+1)
+		}
+	case reflect.Pointer:
+		if v.IsNil() {
+			return 0
+		}
+		if v.CanInterface() {
+			if s, ok := v.Interface().(Sizer); ok {
+				return s.SizeBytes()
+			}
+		}
+		size = int64(v.Type().Elem().Size()) + referencedSize(v.Elem(), depth+1)
+	case reflect.Interface:
+		if v.IsNil() {
+			return 0
+		}
+		elem := v.Elem()
+		if elem.CanInterface() {
+			if s, ok := elem.Interface().(Sizer); ok {
+				return s.SizeBytes()
+			}
+		}
+		size = int64(elem.Type().Size()) + referencedSize(elem, depth+1)
+	case reflect.Map:
+		if v.IsNil() {
+			return 0
+		}
+		t := v.Type()
+		size = int64(v.Len()) * int64(t.Key().Size()+t.Elem().Size()+mapEntryOverhead)
+		iter := v.MapRange()
+		for iter.Next() {
+			size += referencedSize(iter.Key(), depth+1) + referencedSize(iter.Value(), depth+1)
+		}
+	}
+	return size
+}
+
+// BytesUsed returns the estimated memory used by the entries of a cache
+// created with NewWithMemoryLimit.  It is the same as Cost.
+func (c *Cache[K, V]) BytesUsed() int64 {
+	return c.Cost()
+}
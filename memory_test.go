@@ -0,0 +1,57 @@
+package lru
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type sizedValue int64
+
+func (s sizedValue) SizeBytes() int64 { return int64(s) }
+
+func TestEstimateSize(t *testing.T) {
+	overhead := EstimateSize[string, []byte]("", nil)
+	if size := EstimateSize[string, []byte]("key", make([]byte, 100)); size != overhead+3+100 {
+		t.Errorf("expected the key and value bytes to be counted: %d", size-overhead)
+	}
+
+	type record struct {
+		name string
+		tags map[string]bool
+		next *record
+	}
+	r := &record{name: "abcd", tags: map[string]bool{"x": true}}
+	r.next = r // cycles are cut off rather than followed forever
+	if size := EstimateSize[int, *record](1, r); size <= EstimateSize[int, *record](1, nil) {
+		t.Errorf("expected the referenced memory to be counted: %d", size)
+	}
+
+	if size := EstimateSize[int, interface{}](1, sizedValue(1000)); size != EstimateSize[int, interface{}](1, nil)+1000 {
+		t.Errorf("expected the Sizer's size to be used: %d", size)
+	}
+}
+
+func TestLRUMemoryLimit(t *testing.T) {
+	l, err := NewWithMemoryLimit[string, string](16 * 1024)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	value := strings.Repeat("x", 1000)
+	for i := 0; i < 100; i++ {
+		l.Add(strconv.Itoa(i), value)
+	}
+	if used := l.BytesUsed(); used > 16*1024 || used < 8*1024 {
+		t.Errorf("expected the cache to be near its memory limit: %d", used)
+	}
+	if l.Len() >= 16 {
+		t.Errorf("expected entries to be evicted to fit: %d", l.Len())
+	}
+	if !l.Contains("99") {
+		t.Errorf("the most recent entry should be cached")
+	}
+
+	if _, err := NewWithMemoryLimit[string, string](0); err == nil {
+		t.Errorf("expected a non-positive limit to be rejected")
+	}
+}
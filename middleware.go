@@ -0,0 +1,136 @@
+package lru
+
+// OpKind identifies a cache operation seen by a Middleware.
+type OpKind uint8
+
+// The kinds of operation, one per method of Cacher.
+const (
+	OpAdd OpKind = iota
+	OpGet
+	OpContains
+	OpPeek
+	OpRemove
+	OpLen
+	OpPurge
+)
+
+func (k OpKind) String() string {
+	switch k {
+	case OpAdd:
+		return "add"
+	case OpGet:
+		return "get"
+	case OpContains:
+		return "contains"
+	case OpPeek:
+		return "peek"
+	case OpRemove:
+		return "remove"
+	case OpLen:
+		return "len"
+	case OpPurge:
+		return "purge"
+	default:
+		return "unknown"
+	}
+}
+
+// Op is a cache operation.  Key is unset for OpLen and OpPurge, and Value
+// is only set for OpAdd.
+type Op[K comparable, V any] struct {
+	Kind  OpKind
+	Key   K
+	Value V
+}
+
+// OpResult is the result of a cache operation.  OK is the operation's
+// boolean result: whether an OpAdd evicted an entry, or whether the key
+// of an OpGet, OpContains, OpPeek or OpRemove was found.  Value is the
+// value found by OpGet and OpPeek, and Len the length returned by OpLen.
+type OpResult[V any] struct {
+	Value V
+	OK    bool
+	Len   int
+}
+
+// OpFunc performs a cache operation.
+type OpFunc[K comparable, V any] func(op Op[K, V]) OpResult[V]
+
+// Middleware wraps the OpFunc performing cache operations, for example to
+// trace them, check permissions or rewrite keys.  It may change the op
+// before passing it to next, or not call next at all.
+type Middleware[K comparable, V any] func(next OpFunc[K, V]) OpFunc[K, V]
+
+// MiddlewareCache is a Cacher passing every operation through a chain of
+// middleware before it reaches the underlying cache.
+type MiddlewareCache[K comparable, V any] struct {
+	do OpFunc[K, V]
+}
+
+// WithMiddleware wraps c so that its operations pass through middleware.
+// The first middleware is the outermost: it sees each operation first, and
+// its result last.
+func WithMiddleware[K comparable, V any](c Cacher[K, V], middleware ...Middleware[K, V]) *MiddlewareCache[K, V] {
+	do := func(op Op[K, V]) (result OpResult[V]) {
+		switch op.Kind {
+		case OpAdd:
+			result.OK = c.Add(op.Key, op.Value)
+		case OpGet:
+			result.Value, result.OK = c.Get(op.Key)
+		case OpContains:
+			result.OK = c.Contains(op.Key)
+		case OpPeek:
+			result.Value, result.OK = c.Peek(op.Key)
+		case OpRemove:
+			result.OK = c.Remove(op.Key)
+		case OpLen:
+			result.Len = c.Len()
+		case OpPurge:
+			c.Purge()
+		}
+		return result
+	}
+	for i := len(middleware) - 1; i >= 0; i-- {
+		do = middleware[i](do)
+	}
+	return &MiddlewareCache[K, V]{do: do}
+}
+
+// Add adds a value to the cache. Returns true if an eviction occurred.
+func (c *MiddlewareCache[K, V]) Add(key K, value V) (evicted bool) {
+	return c.do(Op[K, V]{Kind: OpAdd, Key: key, Value: value}).OK
+}
+
+// Get looks up a key's value from the cache.
+func (c *MiddlewareCache[K, V]) Get(key K) (value V, ok bool) {
+	r := c.do(Op[K, V]{Kind: OpGet, Key: key})
+	return r.Value, r.OK
+}
+
+// Contains checks if a key is in the cache, without updating the
+// recent-ness.
+func (c *MiddlewareCache[K, V]) Contains(key K) bool {
+	return c.do(Op[K, V]{Kind: OpContains, Key: key}).OK
+}
+
+// Peek returns the key value (or undefined if not found) without updating
+// the "recently used"-ness of the key.
+func (c *MiddlewareCache[K, V]) Peek(key K) (value V, ok bool) {
+	r := c.do(Op[K, V]{Kind: OpPeek, Key: key})
+	return r.Value, r.OK
+}
+
+// Remove removes the provided key from the cache.
+func (c *MiddlewareCache[K, V]) Remove(key K) (present bool) {
+	return c.do(Op[K, V]{Kind: OpRemove, Key: key}).OK
+}
+
+// Len returns the number of items in the cache.
+func (c *MiddlewareCache[K, V]) Len() int {
+	return c.do(Op[K, V]{Kind: OpLen}).Len
+}
+
+// Purge is used to completely clear the cache.
+func (c *MiddlewareCache[K, V]) Purge() {
+	c.do(Op[K, V]{Kind: OpPurge})
+}
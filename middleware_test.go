@@ -0,0 +1,57 @@
+package lru
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	inner, err := New[string, int](8)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	var trace []string
+	tracing := func(next OpFunc[string, int]) OpFunc[string, int] {
+		return func(op Op[string, int]) OpResult[int] {
+			trace = append(trace, op.Kind.String()+" "+op.Key)
+			return next(op)
+		}
+	}
+	namespacing := func(next OpFunc[string, int]) OpFunc[string, int] {
+		return func(op Op[string, int]) OpResult[int] {
+			op.Key = "ns:" + op.Key
+			return next(op)
+		}
+	}
+	readOnly := func(next OpFunc[string, int]) OpFunc[string, int] {
+		return func(op Op[string, int]) OpResult[int] {
+			if op.Kind == OpRemove && strings.HasPrefix(op.Key, "ns:keep") {
+				return OpResult[int]{}
+			}
+			return next(op)
+		}
+	}
+	c := WithMiddleware[string, int](inner, tracing, namespacing, readOnly)
+
+	c.Add("a", 1)
+	c.Add("keep", 2)
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Errorf("bad value: %v, %v", v, ok)
+	}
+	if !inner.Contains("ns:a") {
+		t.Errorf("expected the key to be rewritten")
+	}
+	if c.Remove("keep") || !c.Contains("keep") {
+		t.Errorf("expected the remove to be blocked")
+	}
+	if c.Len() != 2 {
+		t.Errorf("bad len: %v", c.Len())
+	}
+
+	expected := []string{"add a", "add keep", "get a", "remove keep", "contains keep", "len "}
+	if !reflect.DeepEqual(trace, expected) {
+		t.Errorf("expected the outermost middleware to see the original ops: %v", trace)
+	}
+}
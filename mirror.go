@@ -0,0 +1,74 @@
+package lru
+
+// mirrorOp is a change waiting to be applied to a mirror.
+type mirrorOp[K comparable, V any] struct {
+	key   K
+	value V
+	kind  mirrorKind
+}
+
+type mirrorKind uint8
+
+const (
+	mirrorAdd mirrorKind = iota
+	mirrorRemove
+	mirrorPurge
+)
+
+// mirror applies a cache's adds and removes to a secondary cache in the
+// background.  Like asyncEvicter, ops are only queued with the cache lock
+// held.
+type mirror[K comparable, V any] struct {
+	target Cacher[K, V]
+	queue  chan mirrorOp[K, V]
+	// dropped counts ops dropped because the queue was full.
+	dropped uint64
+	done    chan struct{}
+}
+
+func newMirror[K comparable, V any](target Cacher[K, V], size int) *mirror[K, V] {
+	m := &mirror[K, V]{
+		target: target,
+		queue:  make(chan mirrorOp[K, V], size),
+		done:   make(chan struct{}),
+	}
+	go m.run()
+	return m
+}
+
+func (m *mirror[K, V]) run() {
+	defer close(m.done)
+	for op := range m.queue {
+		switch op.kind {
+		case mirrorAdd:
+			m.target.Add(op.key, op.value)
+		case mirrorRemove:
+			m.target.Remove(op.key)
+		case mirrorPurge:
+			m.target.Purge()
+		}
+	}
+}
+
+// send queues op, dropping it if the queue is full: mirroring is best
+// effort, and must never hold up the primary cache.
+func (m *mirror[K, V]) send(op mirrorOp[K, V]) {
+	select {
+	case m.queue <- op:
+	default:
+		m.dropped++
+	}
+}
+
+// stopMirror stops mirroring, returning a channel closed once the queued
+// ops have been applied, or nil if the cache isn't mirrored.  c.lock must be
+// held.
+func (c *Cache[K, V]) stopMirror() (done <-chan struct{}) {
+	if c.ext == nil || c.ext.mirror == nil {
+		return nil
+	}
+	m := c.ext.mirror
+	c.ext.mirror = nil
+	close(m.queue)
+	return m.done
+}
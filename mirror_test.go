@@ -0,0 +1,69 @@
+package lru
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestLRUMirror(t *testing.T) {
+	standby, err := New[int, int](8)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l, err := NewWithOptions[int, int](2, Options[int, int]{
+		Mirror:      standby,
+		MirrorQueue: 16,
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, 1)
+	l.Add(2, 2)
+	l.Add(3, 3) // evicts 1, which the standby keeps
+	l.Remove(2)
+	l.Close()
+
+	keys := standby.Keys()
+	sort.Ints(keys)
+	if !reflect.DeepEqual(keys, []int{1, 3}) {
+		t.Errorf("unexpected mirrored keys: %v", keys)
+	}
+	if r := l.Runtime(); r.Goroutines != 0 || r.MirrorDropped != 0 {
+		t.Errorf("unexpected runtime report: %+v", r)
+	}
+
+	// changes beyond the queue are dropped rather than blocking
+	blocked := &blockingCacher{Cache: standby, release: make(chan struct{})}
+	l, err = NewWithOptions[int, int](8, Options[int, int]{
+		Mirror:      blocked,
+		MirrorQueue: 1,
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		l.Add(i, i)
+	}
+	if dropped := l.Runtime().MirrorDropped; dropped < 2 {
+		t.Errorf("expected changes to be dropped: %d", dropped)
+	}
+	close(blocked.release)
+	l.Close()
+
+	if _, err := NewWithOptions[int, int](8, Options[int, int]{Mirror: standby}); err == nil {
+		t.Errorf("expected an error without a MirrorQueue")
+	}
+}
+
+// blockingCacher is a Cacher whose adds wait for release.
+type blockingCacher struct {
+	*Cache[int, int]
+	release chan struct{}
+}
+
+func (b *blockingCacher) Add(key, value int) bool {
+	<-b.release
+	return b.Cache.Add(key, value)
+}
@@ -0,0 +1,22 @@
+package lru
+
+// AppendTo appends v to the list of values stored for key in a cache of
+// slices, keeping at most the maxPerKey most recently appended values (or
+// all of them, if maxPerKey isn't positive).  LRU ordering applies to keys:
+// appending marks the key as recently used, and evicting it drops its whole
+// list.  Slices returned by lookups stay valid, but callers must not append
+// to them.  Returns true if an eviction occurred.
+func AppendTo[K comparable, V any](c *Cache[K, []V], key K, v V, maxPerKey int) (evicted bool) {
+	c.lock.Lock()
+	defer c.unlock()
+
+	list, _ := c.peek(key)
+	// appending only ever writes past the end of slices handed out
+	// earlier, and trimming reslices rather than shifting, so readers
+	// never observe the list changing under them.
+	list = append(list, v)
+	if maxPerKey > 0 && len(list) > maxPerKey {
+		list = list[len(list)-maxPerKey:]
+	}
+	return c.add(key, list)
+}
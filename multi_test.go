@@ -0,0 +1,46 @@
+package lru
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAppendTo(t *testing.T) {
+	l, err := New[string, []int](2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		AppendTo(l, "1", i, 3)
+	}
+	got, ok := l.Get("1")
+	if !ok || !reflect.DeepEqual(got, []int{2, 3, 4}) {
+		t.Fatalf("expected the 3 most recent values: %v, %v", got, ok)
+	}
+
+	AppendTo(l, "1", 5, 3)
+	if !reflect.DeepEqual(got, []int{2, 3, 4}) {
+		t.Errorf("appending shouldn't change previously returned lists: %v", got)
+	}
+	if got, _ := l.Peek("1"); !reflect.DeepEqual(got, []int{3, 4, 5}) {
+		t.Errorf("bad list: %v", got)
+	}
+
+	// LRU applies at the key level
+	AppendTo(l, "2", 1, 3)
+	AppendTo(l, "1", 6, 3)
+	if AppendTo(l, "3", 1, 3) != true {
+		t.Errorf("should have an eviction")
+	}
+	if l.Contains("2") || !l.Contains("1") {
+		t.Errorf("expected 2 to be evicted as least recently appended to")
+	}
+
+	for i := 0; i < 10; i++ {
+		AppendTo(l, "3", i, 0)
+	}
+	if got, _ := l.Peek("3"); len(got) != 11 {
+		t.Errorf("a non-positive maxPerKey shouldn't bound the list: %v", got)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !lrudebug
+
+package lru
+
+import (
+	"sync"
+)
+
+// mutex guards a cache's state.  Building with the lrudebug tag replaces it
+// with a mutex that detects reentrant locking -- see mutex_debug.go.
+type mutex = sync.Mutex
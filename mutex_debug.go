@@ -0,0 +1,63 @@
+//go:build lrudebug
+
+package lru
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"sync"
+)
+
+// lockOwners maps each held *mutex to the ID of the goroutine holding it.
+var lockOwners sync.Map
+
+// mutex is a sync.Mutex that panics, rather than deadlocking, when the
+// goroutine holding it tries to acquire it again -- for example by calling
+// back into a cache from inside its eviction callback.  Tracking the owning
+// goroutine is expensive, so it is only used when built with the lrudebug
+// tag.
+type mutex struct {
+	mu sync.Mutex
+}
+
+func (m *mutex) Lock() {
+	id := goroutineID()
+	if owner, ok := lockOwners.Load(m); ok && owner.(uint64) == id {
+		panic("lru: reentrant cache call would deadlock: a cache method was called " +
+			"from a goroutine already holding the cache's lock, such as from inside an eviction callback")
+	}
+	m.mu.Lock()
+	lockOwners.Store(m, id)
+}
+
+func (m *mutex) TryLock() bool {
+	// a reentrant TryLock fails rather than deadlocking, so it needn't
+	// panic.
+	if !m.mu.TryLock() {
+		return false
+	}
+	lockOwners.Store(m, goroutineID())
+	return true
+}
+
+func (m *mutex) Unlock() {
+	lockOwners.Delete(m)
+	m.mu.Unlock()
+}
+
+// goroutineID parses the current goroutine's ID out of the header of its
+// stack trace, which looks like "goroutine 18 [running]:".
+func goroutineID() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		b = b[:i]
+	}
+	id, err := strconv.ParseUint(string(b), 10, 64)
+	if err != nil {
+		panic("lru: couldn't parse goroutine ID: " + err.Error())
+	}
+	return id
+}
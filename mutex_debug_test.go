@@ -0,0 +1,29 @@
+//go:build lrudebug
+
+package lru
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReentrantCallPanics(t *testing.T) {
+	var l *Cache[string, int]
+	l, err := NewWithEvict[string, int](1, func(k string, v int) {
+		l.Len()
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	defer func() {
+		r := recover()
+		if msg, ok := r.(string); !ok || !strings.Contains(msg, "reentrant") {
+			t.Fatalf("expected a reentrancy panic, not %v", r)
+		}
+	}()
+
+	l.Add("1", 1)
+	l.Add("2", 2)
+	t.Fatalf("expected Add to panic")
+}
@@ -0,0 +1,94 @@
+package lru
+
+import (
+	"errors"
+	"time"
+
+	"github.com/bpowers/approx-lru/internal/approxlru"
+)
+
+// ErrNotFound marks a key known not to exist.  Lookup returns it for keys
+// added with AddNegative, and a GetOrLoad loader can return it (or an
+// error wrapping it) to have the miss cached in a cache with
+// Options.NegativeTTL.
+var ErrNotFound = errors.New("lru: not found")
+
+// negativeCache remembers keys known not to exist, separately from the
+// cache's values so they don't need a sentinel value.
+type negativeCache[K comparable] struct {
+	ttl time.Duration
+	now func() time.Time
+	// entries maps each key to the UnixNano time it expires at.
+	entries approxlru.LRU[K, int64]
+}
+
+func newNegativeCache[K comparable](size int, ttl time.Duration) (*negativeCache[K], error) {
+	entries, err := approxlru.NewLRU[K, int64](size, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &negativeCache[K]{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: *entries,
+	}, nil
+}
+
+func (n *negativeCache[K]) add(key K) {
+	n.entries.Add(key, n.now().Add(n.ttl).UnixNano())
+}
+
+// has reports whether key is known not to exist, expiring it if it has
+// outlived its time-to-live.
+func (n *negativeCache[K]) has(key K) bool {
+	expires, ok := n.entries.Get(key)
+	if !ok {
+		return false
+	}
+	if n.now().UnixNano() >= expires {
+		n.entries.Remove(key)
+		return false
+	}
+	return true
+}
+
+// negative reports whether key is cached as not existing.  c.lock must be
+// held.
+func (c *Cache[K, V]) negative(key K) bool {
+	if c.ext == nil || c.ext.negatives == nil || !c.ext.negatives.has(key) {
+		return false
+	}
+	c.stats.lookup(true)
+	return true
+}
+
+// AddNegative records that key doesn't exist, for Options.NegativeTTL,
+// replacing any value cached for it.  Until then, Lookup and GetOrLoad
+// return ErrNotFound for it, and Get reports it as missing.  Negative
+// entries are kept apart from values, in their own LRU of the same size
+// as the cache.  AddNegative does nothing if the cache wasn't created with
+// a NegativeTTL.
+func (c *Cache[K, V]) AddNegative(key K) {
+	c.lock.Lock()
+	defer c.unlock()
+
+	if c.ext == nil || c.ext.negatives == nil {
+		return
+	}
+	c.remove(key)
+	c.ext.negatives.add(key)
+}
+
+// Lookup is like Get, but distinguishes keys known not to exist, added
+// with AddNegative, by returning ErrNotFound for them.  Such lookups are
+// counted as hits.
+func (c *Cache[K, V]) Lookup(key K) (value V, ok bool, err error) {
+	c.lock.Lock()
+	defer c.unlock()
+
+	if c.negative(key) {
+		return value, false, ErrNotFound
+	}
+	value, ok = c.get(key)
+	return value, ok, nil
+}
@@ -0,0 +1,92 @@
+package lru
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestLRUNegative(t *testing.T) {
+	now := time.Unix(1000, 0)
+	l, err := NewWithOptions[string, int](8, Options[string, int]{
+		TTL:         time.Hour,
+		NegativeTTL: time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.ext.negatives.now = func() time.Time { return now }
+
+	l.Add("1", 1)
+	l.AddNegative("1")
+	if _, ok, err := l.Lookup("1"); ok || err != ErrNotFound {
+		t.Errorf("expected 1 to be known missing: %v, %v", ok, err)
+	}
+	if _, ok := l.Get("1"); ok {
+		t.Errorf("negative entries should be misses for Get")
+	}
+	if l.Len() != 0 {
+		t.Errorf("negative entries shouldn't take up values: %v", l.Len())
+	}
+
+	// negative entries expire on their own TTL
+	now = now.Add(2 * time.Minute)
+	if _, ok, err := l.Lookup("1"); ok || err != nil {
+		t.Errorf("the negative entry should have expired: %v, %v", ok, err)
+	}
+
+	// adding a value overrides a negative entry
+	l.AddNegative("2")
+	l.Add("2", 2)
+	if v, ok, err := l.Lookup("2"); !ok || v != 2 || err != nil {
+		t.Errorf("expected the added value: %v, %v, %v", v, ok, err)
+	}
+
+	// loaders can report missing keys, which are then remembered
+	calls := 0
+	loader := func(key string) (int, error) {
+		calls++
+		return 0, fmt.Errorf("no such row %s: %w", key, ErrNotFound)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := l.GetOrLoad("3", loader); err == nil {
+			t.Errorf("expected an error")
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected the miss to be cached, not %d loads", calls)
+	}
+
+	// without NegativeTTL, AddNegative does nothing
+	plain, err := New[string, int](8)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	plain.AddNegative("1")
+	if _, _, err := plain.Lookup("1"); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+}
+
+func TestLRUNegativeSize(t *testing.T) {
+	if _, err := NewWithOptions[int, int](0, Options[int, int]{NegativeTTL: time.Minute}); err == nil {
+		t.Errorf("expected unbounded caches to need a NegativeSize")
+	}
+	l, err := NewWithOptions[int, int](0, Options[int, int]{
+		NegativeTTL:  time.Minute,
+		NegativeSize: 16,
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 1000; i++ {
+		l.AddNegative(i)
+	}
+	if n := l.ext.negatives.entries.Len(); n != 16 {
+		t.Errorf("expected negatives to be bounded by NegativeSize: %d", n)
+	}
+	if _, _, err := l.Lookup(999); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected the most recent negative to be kept: %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+package lru
+
+import (
+	"errors"
+	"reflect"
+)
+
+// ErrNilValue is returned by AddChecked when a cache with
+// Options.RejectNilValues is asked to store a nil value.
+var ErrNilValue = errors.New("lru: nil value rejected")
+
+// nilable reports whether values of type V can be nil.
+func nilable[V any]() bool {
+	switch reflect.TypeOf((*V)(nil)).Elem().Kind() {
+	case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Pointer, reflect.Slice, reflect.UnsafePointer:
+		return true
+	}
+	return false
+}
+
+// isNil reports whether value is nil, including typed nils stored in an
+// interface.
+func isNil[V any](value V) bool {
+	v := reflect.ValueOf(any(value))
+	if !v.IsValid() {
+		return true
+	}
+	switch v.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Pointer, reflect.Slice, reflect.UnsafePointer:
+		return v.IsNil()
+	}
+	return false
+}
+
+// AddChecked is like Add, but returns ErrNilValue rather than silently
+// dropping a nil value in a cache created with Options.RejectNilValues.
+func (c *Cache[K, V]) AddChecked(key K, value V) (evicted bool, err error) {
+	c.lock.Lock()
+	defer c.unlock()
+
+	if c.ext != nil && c.ext.rejectNil && isNil(value) {
+		return false, ErrNilValue
+	}
+	return c.add(key, value), nil
+}
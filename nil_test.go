@@ -0,0 +1,46 @@
+package lru
+
+import "testing"
+
+func TestLRUNilValues(t *testing.T) {
+	l, err := New[string, *int](8)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add("nil", nil)
+	if v, ok := l.Get("nil"); !ok || v != nil {
+		t.Errorf("nil values should be stored by default: %v, %v", v, ok)
+	}
+
+	one := 1
+	l, err = NewWithOptions[string, *int](8, Options[string, *int]{RejectNilValues: true})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add("1", &one)
+	l.Add("1", nil)
+	if v, ok := l.Get("1"); !ok || v != &one {
+		t.Errorf("a nil add shouldn't replace the existing value: %v, %v", v, ok)
+	}
+	if _, err := l.AddChecked("2", nil); err != ErrNilValue {
+		t.Errorf("expected ErrNilValue, not %v", err)
+	}
+	if _, err := l.AddChecked("2", &one); err != nil {
+		t.Errorf("err: %v", err)
+	}
+	if l.Len() != 2 {
+		t.Errorf("bad len: %v", l.Len())
+	}
+
+	// typed nils stored in an interface are nil too
+	li, err := NewWithOptions[string, interface{}](8, Options[string, interface{}]{RejectNilValues: true})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	li.Add("untyped", nil)
+	li.Add("typed", (*int)(nil))
+	li.Add("zero", 0)
+	if li.Len() != 1 || !li.Contains("zero") {
+		t.Errorf("only the non-nil value should be stored: %v", li.Keys())
+	}
+}
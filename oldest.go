@@ -0,0 +1,128 @@
+package lru
+
+import (
+	"sort"
+	"time"
+)
+
+// accessTimes records when each entry was last added or looked up, for
+// caches with Options.TrackAccessTimes.
+type accessTimes[K comparable] struct {
+	now func() time.Time
+	at  map[K]int64 // UnixNano
+}
+
+func (a *accessTimes[K]) touch(key K) {
+	a.at[key] = a.now().UnixNano()
+}
+
+// GetOldest returns the least recently used entry in the cache, without
+// updating its recent-ness.  Unlike eviction it is exact, and so O(n)
+// expensive.
+func (c *Cache[K, V]) GetOldest() (key K, value V, ok bool) {
+	c.lock.Lock()
+	defer c.unlock()
+
+	c.removeExpired()
+	return c.lru.Oldest()
+}
+
+// RemoveOldest removes the least recently used entry from the cache and
+// returns it, so that entries can be drained in LRU order.  Like
+// GetOldest it is exact, and O(n) expensive: draining a cache by calling
+// it repeatedly is O(n^2), so use RemoveOldestN or RemoveAccessedBefore
+// to drain many entries.  Pinned entries are in use, so they are skipped:
+// once only pinned entries remain, ok is false.  Unlike Remove, it doesn't
+// leave a tombstone or remove derived entries.
+func (c *Cache[K, V]) RemoveOldest() (key K, value V, ok bool) {
+	c.lock.Lock()
+	defer c.unlock()
+
+	c.removeExpired()
+	if key, value, ok = c.lru.OldestUnpinned(); ok {
+		c.lru.Remove(key)
+	}
+	return key, value, ok
+}
+
+// pinned reports whether key is pinned.  c.lock must be held.
+func (c *Cache[K, V]) pinned(key K) bool {
+	return c.ext != nil && c.ext.pins[key] > 0
+}
+
+// RemoveOldestN removes up to n of the least recently used entries from the
+// cache and returns them, in LRU order; a non-positive n removes every
+// entry.  Like RemoveOldest it skips pinned entries, but it sorts the
+// entries once, so it is O(n * log(n)) expensive however many it removes.
+func (c *Cache[K, V]) RemoveOldestN(n int) (removed []Entry[K, V]) {
+	c.lock.Lock()
+	defer c.unlock()
+
+	c.removeExpired()
+	c.lru.Range(func(key K, value V) bool {
+		if n > 0 && len(removed) >= n {
+			return false
+		}
+		if !c.pinned(key) {
+			c.lru.Remove(key)
+			removed = append(removed, Entry[K, V]{Key: key, Value: value})
+		}
+		return true
+	})
+	return removed
+}
+
+// OldestAccess returns the least recently used unpinned entry's key and when
+// it was last added or looked up, in a cache created with
+// Options.TrackAccessTimes.  It is O(n) expensive.  ok is false if there
+// are no unpinned entries, or access times aren't tracked.
+func (c *Cache[K, V]) OldestAccess() (key K, at time.Time, ok bool) {
+	c.lock.Lock()
+	defer c.unlock()
+
+	c.removeExpired()
+	if c.ext == nil || c.ext.accessed == nil {
+		return key, at, false
+	}
+	var oldest int64
+	for k, nanos := range c.ext.accessed.at {
+		if (!ok || nanos < oldest) && !c.pinned(k) {
+			key, oldest, ok = k, nanos, true
+		}
+	}
+	if ok {
+		at = time.Unix(0, oldest)
+	}
+	return key, at, ok
+}
+
+// RemoveAccessedBefore removes the unpinned entries last added or looked up
+// before t and returns them, oldest first, in a cache created with
+// Options.TrackAccessTimes; otherwise it removes nothing.  It is O(n)
+// expensive, plus sorting the removed entries.
+func (c *Cache[K, V]) RemoveAccessedBefore(t time.Time) (removed []Entry[K, V]) {
+	c.lock.Lock()
+	defer c.unlock()
+
+	c.removeExpired()
+	if c.ext == nil || c.ext.accessed == nil {
+		return nil
+	}
+	at := c.ext.accessed.at
+	cutoff := t.UnixNano()
+	var keys []K
+	for key, nanos := range at {
+		if nanos < cutoff && !c.pinned(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return at[keys[i]] < at[keys[j]]
+	})
+	for _, key := range keys {
+		if value, ok := c.lru.RemoveAndGet(key); ok {
+			removed = append(removed, Entry[K, V]{Key: key, Value: value})
+		}
+	}
+	return removed
+}
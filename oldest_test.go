@@ -0,0 +1,140 @@
+package lru
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLRUOldest(t *testing.T) {
+	l, err := New[int, int](128)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, _, ok := l.GetOldest(); ok {
+		t.Fatalf("an empty cache has no oldest entry")
+	}
+	if _, _, ok := l.RemoveOldest(); ok {
+		t.Fatalf("an empty cache has no oldest entry")
+	}
+
+	for i := 0; i < 128; i++ {
+		l.Add(i, i)
+	}
+	l.Get(0)
+	if k, v, ok := l.GetOldest(); !ok || k != 1 || v != 1 {
+		t.Errorf("expected 1 to be the oldest: %v, %v, %v", k, v, ok)
+	}
+
+	// draining pops entries in LRU order
+	for i := 1; i < 128; i++ {
+		if k, _, ok := l.RemoveOldest(); !ok || k != i {
+			t.Fatalf("expected %d to be the oldest: %v, %v", i, k, ok)
+		}
+	}
+	if k, _, ok := l.RemoveOldest(); !ok || k != 0 {
+		t.Fatalf("expected 0 to be the oldest: %v, %v", k, ok)
+	}
+	if l.Len() != 0 {
+		t.Errorf("bad len: %v", l.Len())
+	}
+}
+
+func TestLRURemoveOldestPinned(t *testing.T) {
+	l, err := New[int, int](4)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		l.Add(i, i)
+	}
+	l.Pin(0)
+
+	// the pinned 0 is the oldest, but is in use, so it is skipped
+	for i := 1; i < 3; i++ {
+		if k, _, ok := l.RemoveOldest(); !ok || k != i {
+			t.Fatalf("expected %d to be removed: %v, %v", i, k, ok)
+		}
+	}
+	if k, _, ok := l.RemoveOldest(); ok {
+		t.Errorf("only pinned entries remain, so nothing should be removed: %v", k)
+	}
+	if !l.Contains(0) {
+		t.Errorf("the pinned entry should remain")
+	}
+
+	l.Unpin(0)
+	if k, _, ok := l.RemoveOldest(); !ok || k != 0 {
+		t.Errorf("expected the unpinned 0 to be removed: %v, %v", k, ok)
+	}
+}
+
+func TestLRURemoveOldestN(t *testing.T) {
+	l, err := New[int, int](8)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 8; i++ {
+		l.Add(i, i)
+	}
+	l.Get(0)
+	l.Pin(1)
+
+	keys := func(entries []Entry[int, int]) (keys []int) {
+		for _, e := range entries {
+			keys = append(keys, e.Key)
+		}
+		return keys
+	}
+	if removed := keys(l.RemoveOldestN(3)); !reflect.DeepEqual(removed, []int{2, 3, 4}) {
+		t.Errorf("bad removals: %v", removed)
+	}
+	if removed := keys(l.RemoveOldestN(0)); !reflect.DeepEqual(removed, []int{5, 6, 7, 0}) {
+		t.Errorf("bad removals: %v", removed)
+	}
+	if l.Len() != 1 || !l.Contains(1) {
+		t.Errorf("only the pinned entry should remain: %v", l.Keys())
+	}
+}
+
+func TestLRURemoveAccessedBefore(t *testing.T) {
+	l, err := NewWithOptions[int, int](8, Options[int, int]{TrackAccessTimes: true})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	now := time.Unix(1000, 0)
+	l.ext.accessed.now = func() time.Time { return now }
+	for i := 0; i < 4; i++ {
+		l.Add(i, i)
+		now = now.Add(time.Second)
+	}
+	l.Get(0) // at 1004
+	l.Pin(1)
+
+	if k, at, ok := l.OldestAccess(); !ok || k != 2 || !at.Equal(time.Unix(1002, 0)) {
+		t.Errorf("bad oldest access: %v, %v, %v", k, at, ok)
+	}
+	removed := l.RemoveAccessedBefore(time.Unix(1004, 0))
+	if !reflect.DeepEqual(removed, []Entry[int, int]{{2, 2}, {3, 3}}) {
+		t.Errorf("bad removals: %v", removed)
+	}
+	if k, _, ok := l.OldestAccess(); !ok || k != 0 {
+		t.Errorf("bad oldest access: %v, %v", k, ok)
+	}
+	l.Remove(0)
+	if _, _, ok := l.OldestAccess(); ok {
+		t.Errorf("only pinned entries remain")
+	}
+	if len(l.ext.accessed.at) != 1 {
+		t.Errorf("removed entries' access times should be forgotten: %v", l.ext.accessed.at)
+	}
+
+	untracked, err := New[int, int](8)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	untracked.Add(1, 1)
+	if removed := untracked.RemoveAccessedBefore(time.Now().Add(time.Hour)); removed != nil {
+		t.Errorf("untracked caches shouldn't remove anything: %v", removed)
+	}
+}
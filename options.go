@@ -0,0 +1,482 @@
+package lru
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bpowers/approx-lru/internal/approxlru"
+)
+
+// Options configures optional behavior of a Cache created with
+// NewWithOptions.  The zero value configures a plain LRU cache.
+type Options[K comparable, V any] struct {
+	// OnEvict, if non-nil, is called with each entry that leaves the cache.
+	OnEvict func(key K, value V)
+	// OnEvictReason, if non-nil, is called with each entry that leaves the
+	// cache and the reason it left.
+	OnEvictReason func(key K, value V, reason EvictReason)
+
+	// OnAdd, OnHit and OnMiss, if non-nil, are called with the cache lock
+	// held when an entry is added (or its value replaced), and when a
+	// lookup such as Get finds or doesn't find its key.  Peek and Contains
+	// don't count as lookups.
+	OnAdd  func(key K, value V)
+	OnHit  func(key K, value V)
+	OnMiss func(key K)
+
+	// OnReplace, if non-nil, is called when Add (or another adding
+	// operation) overwrites the value of a key that is already in the
+	// cache, with the value being replaced and its replacement.  Replaced
+	// values don't leave the cache through eviction, so this is the only
+	// chance to clean them up.
+	OnReplace func(key K, oldValue, newValue V)
+
+	// ThrashWindow, if positive, enables thrash detection: a key added
+	// back to the cache within ThrashWindow of being evicted to make room
+	// for another entry is reported to OnThrash.  Frequent thrashing
+	// usually means the cache is too small for its working set.  Up to
+	// size recently evicted keys are remembered.
+	ThrashWindow time.Duration
+	// OnThrash is called with the cache lock held with each thrashing key
+	// and how long ago it was evicted.
+	OnThrash func(key K, evictedAgo time.Duration)
+
+	// StampedeThreshold, if positive, counts the loads GetOrLoad makes of
+	// each key per minute, as returned by LoadRate.  OnStampede is called
+	// with the cache lock held the first time in a minute that a key's
+	// loads exceed StampedeThreshold, with the count so far.  A key loaded
+	// that often is being invalidated or expired faster than it is worth
+	// caching, or its load errors aren't being cached.
+	StampedeThreshold int
+	OnStampede        func(key K, loads int)
+	// HedgeDelay, if positive, hedges the loads GetOrLoad makes: if a
+	// loader hasn't returned after HedgeDelay, a second attempt is started
+	// alongside it, and the first to succeed is used.  The ctx passed to
+	// both attempts is canceled once one succeeds.  It suits loaders
+	// backed by replicated services, whose slowest responses dominate the
+	// cost of a miss; loaders should be safe to call twice.
+	HedgeDelay time.Duration
+
+	// TombstoneTTL, if positive, makes removing a key leave a tombstone
+	// behind for TombstoneTTL.  While the tombstone lives, adds of the key
+	// are dropped, so that a slow loader finishing after an invalidation
+	// can't resurrect stale data.  Use ClearTombstone to allow a key to be
+	// added again early.
+	TombstoneTTL time.Duration
+
+	// TTL, if positive, is the default time-to-live of entries: they
+	// expire TTL after they were last added.  AddWithTTL overrides it
+	// per entry.
+	TTL time.Duration
+	// ReapInterval, if positive, starts a background goroutine removing
+	// expired entries every ReapInterval; otherwise they are only removed
+	// when next looked up.  Call Close to stop the goroutine.
+	ReapInterval time.Duration
+	// ExpirationQueue, if positive, makes the reaper send the entries
+	// expiring between each of its ticks to the channel returned by
+	// Expirations, which buffers up to ExpirationQueue batches.  It
+	// requires ReapInterval.
+	ExpirationQueue int
+	// StaleWhileRevalidate, if positive, keeps entries for up to
+	// StaleWhileRevalidate after they expire.  Looking up such an entry
+	// returns its stale value immediately and starts a background call to
+	// Refresh to reload it, unless one is already running for the key.
+	// Successfully refreshed values are added to the cache; failed
+	// refreshes leave the entry to expire at the end of the window.
+	// RefreshCtx may be given instead of Refresh: refreshes started by
+	// GetCtx or GetOrLoadCtx are passed a context carrying the values and
+	// deadline of the lookup's ctx, but not canceled when the lookup
+	// returns; other refreshes are passed context.Background().
+	StaleWhileRevalidate time.Duration
+	Refresh              func(key K) (V, error)
+	RefreshCtx           func(ctx context.Context, key K) (V, error)
+
+	// MaxCost, if positive, bounds the total cost of the cache's entries,
+	// as computed by Cost: adding an entry evicts old entries until the
+	// total cost is at most MaxCost again.  Entries costing more than
+	// MaxCost on their own aren't cached at all.
+	MaxCost int64
+	Cost    func(key K, value V) int64
+
+	// Indexes registers named secondary indexes, for looking entries up
+	// with GetBySecondary by something other than their key.  Each
+	// function extracts an entry's secondary key, or "" to leave it out of
+	// the index.  Several entries may share a secondary key.
+	Indexes map[string]func(key K, value V) string
+
+	// AccuracySampleRate, if positive, tracks one in every
+	// AccuracySampleRate keys in exact LRU order alongside the cache, to
+	// measure how closely its approximate eviction follows an exact LRU.
+	// See EvictionAccuracy.  Tracking costs memory and time proportional
+	// to the number of sampled keys, so it is best used with a rate of
+	// 100 or more on large caches.
+	AccuracySampleRate int
+
+	// HitRatioCurve, if set, estimates the hit ratio the cache would have
+	// at each of the given sizes, reported by HitRatioCurve, to
+	// help decide whether resizing it would pay off.  Each size is
+	// simulated by a ghost cache of keys alone, fed with one in every
+	// HitRatioCurveSampleRate keys (all of them if zero), as chosen by
+	// KeyHash.  Simulation costs memory and time proportional to the
+	// number of sampled keys the ghost caches hold, so large caches are
+	// best estimated with a sample rate of 100 or more.
+	HitRatioCurve           []int
+	HitRatioCurveSampleRate int
+
+	// TrackAccessTimes, if set, records when each entry was last added or
+	// looked up, so that OldestAccess and RemoveAccessedBefore can drain
+	// entries by age, for example in a write-behind flusher.  It costs a
+	// map update on every hit.
+	TrackAccessTimes bool
+
+	// RejectNilValues, if set, makes the cache refuse to store nil
+	// values (nil pointers, slices, maps, interfaces and so on): adds of
+	// them are dropped, leaving any existing value for the key in place,
+	// and AddChecked reports them with ErrNilValue.  Otherwise nil values
+	// are stored like any other, and the ok result of Get and friends is
+	// the only way to tell them apart from a miss.
+	RejectNilValues bool
+
+	// NegativeTTL, if positive, enables negative caching: AddNegative, or
+	// a GetOrLoad loader returning ErrNotFound, records that a key
+	// doesn't exist for NegativeTTL, independently of the TTL of values.
+	// Up to NegativeSize keys are remembered, or as many as the cache's
+	// size if zero; unbounded caches, including those bounded by cost or
+	// memory instead, must set NegativeSize.
+	NegativeTTL  time.Duration
+	NegativeSize int
+
+	// Validate, if non-nil, is called with the cache lock held on every
+	// entry found by a lookup such as Get.  Entries it returns an error
+	// for are treated as misses and removed, with ReasonInvalid, so that
+	// corrupt values are dropped rather than served forever.  See
+	// Stats.Invalid.
+	Validate func(key K, value V) error
+
+	// AsyncEvictQueue, if positive, runs OnEvict and OnEvictReason on a
+	// background goroutine rather than with the cache lock held, so slow
+	// callbacks doing I/O don't stall other users of the cache.  Up to
+	// AsyncEvictQueue evictions are queued for the goroutine; once the
+	// queue is full, operations that evicted wait for room after
+	// releasing the cache lock, so callbacks may call back into the
+	// cache, though adding from a callback can then wait on itself.
+	// Callbacks run one at a time, in eviction order.  Use Flush to wait
+	// for queued evictions, and Close to drain them and stop the
+	// goroutine, after which callbacks run with the lock held again.
+	// AsyncEvictPolicy can drop or spill evictions that don't fit in the
+	// queue instead of waiting for room; the AsyncEvictSpill policy
+	// requires an AsyncEvictSpill function to spill them to.
+	AsyncEvictQueue  int
+	AsyncEvictPolicy AsyncEvictPolicy
+	AsyncEvictSpill  func(key K, value V, reason EvictReason)
+
+	// Mirror, if non-nil, receives a copy of every add, remove and purge
+	// of the cache, for example to keep a standby cache warm.  Entries
+	// the cache evicts are left for Mirror's own eviction policy.
+	// Mirroring happens in the background and is best effort: up to
+	// MirrorQueue changes are queued, and changes beyond that are dropped
+	// (see RuntimeReport.MirrorDropped).  Close stops mirroring, after
+	// applying the queued changes.
+	Mirror      Cacher[K, V]
+	MirrorQueue int
+
+	// TinyLFU, if set, adds a TinyLFU admission filter to a bounded
+	// cache: the cache estimates how often keys are looked up, and a new
+	// key is only added to a full cache if it has been looked up more
+	// often recently than the entry it would evict.  This keeps scans of
+	// one-off keys from flushing out hot entries.  Rejected adds are
+	// counted in Stats.Rejected.  KeyHash hashes keys for the filter; it
+	// may be nil for string and integer keys.
+	TinyLFU bool
+	KeyHash func(key K) uint64
+
+	// CardinalityWindow, if positive, enables a guard against keyspaces
+	// far larger than the cache: after every CardinalityWindow lookups,
+	// a full cache whose hit ratio over them is below
+	// CardinalityMinHitRatio (1% if zero) is considered collapsed.  Each
+	// collapsed window is counted in Stats.Collapses and reported to
+	// OnCardinalityCollapse, called with the cache lock held.  The cache
+	// is no longer considered collapsed once its hit ratio over a window
+	// recovers to twice CardinalityMinHitRatio; see CardinalityCollapsed.
+	CardinalityWindow      int
+	CardinalityMinHitRatio float64
+	OnCardinalityCollapse  func(hitRatio float64)
+	// CardinalityThrottle, if set with CardinalityWindow, switches on a
+	// TinyLFU admission filter (see TinyLFU) while the cache is
+	// collapsed, so only keys looked up repeatedly displace entries.  It
+	// has no effect on caches with TinyLFU set, which always filter.
+	CardinalityThrottle bool
+}
+
+// cacheExt holds the state of optional features, and is only allocated by
+// caches that use them.
+type cacheExt[K comparable, V any] struct {
+	// onEvict is the LRU's eviction callback from before the cacheExt
+	// installed its own.
+	onEvict    approxlru.EvictReasonCallback[K, V]
+	onReplace  func(key K, oldValue, newValue V)
+	onAdd      func(key K, value V)
+	onHit      func(key K, value V)
+	onMiss     func(key K)
+	thrash     *thrashDetector[K]
+	tags       *tagIndex[K]
+	deps       *depGraph[K]
+	tombstones *tombstones[K]
+	ttl        *ttlState[K]
+	loads      map[K]*loadCall[V]
+	loadRates  *loadRates[K]
+	hedgeDelay time.Duration
+	versions   map[K]int64
+	cost       *costState[K, V]
+	indexes    map[string]*secondaryIndex[K, V]
+	accuracy   *accuracyShadow[K]
+	pins       map[K]int
+	negatives  *negativeCache[K]
+	validate   func(key K, value V) error
+	admission  *tinyLFU[K]
+	// curve is set for caches with Options.HitRatioCurve.
+	curve *hitRatioCurve[K]
+	// accessed is set for caches with Options.TrackAccessTimes.
+	accessed *accessTimes[K]
+	// cardinality is set when guarding against hit ratio collapse.
+	cardinality *cardinalityGuard[K]
+	// async is set while eviction callbacks run in the background.
+	async *asyncEvicter[K, V]
+	// mirror is set while changes are mirrored to a secondary cache.
+	mirror *mirror[K, V]
+	// autoSize is set while SetTargetSizeFunc resizes the cache.
+	autoSize *autoSizer
+	// expirations is set for caches with Options.ExpirationQueue.
+	expirations *expirationQueue[K, V]
+	// revalidator is set for stale-while-revalidate caches.
+	revalidator *revalidator[K, V]
+	// rejectNil is set when nil values are dropped rather than stored.
+	rejectNil bool
+	// addHook and evictHook are set when values may implement AddHook
+	// and EvictHook.
+	addHook   bool
+	evictHook bool
+}
+
+// NewWithOptions constructs a fixed size cache with the given options.
+func NewWithOptions[K comparable, V any](size int, opts Options[K, V]) (*Cache[K, V], error) {
+	c, err := NewWithEvict[K, V](size, opts.OnEvict)
+	if err != nil {
+		return nil, err
+	}
+	if onEvictReason := opts.OnEvictReason; onEvictReason != nil {
+		e := c.extension()
+		onEvict := e.onEvict
+		e.onEvict = func(key K, value V, reason approxlru.EvictReason) {
+			if onEvict != nil {
+				onEvict(key, value, reason)
+			}
+			onEvictReason(key, value, EvictReason(reason))
+		}
+	}
+	if opts.OnAdd != nil || opts.OnHit != nil || opts.OnMiss != nil {
+		e := c.extension()
+		e.onAdd, e.onHit, e.onMiss = opts.OnAdd, opts.OnHit, opts.OnMiss
+	}
+	if opts.OnReplace != nil {
+		c.extension().onReplace = opts.OnReplace
+	}
+	if opts.StampedeThreshold > 0 {
+		c.extension().loadRates = newLoadRates[K](opts.StampedeThreshold, opts.OnStampede)
+	}
+	if opts.HedgeDelay > 0 {
+		c.extension().hedgeDelay = opts.HedgeDelay
+	}
+	if opts.ThrashWindow > 0 && opts.OnThrash != nil {
+		c.extension().thrash = newThrashDetector[K](size, opts.ThrashWindow, opts.OnThrash)
+	}
+	if opts.TombstoneTTL > 0 {
+		c.extension().tombstones = newTombstones[K](opts.TombstoneTTL)
+	}
+	if opts.TTL > 0 {
+		c.extension().ttl = newTTLState[K](opts.TTL)
+	}
+	if opts.MaxCost > 0 || opts.Cost != nil {
+		cost, err := newCostState(opts.MaxCost, opts.Cost)
+		if err != nil {
+			return nil, err
+		}
+		c.extension().cost = cost
+		c.stats.costs = cost.sketch
+	}
+	if len(opts.Indexes) > 0 {
+		e := c.extension()
+		e.indexes = make(map[string]*secondaryIndex[K, V], len(opts.Indexes))
+		for name, extract := range opts.Indexes {
+			e.indexes[name] = &secondaryIndex[K, V]{
+				extract: extract,
+				keys:    newTagIndex[K](),
+			}
+		}
+	}
+	if opts.TrackAccessTimes {
+		c.extension().accessed = &accessTimes[K]{now: time.Now, at: make(map[K]int64)}
+	}
+	if opts.AccuracySampleRate > 0 {
+		c.extension().accuracy = newAccuracyShadow[K](opts.AccuracySampleRate)
+	}
+	if len(opts.HitRatioCurve) > 0 {
+		curve, err := newHitRatioCurve(opts.HitRatioCurve, opts.HitRatioCurveSampleRate, opts.KeyHash)
+		if err != nil {
+			return nil, err
+		}
+		c.extension().curve = curve
+	}
+	if opts.NegativeTTL > 0 {
+		negativeSize := opts.NegativeSize
+		if negativeSize <= 0 {
+			negativeSize = size
+		}
+		if negativeSize <= 0 {
+			return nil, errors.New("must provide a positive NegativeSize with NegativeTTL for unbounded caches")
+		}
+		negatives, err := newNegativeCache[K](negativeSize, opts.NegativeTTL)
+		if err != nil {
+			return nil, err
+		}
+		c.extension().negatives = negatives
+	}
+	if opts.TinyLFU {
+		admission, err := newTinyLFU[K](size, opts.KeyHash)
+		if err != nil {
+			return nil, err
+		}
+		c.extension().admission = admission
+	}
+	if opts.CardinalityWindow > 0 {
+		g := &cardinalityGuard[K]{
+			window:     opts.CardinalityWindow,
+			minRatio:   opts.CardinalityMinHitRatio,
+			onCollapse: opts.OnCardinalityCollapse,
+		}
+		if g.minRatio == 0 {
+			g.minRatio = defaultCardinalityMinHitRatio
+		}
+		if opts.CardinalityThrottle && !opts.TinyLFU {
+			filter, err := newTinyLFU[K](size, opts.KeyHash)
+			if err != nil {
+				return nil, err
+			}
+			g.filter = filter
+		}
+		c.extension().cardinality = g
+	}
+	if opts.Validate != nil {
+		c.extension().validate = opts.Validate
+	}
+	if opts.RejectNilValues && nilable[V]() {
+		c.extension().rejectNil = true
+	}
+	if opts.StaleWhileRevalidate > 0 {
+		refresh := opts.RefreshCtx
+		if refresh == nil && opts.Refresh != nil {
+			refresh = func(_ context.Context, key K) (V, error) {
+				return opts.Refresh(key)
+			}
+		}
+		if refresh == nil {
+			return nil, errors.New("must provide a Refresh function with StaleWhileRevalidate")
+		}
+		e := c.extension()
+		e.ttlState().revalidateWindow = opts.StaleWhileRevalidate
+		e.revalidator = &revalidator[K, V]{
+			refresh:  refresh,
+			inflight: make(map[K]struct{}),
+		}
+	}
+	if opts.AsyncEvictPolicy == AsyncEvictSpill && opts.AsyncEvictSpill == nil {
+		return nil, errors.New("must provide an AsyncEvictSpill function with the AsyncEvictSpill policy")
+	}
+	if opts.Mirror != nil && opts.MirrorQueue <= 0 {
+		return nil, errors.New("must provide a positive MirrorQueue with Mirror")
+	}
+	if opts.ExpirationQueue > 0 {
+		if opts.ReapInterval <= 0 {
+			return nil, errors.New("must provide a positive ReapInterval with ExpirationQueue")
+		}
+		c.extension().expirations = newExpirationQueue[K, V](opts.ExpirationQueue)
+	}
+
+	// background goroutines are only started once every option has been
+	// validated, so that returning an error can't leak them
+	if opts.Mirror != nil {
+		c.extension().mirror = newMirror(opts.Mirror, opts.MirrorQueue)
+	}
+	if opts.AsyncEvictQueue > 0 {
+		c.startAsyncEvict(opts.AsyncEvictQueue, opts.AsyncEvictPolicy, opts.AsyncEvictSpill)
+	}
+	if opts.ReapInterval > 0 {
+		c.extension().ttlState()
+		c.startReaper(opts.ReapInterval)
+	}
+	return c, nil
+}
+
+// extension returns the cache's optional feature state, allocating it and
+// hooking it into the LRU's eviction callback on first use.  c.lock must be
+// held, unless the cache hasn't been shared yet.
+func (c *Cache[K, V]) extension() *cacheExt[K, V] {
+	if c.ext == nil {
+		c.ext = &cacheExt[K, V]{
+			onEvict: c.lru.EvictCallback(),
+		}
+		c.lru.SetEvictCallback(c.ext.evicted)
+	}
+	return c.ext
+}
+
+// evicted is the LRU's eviction callback once a cacheExt is allocated.
+func (e *cacheExt[K, V]) evicted(key K, value V, reason approxlru.EvictReason) {
+	if e.thrash != nil && reason == approxlru.ReasonEvicted {
+		e.thrash.evicted(key)
+	}
+	if e.tags != nil {
+		e.tags.remove(key)
+	}
+	if e.deps != nil {
+		e.deps.remove(key)
+	}
+	if e.ttl != nil {
+		if e.expirations != nil && reason == approxlru.ReasonExpired {
+			e.expirations.expired(key, value, e.ttl.expires[key])
+		}
+		e.ttl.forget(key)
+	}
+	if e.versions != nil {
+		delete(e.versions, key)
+	}
+	if e.cost != nil {
+		if cost, ok := e.cost.costs[key]; ok {
+			e.cost.sketch.remove(cost)
+			e.cost.total -= cost
+			delete(e.cost.costs, key)
+		}
+	}
+	for _, idx := range e.indexes {
+		idx.keys.remove(key)
+	}
+	if e.accuracy != nil {
+		e.accuracy.removed(key, reason == approxlru.ReasonEvicted)
+	}
+	if e.pins != nil {
+		delete(e.pins, key)
+	}
+	if e.accessed != nil {
+		delete(e.accessed.at, key)
+	}
+	if e.curve != nil && reason != approxlru.ReasonEvicted && reason != approxlru.ReasonResized {
+		e.curve.removed(key)
+	}
+	if e.evictHook {
+		callEvictHook(value)
+	}
+	if e.onEvict != nil {
+		e.onEvict(key, value, reason)
+	}
+}
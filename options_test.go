@@ -0,0 +1,210 @@
+package lru
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLRUThrashDetection(t *testing.T) {
+	var thrashed []string
+	evictCounter := 0
+	l, err := NewWithOptions[string, int](2, Options[string, int]{
+		OnEvict: func(k string, v int) {
+			evictCounter++
+		},
+		ThrashWindow: time.Hour,
+		OnThrash: func(k string, evictedAgo time.Duration) {
+			thrashed = append(thrashed, k)
+		},
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add("1", 1)
+	l.Add("2", 2)
+	l.Add("3", 3) // evicts 1
+	if evictCounter != 1 {
+		t.Errorf("onEvicted should have been called 1 time: %v", evictCounter)
+	}
+	if len(thrashed) != 0 {
+		t.Errorf("nothing should have thrashed yet: %v", thrashed)
+	}
+
+	l.Add("1", 1) // evicts 2, and 1 was just evicted
+	if len(thrashed) != 1 || thrashed[0] != "1" {
+		t.Errorf("expected 1 to have thrashed: %v", thrashed)
+	}
+
+	// explicit removal isn't thrashing
+	l.Remove("3")
+	l.Add("3", 3)
+	if len(thrashed) != 1 {
+		t.Errorf("re-adding a removed key shouldn't be thrashing: %v", thrashed)
+	}
+}
+
+func TestLRUOnReplace(t *testing.T) {
+	type replacement struct {
+		k        string
+		old, new int
+	}
+	var replaced []replacement
+	evictCounter := 0
+	l, err := NewWithOptions[string, int](2, Options[string, int]{
+		OnEvict: func(k string, v int) {
+			evictCounter++
+		},
+		OnReplace: func(k string, old, new int) {
+			replaced = append(replaced, replacement{k, old, new})
+		},
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add("1", 1)
+	if len(replaced) != 0 {
+		t.Errorf("adding a new key isn't a replacement: %v", replaced)
+	}
+	l.Add("1", 10)
+	if len(replaced) != 1 || replaced[0] != (replacement{"1", 1, 10}) {
+		t.Errorf("expected 1 to have been replaced: %v", replaced)
+	}
+	// GetOrAdd doesn't overwrite existing values
+	l.GetOrAdd("1", 100)
+	if len(replaced) != 1 {
+		t.Errorf("GetOrAdd shouldn't replace: %v", replaced)
+	}
+	if evictCounter != 0 {
+		t.Errorf("replacing shouldn't call onEvicted: %v", evictCounter)
+	}
+}
+
+func TestLRUTombstones(t *testing.T) {
+	l, err := NewWithOptions[string, int](2, Options[string, int]{
+		TombstoneTTL: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add("1", 1)
+	l.Remove("1")
+	// a racing add of stale data is dropped
+	l.Add("1", 1)
+	if l.Contains("1") {
+		t.Errorf("1 should not have been resurrected")
+	}
+	if _, ok, _ := l.GetOrAdd("1", 1); ok || l.Contains("1") {
+		t.Errorf("1 should not have been resurrected by GetOrAdd")
+	}
+
+	if !l.ClearTombstone("1") {
+		t.Errorf("1 should have had a tombstone")
+	}
+	l.Add("1", 2)
+	if v, ok := l.Get("1"); !ok || v != 2 {
+		t.Errorf("1 should be addable after clearing its tombstone: %v, %v", v, ok)
+	}
+	if l.ClearTombstone("2") {
+		t.Errorf("2 was never removed")
+	}
+
+	// tombstones expire
+	short, err := NewWithOptions[string, int](2, Options[string, int]{
+		TombstoneTTL: time.Nanosecond,
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	short.Add("1", 1)
+	short.Remove("1")
+	time.Sleep(time.Millisecond)
+	short.Add("1", 1)
+	if !short.Contains("1") {
+		t.Errorf("expired tombstones shouldn't block adds")
+	}
+}
+
+func TestLRUHooks(t *testing.T) {
+	var added, hits, misses []int
+	reasons := make(map[int]EvictReason)
+	l, err := NewWithOptions[int, int](2, Options[int, int]{
+		OnEvictReason: func(k, v int, reason EvictReason) {
+			reasons[k] = reason
+		},
+		OnAdd:  func(k, v int) { added = append(added, k) },
+		OnHit:  func(k, v int) { hits = append(hits, k) },
+		OnMiss: func(k int) { misses = append(misses, k) },
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, 1)
+	l.Add(2, 2)
+	l.Get(1)
+	l.Get(3)
+	l.Peek(2)
+	l.Add(3, 3) // evicts 2
+	l.Remove(1)
+	l.Add(4, 4)
+	l.Resize(1) // resizes 3 away
+	l.Purge()   // purges 4
+
+	if len(added) != 4 || len(hits) != 1 || hits[0] != 1 || len(misses) != 1 || misses[0] != 3 {
+		t.Errorf("unexpected hooks: added %v, hits %v, misses %v", added, hits, misses)
+	}
+	expected := map[int]EvictReason{
+		1: ReasonRemoved,
+		2: ReasonEvicted,
+		3: ReasonResized,
+		4: ReasonPurged,
+	}
+	for k, reason := range expected {
+		if reasons[k] != reason {
+			t.Errorf("expected %d to be %v, not %v", k, reason, reasons[k])
+		}
+	}
+	if s := ReasonExpired.String(); s != "expired" {
+		t.Errorf("bad reason string: %q", s)
+	}
+}
+
+func TestLRUValidate(t *testing.T) {
+	var reasons []EvictReason
+	l, err := NewWithOptions[string, string](8, Options[string, string]{
+		OnEvictReason: func(k string, v string, reason EvictReason) {
+			reasons = append(reasons, reason)
+		},
+		Validate: func(k string, v string) error {
+			if v == "" {
+				return errors.New("empty value")
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add("good", "value")
+	l.Add("bad", "")
+	if v, ok := l.Get("good"); !ok || v != "value" {
+		t.Errorf("valid entries should be served: %v, %v", v, ok)
+	}
+	if _, ok := l.Get("bad"); ok {
+		t.Errorf("invalid entries should be misses")
+	}
+	if l.Contains("bad") {
+		t.Errorf("invalid entries should be removed")
+	}
+	if len(reasons) != 1 || reasons[0] != ReasonInvalid {
+		t.Errorf("expected the entry to be removed as invalid: %v", reasons)
+	}
+	if stats := l.Stats(); stats.Invalid != 1 || stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("bad stats: %+v", stats)
+	}
+}
@@ -0,0 +1,53 @@
+package lru
+
+// Pin protects the entry for key from eviction until it is unpinned, for
+// example while it is in use.  Pins are counted: an entry pinned several
+// times stays pinned until it has been unpinned as many times.  Pinned
+// entries can still be removed explicitly, which drops their pins.  If
+// every entry is pinned, adds grow the cache beyond its size until entries
+// are unpinned.  Returns whether the key was present.
+func (c *Cache[K, V]) Pin(key K) (present bool) {
+	c.lock.Lock()
+	defer c.unlock()
+
+	if !c.contains(key) {
+		return false
+	}
+	e := c.extension()
+	if e.pins == nil {
+		e.pins = make(map[K]int)
+	}
+	if e.pins[key] == 0 {
+		c.lru.SetPinned(key, true)
+	}
+	e.pins[key]++
+	return true
+}
+
+// Unpin releases a pin taken with Pin, making the entry evictable again
+// once every pin is released.  Returns whether the key was pinned.
+func (c *Cache[K, V]) Unpin(key K) (pinned bool) {
+	c.lock.Lock()
+	defer c.unlock()
+
+	if c.ext == nil || c.ext.pins[key] == 0 {
+		return false
+	}
+	pins := c.ext.pins
+	if pins[key]--; pins[key] == 0 {
+		delete(pins, key)
+		c.lru.SetPinned(key, false)
+	}
+	return true
+}
+
+// Pinned returns the number of pinned entries in the cache.
+func (c *Cache[K, V]) Pinned() int {
+	c.lock.Lock()
+	defer c.unlock()
+
+	if c.ext == nil {
+		return 0
+	}
+	return len(c.ext.pins)
+}
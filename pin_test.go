@@ -0,0 +1,52 @@
+package lru
+
+import (
+	"testing"
+)
+
+func TestLRUPin(t *testing.T) {
+	l, err := New[int, int](8)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 8; i++ {
+		l.Add(i, i)
+	}
+	if l.Pin(100) {
+		t.Errorf("absent keys can't be pinned")
+	}
+	l.Pin(0)
+	l.Pin(0)
+	l.Pin(1)
+	if l.Pinned() != 2 {
+		t.Errorf("bad pinned count: %d", l.Pinned())
+	}
+
+	for i := 8; i < 100; i++ {
+		l.Add(i, i)
+	}
+	if !l.Contains(0) || !l.Contains(1) || l.Len() != 8 {
+		t.Fatalf("pinned entries shouldn't be evicted (len %d)", l.Len())
+	}
+
+	// pins are counted
+	l.Unpin(0)
+	if l.Pinned() != 2 {
+		t.Errorf("0 was pinned twice: %d", l.Pinned())
+	}
+	if !l.Unpin(0) || l.Unpin(0) {
+		t.Errorf("0 should have been unpinned exactly once more")
+	}
+	for i := 100; i < 200; i++ {
+		l.Add(i, i)
+	}
+	if l.Contains(0) || !l.Contains(1) {
+		t.Errorf("expected only unpinned 0 to be evicted")
+	}
+
+	// removal drops pins
+	l.Remove(1)
+	if l.Pinned() != 0 || l.Unpin(1) {
+		t.Errorf("removing 1 should have dropped its pin")
+	}
+}
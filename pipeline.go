@@ -0,0 +1,106 @@
+package lru
+
+type pipelineOpKind uint8
+
+const (
+	pipelineGet pipelineOpKind = iota
+	pipelinePeek
+	pipelineAdd
+	pipelineRemove
+)
+
+type pipelineOp[K comparable, V any] struct {
+	kind  pipelineOpKind
+	key   K
+	value V
+}
+
+// PipelineResult is the outcome of a single operation queued on a Pipeline.
+// For Get, Peek and Remove, Value is the key's value and Ok reports whether
+// the key was present.  For Add, Ok reports whether an eviction occurred.
+type PipelineResult[V any] struct {
+	Value V
+	Ok    bool
+}
+
+// Pipeline queues a sequence of operations against a Cache and executes
+// them under a single lock acquisition.  A Pipeline is not safe for
+// concurrent use, but may be reused after Exec.
+type Pipeline[K comparable, V any] struct {
+	c   *Cache[K, V]
+	ops []pipelineOp[K, V]
+}
+
+// Pipeline returns a new, empty Pipeline for the cache.
+func (c *Cache[K, V]) Pipeline() *Pipeline[K, V] {
+	return &Pipeline[K, V]{c: c}
+}
+
+// Get queues a lookup of the key's value, updating the "recently
+// used"-ness of the key.
+func (p *Pipeline[K, V]) Get(key K) *Pipeline[K, V] {
+	p.ops = append(p.ops, pipelineOp[K, V]{kind: pipelineGet, key: key})
+	return p
+}
+
+// Peek queues a lookup of the key's value without updating the "recently
+// used"-ness of the key.
+func (p *Pipeline[K, V]) Peek(key K) *Pipeline[K, V] {
+	p.ops = append(p.ops, pipelineOp[K, V]{kind: pipelinePeek, key: key})
+	return p
+}
+
+// Add queues adding a value to the cache.
+func (p *Pipeline[K, V]) Add(key K, value V) *Pipeline[K, V] {
+	p.ops = append(p.ops, pipelineOp[K, V]{kind: pipelineAdd, key: key, value: value})
+	return p
+}
+
+// Remove queues removing the key from the cache.
+func (p *Pipeline[K, V]) Remove(key K) *Pipeline[K, V] {
+	p.ops = append(p.ops, pipelineOp[K, V]{kind: pipelineRemove, key: key})
+	return p
+}
+
+// Len returns the number of queued operations.
+func (p *Pipeline[K, V]) Len() int {
+	return len(p.ops)
+}
+
+// Exec runs all queued operations in order while holding the cache lock
+// once, returning one result per operation in the order they were queued.
+// The pipeline is empty afterwards.
+func (p *Pipeline[K, V]) Exec() []PipelineResult[V] {
+	results := make([]PipelineResult[V], len(p.ops))
+	p.exec(results)
+
+	// clear out the queued ops to avoid holding on to references for the GC
+	for i := range p.ops {
+		p.ops[i] = pipelineOp[K, V]{}
+	}
+	p.ops = p.ops[:0]
+
+	return results
+}
+
+// exec runs the queued operations, storing their results.  The lock is
+// released with defer, so a panicking callback doesn't leave it held.
+func (p *Pipeline[K, V]) exec(results []PipelineResult[V]) {
+	c := p.c
+	c.lock.Lock()
+	defer c.unlock()
+
+	for i, op := range p.ops {
+		r := &results[i]
+		switch op.kind {
+		case pipelineGet:
+			r.Value, r.Ok = c.get(op.key)
+		case pipelinePeek:
+			r.Value, r.Ok = c.peek(op.key)
+		case pipelineAdd:
+			r.Ok = c.add(op.key, op.value)
+		case pipelineRemove:
+			r.Value, r.Ok = c.remove(op.key)
+		}
+	}
+}
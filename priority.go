@@ -0,0 +1,63 @@
+package lru
+
+import (
+	"errors"
+	"runtime"
+)
+
+// ErrShed is returned by low-priority operations that were shed, rather
+// than waiting for a contended cache lock.
+var ErrShed = errors.New("lru: operation shed under contention")
+
+// lowPrioritySpins is how many times a low-priority operation tries the
+// lock before being shed.  Locks are typically held only briefly, so a few
+// yields ride out a momentary holder, and only sustained contention sheds.
+const lowPrioritySpins = 4
+
+// tryLockSpin tries to acquire m up to lowPrioritySpins times, yielding the
+// processor between attempts, and reports whether it was acquired.
+func tryLockSpin(m *mutex) bool {
+	for i := 0; i < lowPrioritySpins; i++ {
+		if m.TryLock() {
+			return true
+		}
+		runtime.Gosched()
+	}
+	return false
+}
+
+// GetHighPriority looks up a key's value from the cache, waiting for the
+// cache lock however contended it is.  It is the same as Get, and exists
+// to make the priority of call sites explicit alongside GetLowPriority.
+func (c *Cache[K, V]) GetHighPriority(key K) (value V, ok bool) {
+	return c.Get(key)
+}
+
+// GetLowPriority looks up a key's value from the cache, unless the cache
+// lock stays held by other operations through a few brief retries, in
+// which case the lookup is shed and ErrShed returned without waiting any
+// longer.  Use it for batch or background work, so that under contention
+// it gives way to interactive traffic using Get.
+func (c *Cache[K, V]) GetLowPriority(key K) (value V, ok bool, err error) {
+	if !tryLockSpin(&c.lock) {
+		return value, false, ErrShed
+	}
+	defer c.unlock()
+
+	value, ok = c.get(key)
+	return value, ok, nil
+}
+
+// GetLowPriority looks up a key's value from the cache like
+// Cache.GetLowPriority, shedding the lookup if the key's shard stays
+// locked.
+func (c *ShardedCache[V]) GetLowPriority(key string) (value V, ok bool, err error) {
+	shard := c.getShard(key)
+	if !tryLockSpin(&shard.mu) {
+		return value, false, ErrShed
+	}
+	defer shard.mu.Unlock()
+
+	value, ok = shard.get(key)
+	return value, ok, nil
+}
@@ -0,0 +1,72 @@
+package lru
+
+import (
+	"testing"
+)
+
+func TestLRUGetLowPriority(t *testing.T) {
+	l, err := New[string, int](8)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add("1", 1)
+
+	if v, ok, err := l.GetLowPriority("1"); err != nil || !ok || v != 1 {
+		t.Errorf("uncontended lookups shouldn't be shed: %v, %v, %v", v, ok, err)
+	}
+	if v, ok := l.GetHighPriority("1"); !ok || v != 1 {
+		t.Errorf("bad value: %v, %v", v, ok)
+	}
+
+	// simulate another operation holding the lock
+	l.lock.Lock()
+	_, ok, err := l.GetLowPriority("1")
+	l.lock.Unlock()
+	if err != ErrShed || ok {
+		t.Errorf("expected the lookup to be shed: %v, %v", ok, err)
+	}
+}
+
+func TestLRUGetLowPrioritySpin(t *testing.T) {
+	l, err := New[string, int](8)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add("1", 1)
+
+	// a holder releasing the lock while the lookup retries doesn't shed it
+	shed := 0
+	for i := 0; i < 100; i++ {
+		l.lock.Lock()
+		go l.lock.Unlock()
+		if _, _, err := l.GetLowPriority("1"); err == ErrShed {
+			shed++
+		}
+	}
+	if shed == 100 {
+		t.Errorf("expected briefly held locks to be waited out")
+	}
+}
+
+func TestShardedGetLowPriority(t *testing.T) {
+	l, err := NewSharded[int](8, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add("1", 1)
+
+	if v, ok, err := l.GetLowPriority("1"); err != nil || !ok || v != 1 {
+		t.Errorf("uncontended lookups shouldn't be shed: %v, %v, %v", v, ok, err)
+	}
+	if _, ok, err := l.GetLowPriority("2"); err != nil || ok {
+		t.Errorf("expected a miss: %v, %v", ok, err)
+	}
+
+	shard := l.getShard("1")
+	shard.mu.Lock()
+	_, ok, err := l.GetLowPriority("1")
+	shard.mu.Unlock()
+	if err != ErrShed || ok {
+		t.Errorf("expected the lookup to be shed: %v, %v", ok, err)
+	}
+}
@@ -0,0 +1,76 @@
+package lru
+
+import (
+	"github.com/bpowers/approx-lru/internal/approxlru"
+)
+
+// rankedEntries copies the cache's live entries, in no particular order.
+// Copying them is O(n), so callers hold c.lock for just that and put them
+// in order with sortEntries, which is O(n * log(n)), once it is released.
+// c.lock must be held.
+func (c *Cache[K, V]) rankedEntries() []approxlru.RankedEntry[K, V] {
+	ranked := c.lru.AppendRanked(make([]approxlru.RankedEntry[K, V], 0, c.lru.Len()))
+	if c.ext == nil || c.ext.ttl == nil {
+		return ranked
+	}
+	live := ranked[:0]
+	for _, e := range ranked {
+		if expired, stale := c.ext.ttl.expired(e.Key); !expired || stale {
+			live = append(live, e)
+		}
+	}
+	return live
+}
+
+// entries returns the cache's live entries, from least to most recently
+// used, taking c.lock only while copying them.
+func (c *Cache[K, V]) entries() []Entry[K, V] {
+	c.lock.Lock()
+	ranked := c.rankedEntries()
+	c.unlock()
+
+	approxlru.SortRanked(ranked)
+	entries := make([]Entry[K, V], len(ranked))
+	for i, e := range ranked {
+		entries[i] = Entry[K, V]{Key: e.Key, Value: e.Value}
+	}
+	return entries
+}
+
+// Keys returns the keys in the cache, from least to most recently used.
+// Unlike eviction, which is approximate, the order is exact, at the cost of
+// sorting the entries: it is O(n * log(n)) expensive, though the cache lock
+// is only held while copying them.
+func (c *Cache[K, V]) Keys() []K {
+	entries := c.entries()
+
+	keys := make([]K, len(entries))
+	for i, e := range entries {
+		keys[i] = e.Key
+	}
+	return keys
+}
+
+// Values returns the values in the cache, in the same order as Keys.
+func (c *Cache[K, V]) Values() []V {
+	entries := c.entries()
+
+	values := make([]V, len(entries))
+	for i, e := range entries {
+		values[i] = e.Value
+	}
+	return values
+}
+
+// Range calls fn for each entry in the cache, from least to most recently
+// used, until fn returns false.  It doesn't update the "recently used"-ness
+// of entries.  Range works on a snapshot taken up front and doesn't hold
+// the cache lock while calling fn, so fn may itself use the cache, for
+// example to remove entries selectively.
+func (c *Cache[K, V]) Range(fn func(key K, value V) bool) {
+	for _, e := range c.entries() {
+		if !fn(e.Key, e.Value) {
+			return
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package lru
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLRUKeysValues(t *testing.T) {
+	l, err := New[int, string](8)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add(1, "one")
+	l.Add(2, "two")
+	l.Add(3, "three")
+	l.Get(1)
+	l.Peek(2)
+
+	if keys := l.Keys(); !reflect.DeepEqual(keys, []int{2, 3, 1}) {
+		t.Errorf("expected keys from oldest to newest: %v", keys)
+	}
+	if values := l.Values(); !reflect.DeepEqual(values, []string{"two", "three", "one"}) {
+		t.Errorf("expected values from oldest to newest: %v", values)
+	}
+}
+
+func TestLRURange(t *testing.T) {
+	l, err := New[int, int](8)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 8; i++ {
+		l.Add(i, i)
+	}
+
+	var keys []int
+	l.Range(func(k, v int) bool {
+		keys = append(keys, k)
+		// Range doesn't hold the lock, so fn can use the cache
+		if k%2 == 0 {
+			l.Remove(k)
+		}
+		return k < 5
+	})
+	if !reflect.DeepEqual(keys, []int{0, 1, 2, 3, 4, 5}) {
+		t.Errorf("bad keys: %v", keys)
+	}
+	if keys := l.Keys(); !reflect.DeepEqual(keys, []int{1, 3, 5, 6, 7}) {
+		t.Errorf("bad keys: %v", keys)
+	}
+
+	// Range doesn't update recent-ness
+	l.Range(func(k, v int) bool { return true })
+	l.Resize(4)
+	if l.Contains(1) {
+		t.Errorf("Range shouldn't have updated the recent-ness of 1")
+	}
+}
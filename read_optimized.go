@@ -0,0 +1,114 @@
+package lru
+
+import (
+	"sync"
+
+	"github.com/bpowers/approx-lru/internal/approxlru"
+)
+
+// ReadOptimizedCache is a thread-safe fixed size LRU cache for read-mostly
+// workloads.  Reads take only the read side of a sync.RWMutex, so they
+// don't serialize against each other; writes still take it exclusively.
+// Get records recency with an atomic store of the latest write's logical
+// time rather than advancing the clock, so entries read between the same
+// two writes are equally recent.  This makes eviction a little less exact
+// than Cache's in exchange for much better read throughput.
+type ReadOptimizedCache[K comparable, V any] struct {
+	lock sync.RWMutex
+	lru  approxlru.LRU[K, V]
+}
+
+// NewReadOptimized creates a ReadOptimizedCache of the given size.  A size
+// of zero creates an unbounded cache.
+func NewReadOptimized[K comparable, V any](size int) (*ReadOptimizedCache[K, V], error) {
+	return NewReadOptimizedWithEvict[K, V](size, nil)
+}
+
+// NewReadOptimizedWithEvict constructs a ReadOptimizedCache with the given
+// eviction callback, which is called with the write lock held.
+func NewReadOptimizedWithEvict[K comparable, V any](size int, onEvicted func(key K, value V)) (*ReadOptimizedCache[K, V], error) {
+	lru, err := approxlru.NewLRU(size, onEvicted)
+	if err != nil {
+		return nil, err
+	}
+	c := &ReadOptimizedCache[K, V]{
+		lru: *lru,
+	}
+	return c, nil
+}
+
+// Purge is used to completely clear the cache.
+func (c *ReadOptimizedCache[K, V]) Purge() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.lru.Purge()
+}
+
+// Add adds a value to the cache. Returns true if an eviction occurred.
+func (c *ReadOptimizedCache[K, V]) Add(key K, value V) (evicted bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.lru.Add(key, value)
+}
+
+// Get looks up a key's value from the cache, taking only the read lock.
+func (c *ReadOptimizedCache[K, V]) Get(key K) (value V, ok bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.lru.GetConcurrent(key)
+}
+
+// Contains checks if a key is in the cache, without updating the
+// recent-ness or deleting it for being stale.
+func (c *ReadOptimizedCache[K, V]) Contains(key K) bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.lru.Contains(key)
+}
+
+// Peek returns the key value (or undefined if not found) without updating
+// the "recently used"-ness of the key.
+func (c *ReadOptimizedCache[K, V]) Peek(key K) (value V, ok bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.lru.Peek(key)
+}
+
+// Remove removes the provided key from the cache.
+func (c *ReadOptimizedCache[K, V]) Remove(key K) (present bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.lru.Remove(key)
+}
+
+// Resize changes the cache size.  Like Cache.Resize, Resize(0) evicts
+// every entry rather than making the cache unbounded: use SetUnbounded for
+// that.
+func (c *ReadOptimizedCache[K, V]) Resize(size int) (evicted int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.lru.Resize(size)
+}
+
+// SetUnbounded removes the cache's size limit.  Resize bounds it again.
+func (c *ReadOptimizedCache[K, V]) SetUnbounded() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.lru.SetUnbounded()
+}
+
+// Len returns the number of items in the cache.
+func (c *ReadOptimizedCache[K, V]) Len() int {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.lru.Len()
+}
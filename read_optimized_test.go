@@ -0,0 +1,102 @@
+package lru
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestReadOptimized(t *testing.T) {
+	evictCounter := 0
+	l, err := NewReadOptimizedWithEvict[int, int](128, func(k, v int) {
+		evictCounter++
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for i := 0; i < 256; i++ {
+		l.Add(i, i)
+	}
+	if l.Len() != 128 || evictCounter != 128 {
+		t.Fatalf("bad len %v or evict count %v", l.Len(), evictCounter)
+	}
+
+	// reads from many goroutines at once, racing with writes
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				k := 128 + i%128
+				if v, ok := l.Get(k); ok && v != k {
+					t.Errorf("bad value for %d: %d", k, v)
+				}
+				if g == 0 && i%100 == 0 {
+					l.Add(1000+i, i)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	// recently read entries survive eviction
+	l.Purge()
+	for i := 0; i < 128; i++ {
+		l.Add(i, i)
+	}
+	l.Get(0)
+	l.Add(128, 128) // advances the clock past 0's read
+	for i := 129; i < 160; i++ {
+		l.Add(i, i)
+	}
+	if !l.Contains(0) {
+		t.Errorf("0 was read recently, and shouldn't have been evicted")
+	}
+	if !l.Remove(0) || l.Contains(0) {
+		t.Errorf("0 should have been removed")
+	}
+}
+
+func BenchmarkReadOptimized_ParallelGet(b *testing.B) {
+	l, err := NewReadOptimized[string, int](1024)
+	if err != nil {
+		b.Fatalf("err: %v", err)
+	}
+	keys := make([]string, 1024)
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+		l.Add(keys[i], i)
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			l.Get(keys[i%len(keys)])
+			i++
+		}
+	})
+}
+
+func BenchmarkLRU_ParallelGet(b *testing.B) {
+	l, err := New[string, int](1024)
+	if err != nil {
+		b.Fatalf("err: %v", err)
+	}
+	keys := make([]string, 1024)
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+		l.Add(keys[i], i)
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			l.Get(keys[i%len(keys)])
+			i++
+		}
+	})
+}
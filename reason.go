@@ -0,0 +1,45 @@
+package lru
+
+import (
+	"github.com/bpowers/approx-lru/internal/approxlru"
+)
+
+// EvictReason describes why an entry left the cache.
+type EvictReason uint8
+
+const (
+	// ReasonEvicted means the entry was evicted to make room for another.
+	ReasonEvicted = EvictReason(approxlru.ReasonEvicted)
+	// ReasonRemoved means the entry was explicitly removed, for example
+	// by Remove or InvalidateTag.
+	ReasonRemoved = EvictReason(approxlru.ReasonRemoved)
+	// ReasonPurged means the entry was removed by Purge.
+	ReasonPurged = EvictReason(approxlru.ReasonPurged)
+	// ReasonResized means the entry was evicted by Resize shrinking the
+	// cache.
+	ReasonResized = EvictReason(approxlru.ReasonResized)
+	// ReasonExpired means the entry outlived its time-to-live.
+	ReasonExpired = EvictReason(approxlru.ReasonExpired)
+	// ReasonInvalid means the entry failed Options.Validate when looked
+	// up.
+	ReasonInvalid = EvictReason(approxlru.ReasonInvalid)
+)
+
+func (r EvictReason) String() string {
+	switch r {
+	case ReasonEvicted:
+		return "evicted"
+	case ReasonRemoved:
+		return "removed"
+	case ReasonPurged:
+		return "purged"
+	case ReasonResized:
+		return "resized"
+	case ReasonExpired:
+		return "expired"
+	case ReasonInvalid:
+		return "invalid"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,78 @@
+package lru
+
+import (
+	"context"
+)
+
+// RequestCache is a small cache scoped to a single request, layered in
+// front of a shared cache.  Values read from or written to the shared
+// cache are remembered in a local map, so repeated lookups of the same key
+// within the request don't touch the shared cache (or its lock) again.
+// Misses are not remembered.  A RequestCache is not safe for concurrent
+// use, and is meant to be discarded at the end of the request.
+type RequestCache[K comparable, V any] struct {
+	shared Cacher[K, V]
+	local  map[K]V
+}
+
+// NewRequestCache returns an empty RequestCache in front of shared.
+func NewRequestCache[K comparable, V any](shared Cacher[K, V]) *RequestCache[K, V] {
+	return &RequestCache[K, V]{
+		shared: shared,
+		local:  make(map[K]V),
+	}
+}
+
+// Get looks up a key's value, first in the request-local map and then in
+// the shared cache.
+func (r *RequestCache[K, V]) Get(key K) (value V, ok bool) {
+	if value, ok = r.local[key]; ok {
+		return value, true
+	}
+	if value, ok = r.shared.Get(key); ok {
+		r.local[key] = value
+	}
+	return value, ok
+}
+
+// Add adds a value to both the shared cache and the request-local map.
+// Returns true if an eviction occurred in the shared cache.
+func (r *RequestCache[K, V]) Add(key K, value V) (evicted bool) {
+	r.local[key] = value
+	return r.shared.Add(key, value)
+}
+
+// Remove removes the key from both the shared cache and the request-local
+// map.  Returns whether the key was present in the shared cache.
+func (r *RequestCache[K, V]) Remove(key K) (present bool) {
+	delete(r.local, key)
+	return r.shared.Remove(key)
+}
+
+// Shared returns the shared cache this RequestCache is layered over.
+func (r *RequestCache[K, V]) Shared() Cacher[K, V] {
+	return r.shared
+}
+
+// requestCacheKey is the context key a RequestCache is stored under.  It
+// is keyed by the shared cache, so a context can carry request caches for
+// several shared caches at once.
+type requestCacheKey[K comparable, V any] struct {
+	shared Cacher[K, V]
+}
+
+// WithRequestCache returns a copy of ctx carrying a new RequestCache in
+// front of shared.
+func WithRequestCache[K comparable, V any](ctx context.Context, shared Cacher[K, V]) context.Context {
+	return context.WithValue(ctx, requestCacheKey[K, V]{shared}, NewRequestCache(shared))
+}
+
+// RequestCacheFrom returns the RequestCache for shared carried by ctx.  If
+// ctx doesn't carry one, a RequestCache that isn't attached to any context
+// is returned, so callers can always use the result.
+func RequestCacheFrom[K comparable, V any](ctx context.Context, shared Cacher[K, V]) *RequestCache[K, V] {
+	if r, ok := ctx.Value(requestCacheKey[K, V]{shared}).(*RequestCache[K, V]); ok {
+		return r
+	}
+	return NewRequestCache(shared)
+}
@@ -0,0 +1,43 @@
+package lru
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRequestCache(t *testing.T) {
+	shared, err := New[string, int](2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	shared.Add("1", 1)
+
+	ctx := WithRequestCache[string, int](context.Background(), shared)
+	r := RequestCacheFrom[string, int](ctx, shared)
+	if r != RequestCacheFrom[string, int](ctx, shared) {
+		t.Fatalf("expected the same request cache from the same context")
+	}
+
+	if v, ok := r.Get("1"); !ok || v != 1 {
+		t.Fatalf("1 should be set to 1: %v, %v", v, ok)
+	}
+	// served from the request-local map even once gone from the shared cache
+	shared.Remove("1")
+	if v, ok := r.Get("1"); !ok || v != 1 {
+		t.Errorf("1 should still be visible to the request: %v, %v", v, ok)
+	}
+
+	r.Add("2", 2)
+	if v, ok := shared.Get("2"); !ok || v != 2 {
+		t.Errorf("Add should write through to the shared cache: %v, %v", v, ok)
+	}
+	r.Remove("2")
+	if _, ok := r.Get("2"); ok {
+		t.Errorf("2 should have been removed")
+	}
+
+	other := RequestCacheFrom[string, int](context.Background(), shared)
+	if _, ok := other.Get("1"); ok {
+		t.Errorf("a fresh request cache shouldn't see another request's values")
+	}
+}
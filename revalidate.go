@@ -0,0 +1,52 @@
+package lru
+
+import (
+	"context"
+	"sync"
+)
+
+// revalidator refreshes stale entries in the background.
+type revalidator[K comparable, V any] struct {
+	refresh func(ctx context.Context, key K) (V, error)
+	// ctx is the ctx of the GetCtx or GetOrLoadCtx call holding c.lock,
+	// if any, for the refreshes it starts.
+	ctx context.Context
+	// inflight holds the keys being refreshed
+	inflight map[K]struct{}
+	// pending tracks the refresh goroutines, so tests can wait for them.
+	pending sync.WaitGroup
+}
+
+// revalidate starts refreshing key in the background, unless it already is
+// being refreshed.  c.lock must be held.
+func (c *Cache[K, V]) revalidate(key K) {
+	r := c.ext.revalidator
+	if _, ok := r.inflight[key]; ok {
+		return
+	}
+	r.inflight[key] = struct{}{}
+	// refreshed values keep the entry's own time-to-live, rather than
+	// the cache's default
+	ttl := c.ext.ttl.ttl(key)
+	parent := r.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := detach(parent)
+	r.pending.Add(1)
+	go func() {
+		defer r.pending.Done()
+		defer cancel()
+		value, err := r.refresh(ctx, key)
+
+		c.lock.Lock()
+		defer c.unlock()
+		delete(r.inflight, key)
+		if err == nil {
+			c.add(key, value)
+			if c.lru.Contains(key) {
+				c.ext.ttl.set(key, ttl)
+			}
+		}
+	}()
+}
@@ -0,0 +1,173 @@
+package lru
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLRUStaleWhileRevalidate(t *testing.T) {
+	// the clock is read by refresh goroutines, so guard it
+	var clockMu sync.Mutex
+	now := time.Unix(1000, 0)
+	advance := func(d time.Duration) {
+		clockMu.Lock()
+		defer clockMu.Unlock()
+		now = now.Add(d)
+	}
+
+	var calls int
+	release := make(chan error)
+	l, err := NewWithOptions[string, int](8, Options[string, int]{
+		TTL:                  time.Minute,
+		StaleWhileRevalidate: time.Minute,
+		Refresh: func(k string) (int, error) {
+			calls++
+			return 2, <-release
+		},
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.ext.ttl.now = func() time.Time {
+		clockMu.Lock()
+		defer clockMu.Unlock()
+		return now
+	}
+	refreshes := &l.ext.revalidator.pending
+
+	l.Add("1", 1)
+	advance(90 * time.Second)
+	for i := 0; i < 2; i++ {
+		if v, ok := l.Get("1"); !ok || v != 1 {
+			t.Errorf("expected the stale value while revalidating: %v, %v", v, ok)
+		}
+	}
+
+	// a failed refresh leaves the stale entry alone
+	release <- errors.New("unavailable")
+	refreshes.Wait()
+	if v, ok := l.Peek("1"); !ok || v != 1 {
+		t.Errorf("expected the stale value after a failed refresh: %v, %v", v, ok)
+	}
+
+	// ... and the next lookup tries again
+	l.Get("1")
+	release <- nil
+	refreshes.Wait()
+	if v, ok := l.Peek("1"); !ok || v != 2 {
+		t.Errorf("expected the refreshed value: %v, %v", v, ok)
+	}
+	if calls != 2 {
+		t.Errorf("expected one refresh per stale lookup burst, not %d", calls)
+	}
+	if l.DegradedServes() != 0 {
+		t.Errorf("revalidating shouldn't count as degraded: %d", l.DegradedServes())
+	}
+
+	// the refresh reset the TTL, so this doesn't start another one
+	advance(45 * time.Second)
+	l.Get("1")
+	// past the window, the entry is gone
+	advance(2 * time.Minute)
+	if _, ok := l.Get("1"); ok {
+		t.Errorf("1 should have expired after the revalidation window")
+	}
+	if calls != 2 {
+		t.Errorf("unexpected refreshes: %d", calls)
+	}
+
+	if _, err := NewWithOptions[string, int](8, Options[string, int]{StaleWhileRevalidate: time.Minute}); err == nil {
+		t.Errorf("expected an error without a Refresh function")
+	}
+}
+
+func TestLRUStaleWhileRevalidateTTL(t *testing.T) {
+	var clockMu sync.Mutex
+	now := time.Unix(1000, 0)
+	l, err := NewWithOptions[string, int](8, Options[string, int]{
+		StaleWhileRevalidate: time.Minute,
+		Refresh: func(k string) (int, error) {
+			return 2, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.ext.ttl.now = func() time.Time {
+		clockMu.Lock()
+		defer clockMu.Unlock()
+		return now
+	}
+
+	l.AddWithTTL("1", 1, time.Minute)
+	clockMu.Lock()
+	now = now.Add(90 * time.Second)
+	clockMu.Unlock()
+	l.Get("1")
+	l.ext.revalidator.pending.Wait()
+	if v, ok := l.Peek("1"); !ok || v != 2 {
+		t.Fatalf("expected the refreshed value: %v, %v", v, ok)
+	}
+
+	// the refreshed entry expires like the original, despite there being
+	// no default TTL
+	clockMu.Lock()
+	now = now.Add(24 * time.Hour)
+	clockMu.Unlock()
+	if v, ok := l.Get("1"); ok {
+		t.Errorf("expected the refreshed entry to expire: %v", v)
+	}
+}
+
+func TestLRURefreshCtx(t *testing.T) {
+	type ctxKey struct{}
+	now := time.Unix(1000, 0)
+	started := make(chan context.Context, 1)
+	release := make(chan struct{})
+	l, err := NewWithOptions[string, int](8, Options[string, int]{
+		TTL:                  time.Minute,
+		StaleWhileRevalidate: time.Minute,
+		RefreshCtx: func(ctx context.Context, k string) (int, error) {
+			started <- ctx
+			<-release
+			return 2, ctx.Err()
+		},
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.ext.ttl.now = func() time.Time { return now }
+
+	l.Add("1", 1)
+	now = now.Add(90 * time.Second)
+	deadline := time.Now().Add(time.Hour)
+	ctx, cancel := context.WithDeadline(context.WithValue(context.Background(), ctxKey{}, "request"), deadline)
+	if v, ok, err := l.GetCtx(ctx, "1"); err != nil || !ok || v != 1 {
+		t.Errorf("expected the stale value while revalidating: %v, %v, %v", v, ok, err)
+	}
+	refreshCtx := <-started
+	// the lookup is over, but its refresh isn't
+	cancel()
+	if refreshCtx.Value(ctxKey{}) != "request" {
+		t.Errorf("expected the refresh to carry the lookup's values")
+	}
+	if d, ok := refreshCtx.Deadline(); !ok || !d.Equal(deadline) {
+		t.Errorf("expected the refresh to carry the lookup's deadline: %v, %v", d, ok)
+	}
+	close(release)
+	l.ext.revalidator.pending.Wait()
+	if v, ok := l.Peek("1"); !ok || v != 2 {
+		t.Errorf("expected the refresh to outlive the lookup's cancelation: %v, %v", v, ok)
+	}
+
+	// refreshes started without a ctx get a background one
+	now = now.Add(90 * time.Second)
+	l.Get("1")
+	if refreshCtx := <-started; refreshCtx.Done() != nil {
+		t.Errorf("expected a background ctx for a refresh started by Get")
+	}
+	l.ext.revalidator.pending.Wait()
+}
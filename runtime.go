@@ -0,0 +1,71 @@
+package lru
+
+import (
+	"time"
+)
+
+// RuntimeReport describes a cache's background goroutines, so health
+// checks and leak detectors can verify they are alive and bounded.
+type RuntimeReport struct {
+	// Goroutines is the number of background goroutines the cache runs.
+	Goroutines int
+	// Reaper describes the goroutine removing expired entries, if any.
+	Reaper *ReaperReport
+	// EvictQueue is the number of evictions waiting for the background
+	// eviction callback goroutine, if there is one.
+	EvictQueue int
+	// EvictDropped and EvictSpilled count the evictions that didn't fit
+	// in the queue, and were dropped or spilled as Options.AsyncEvictPolicy
+	// says.
+	EvictDropped uint64
+	EvictSpilled uint64
+	// MirrorDropped counts the changes dropped rather than mirrored to
+	// Options.Mirror because its queue was full.
+	MirrorDropped uint64
+}
+
+// ReaperReport describes the goroutine removing expired entries.
+type ReaperReport struct {
+	Interval time.Duration
+	// Runs is the number of times the reaper has run, and LastRun when it
+	// last did, or the zero time if it hasn't yet.
+	Runs    uint64
+	LastRun time.Time
+}
+
+// Runtime reports on the cache's background goroutines.  Caches only run
+// any when configured to, such as with Options.ReapInterval,
+// Options.AsyncEvictQueue or SetTargetSizeFunc, and stop them when closed.
+func (c *Cache[K, V]) Runtime() (report RuntimeReport) {
+	c.lock.Lock()
+	defer c.unlock()
+
+	if c.ext == nil {
+		return report
+	}
+	if a := c.ext.async; a != nil {
+		report.Goroutines++
+		a.mu.Lock()
+		report.EvictQueue = len(a.queue)
+		report.EvictDropped, report.EvictSpilled = a.dropped, a.spills
+		a.mu.Unlock()
+	}
+	if c.ext.autoSize != nil {
+		report.Goroutines++
+	}
+	if m := c.ext.mirror; m != nil {
+		report.Goroutines++
+		report.MirrorDropped = m.dropped
+	}
+	t := c.ext.ttl
+	if t == nil || t.stop == nil {
+		return report
+	}
+	report.Goroutines++
+	report.Reaper = &ReaperReport{
+		Interval: t.reapInterval,
+		Runs:     t.reaps,
+		LastRun:  t.lastReap,
+	}
+	return report
+}
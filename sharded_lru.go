@@ -2,17 +2,60 @@ package lru
 
 import (
 	"hash/maphash"
-	"sync"
+	"runtime"
+	"strconv"
+	"strings"
+	"unsafe"
 
 	"github.com/bpowers/approx-lru/internal/approxlru"
 )
 
-const defaultShardCount = 256
+// shardsPerProc is the number of shards per GOMAXPROCS used by default.
+// Using several per processor keeps the odds of two goroutines contending
+// for the same shard low.
+const shardsPerProc = 16
+
+// defaultShardCount returns the number of shards to use when the caller
+// doesn't specify one.
+func defaultShardCount() int {
+	return shardsPerProc * runtime.GOMAXPROCS(0)
+}
 
 type shard[V any] struct {
-	mu       sync.Mutex
-	lru      approxlru.LRU[string, V]
-	_padding [16]uint8
+	mu    mutex
+	lru   approxlru.LRU[string, V]
+	stats *statsCounters
+	// _padding is empty on 64-bit platforms, and must not be last: Go
+	// pads structs ending in a zero-size field.
+	_padding [shardPadding]uint8
+	// global is set for caches approximating a global eviction order.
+	global *globalEviction[V]
+}
+
+// shardPadding pads a shard out to a multiple of the cache line size.
+const shardPadding = (cacheLineSize - (unsafe.Sizeof(mutex{})+approxlru.LRUStructSize+2*unsafe.Sizeof(uintptr(0)))%cacheLineSize) % cacheLineSize
+
+// add adds a value to the shard, counting it in the shard's stats.
+// s.mu must be held.
+func (s *shard[V]) add(key string, value V) (evicted bool) {
+	var stole bool
+	if s.global != nil {
+		stole = s.global.makeRoom(s, key, s.global.tick(s))
+	}
+	evicted = s.lru.Add(key, value)
+	s.stats.added(evicted)
+	return evicted || stole
+}
+
+// get looks up a key's value in the shard, counting it in the shard's
+// stats.  s.mu must be held.
+func (s *shard[V]) get(key string) (value V, ok bool) {
+	if s.global != nil {
+		s.global.read(s)
+	}
+	value, ok = s.lru.Get(key)
+	s.stats.lookup(ok)
+	return value, ok
 }
 
 // Cache is a thread-safe fixed size LRU cache.
@@ -22,7 +65,11 @@ type ShardedCache[V any] struct {
 	size         int
 }
 
-// New creates an LRU of the given size.
+// NewSharded creates an LRU of the given size, split across shardCount
+// shards.  A non-positive shardCount picks a default based on GOMAXPROCS.
+// Sizes smaller than the shard count are rounded up to it.  A size of
+// zero creates an unbounded cache, which never evicts entries to make room
+// for new ones.
 func NewSharded[V any](size, shardCount int) (*ShardedCache[V], error) {
 	return NewShardedWithEvict[V](size, shardCount, nil)
 }
@@ -31,9 +78,9 @@ func NewSharded[V any](size, shardCount int) (*ShardedCache[V], error) {
 // callback.
 func NewShardedWithEvict[V any](size, shardCount int, onEvicted func(key string, value V)) (*ShardedCache[V], error) {
 	if shardCount <= 0 {
-		shardCount = defaultShardCount
+		shardCount = defaultShardCount()
 	}
-	if size < shardCount {
+	if size != 0 && size < shardCount {
 		size = shardCount
 	}
 	perShardSize := size / shardCount
@@ -49,25 +96,41 @@ func NewShardedWithEvict[V any](size, shardCount int, onEvicted func(key string,
 			return nil, err
 		}
 		c.shards[i].lru = *shard
+		c.shards[i].stats = new(statsCounters)
 	}
 	return c, nil
 }
 
 // Purge is used to completely clear the cache.
 func (c *ShardedCache[V]) Purge() {
+	c.PurgeIncremental(nil)
+}
+
+// PurgeIncremental clears the cache one shard at a time, only ever holding
+// a single shard's lock.  If progress is non-nil it is called without any
+// locks held after each shard is cleared, with the number of shards
+// cleared so far and the total number of shards; callers can use it to
+// report progress or to yield between shards.
+func (c *ShardedCache[V]) PurgeIncremental(progress func(done, total int)) {
 	for i := 0; i < len(c.shards); i++ {
 		shard := &c.shards[i]
 		shard.mu.Lock()
 		shard.lru.Purge()
 		shard.mu.Unlock()
+		if progress != nil {
+			progress(i+1, len(c.shards))
+		}
 	}
 }
 
-func (c *ShardedCache[V]) getShard(key string) *shard[V] {
+func (c *ShardedCache[V]) shardIndex(key string) int {
 	hash := c.templateHash
 	hash.WriteString(key)
-	shardId := hash.Sum64() % uint64(len(c.shards))
-	return &c.shards[shardId]
+	return int(hash.Sum64() % uint64(len(c.shards)))
+}
+
+func (c *ShardedCache[V]) getShard(key string) *shard[V] {
+	return &c.shards[c.shardIndex(key)]
 }
 
 // Add adds a value to the cache. Returns true if an eviction occurred.
@@ -75,7 +138,39 @@ func (c *ShardedCache[V]) Add(key string, value V) (evicted bool) {
 	shard := c.getShard(key)
 	shard.mu.Lock()
 	defer shard.mu.Unlock()
-	return shard.lru.Add(key, value)
+	return shard.add(key, value)
+}
+
+// WarmFrom adds every key/value pair produced by seq to the cache, in
+// order, treating later pairs as more recently used than earlier ones.  The
+// eviction callback is not invoked for entries evicted while warming.  seq
+// has the same shape as an iter.Seq2[string, V].  Returns the number of
+// pairs added.
+func (c *ShardedCache[V]) WarmFrom(seq func(yield func(string, V) bool)) (added int) {
+	seq(func(key string, value V) bool {
+		shard := c.getShard(key)
+		shard.mu.Lock()
+		if shard.global != nil {
+			shard.global.tick(shard)
+		}
+		shard.stats.added(shard.lru.AddWithoutCallback(key, value))
+		shard.mu.Unlock()
+		added++
+		return true
+	})
+	return added
+}
+
+// WarmFromChan is like WarmFrom, but reads entries from ch until it is
+// closed.
+func (c *ShardedCache[V]) WarmFromChan(ch <-chan Entry[string, V]) (added int) {
+	return c.WarmFrom(func(yield func(string, V) bool) {
+		for e := range ch {
+			if !yield(e.Key, e.Value) {
+				return
+			}
+		}
+	})
 }
 
 // Get looks up a key's value from the cache.
@@ -83,7 +178,7 @@ func (c *ShardedCache[V]) Get(key string) (value V, ok bool) {
 	shard := c.getShard(key)
 	shard.mu.Lock()
 	defer shard.mu.Unlock()
-	return shard.lru.Get(key)
+	return shard.get(key)
 }
 
 // Contains checks if a key is in the cache, without updating the
@@ -95,6 +190,29 @@ func (c *ShardedCache[V]) Contains(key string) bool {
 	return shard.lru.Contains(key)
 }
 
+// ContainsMulti checks if each of the keys is in the cache, without updating
+// their recent-ness, while taking each shard's lock at most once.  The
+// result holds one entry per key, in order.
+func (c *ShardedCache[V]) ContainsMulti(keys []string) []bool {
+	found := make([]bool, len(keys))
+
+	// group the offsets of keys by the shard they live in
+	byShard := make(map[*shard[V]][]int)
+	for i, key := range keys {
+		shard := c.getShard(key)
+		byShard[shard] = append(byShard[shard], i)
+	}
+
+	for shard, offsets := range byShard {
+		shard.mu.Lock()
+		for _, i := range offsets {
+			found[i] = shard.lru.Contains(keys[i])
+		}
+		shard.mu.Unlock()
+	}
+	return found
+}
+
 // Peek returns the key value (or undefined if not found) without updating
 // the "recently used"-ness of the key.
 func (c *ShardedCache[V]) Peek(key string) (value V, ok bool) {
@@ -115,7 +233,7 @@ func (c *ShardedCache[V]) ContainsOrAdd(key string, value V) (ok, evicted bool)
 	if shard.lru.Contains(key) {
 		return true, false
 	}
-	evicted = shard.lru.Add(key, value)
+	evicted = shard.add(key, value)
 	return false, evicted
 }
 
@@ -132,10 +250,43 @@ func (c *ShardedCache[V]) PeekOrAdd(key string, value V) (previous V, ok, evicte
 		return previous, true, false
 	}
 
-	evicted = shard.lru.Add(key, value)
+	evicted = shard.add(key, value)
 	return previous, false, evicted
 }
 
+// GetOrAdd looks up a key's value from the cache, updating the
+// "recently used"-ness of the key if found, and if not, adds the value.
+// Returns the value now stored for the key, whether it was already present
+// and whether an eviction occurred.
+func (c *ShardedCache[V]) GetOrAdd(key string, value V) (actual V, ok, evicted bool) {
+	shard := c.getShard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	actual, ok = shard.get(key)
+	if ok {
+		return actual, true, false
+	}
+
+	evicted = shard.add(key, value)
+	return value, false, evicted
+}
+
+// LoadOrStore is GetOrAdd with the signature of sync.Map's LoadOrStore: it
+// returns the existing value for key if present, and otherwise adds and
+// returns value.  loaded reports whether the value was already present.
+func (c *ShardedCache[V]) LoadOrStore(key string, value V) (actual V, loaded bool) {
+	actual, loaded, _ = c.GetOrAdd(key, value)
+	return actual, loaded
+}
+
+// AddIfAbsent adds a value to the cache only if key isn't already present,
+// returning whether it was added.
+func (c *ShardedCache[V]) AddIfAbsent(key string, value V) (added bool) {
+	ok, _ := c.ContainsOrAdd(key, value)
+	return !ok
+}
+
 // Remove removes the provided key from the cache.
 func (c *ShardedCache[V]) Remove(key string) (present bool) {
 	shard := c.getShard(key)
@@ -144,6 +295,58 @@ func (c *ShardedCache[V]) Remove(key string) (present bool) {
 	return shard.lru.Remove(key)
 }
 
+// RemoveAndGet removes the provided key from the cache, returning the
+// removed value.
+func (c *ShardedCache[V]) RemoveAndGet(key string) (value V, present bool) {
+	shard := c.getShard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	return shard.lru.RemoveAndGet(key)
+}
+
+// KeysPage returns up to limit keys from the cache, starting at cursor, and
+// the cursor to pass to get the next page.  Pass an empty cursor to get
+// the first page; an empty next cursor means there are no more keys.
+// A non-positive limit returns all remaining keys.  Keys are returned in
+// no particular order, and paging is best-effort: keys added or removed
+// while paging may cause other keys to be skipped or repeated.
+func (c *ShardedCache[V]) KeysPage(cursor string, limit int) (keys []string, next string) {
+	shardID, offset := 0, 0
+	if cursor != "" {
+		var err1, err2 error
+		s, o, _ := strings.Cut(cursor, ":")
+		shardID, err1 = strconv.Atoi(s)
+		offset, err2 = strconv.Atoi(o)
+		if err1 != nil || err2 != nil || shardID < 0 || offset < 0 {
+			return nil, ""
+		}
+	}
+
+	for ; shardID < len(c.shards); shardID++ {
+		remaining := 0
+		if limit > 0 {
+			remaining = limit - len(keys)
+		}
+		shard := &c.shards[shardID]
+		shard.mu.Lock()
+		var more bool
+		keys, offset, more = shard.lru.KeysPage(keys, offset, remaining)
+		shard.mu.Unlock()
+
+		if more {
+			return keys, strconv.Itoa(shardID) + ":" + strconv.Itoa(offset)
+		}
+		offset = 0
+		if limit > 0 && len(keys) >= limit {
+			if shardID+1 < len(c.shards) {
+				next = strconv.Itoa(shardID+1) + ":0"
+			}
+			return keys, next
+		}
+	}
+	return keys, ""
+}
+
 // we don't support resize
 
 // Len returns the number of items in the cache.
@@ -1,6 +1,7 @@
 package lru
 
 import (
+	"runtime"
 	"strconv"
 	"sync"
 	"testing"
@@ -8,20 +9,180 @@ import (
 )
 
 func TestNewSharded(t *testing.T) {
+	l, err := NewSharded[int](0, 0)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(l.shards) != shardsPerProc*runtime.GOMAXPROCS(0) {
+		t.Errorf("expected a GOMAXPROCS-based default shard count, not %d", len(l.shards))
+	}
+
+	l, err = NewSharded[int](3, 8)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(l.shards) != 8 || l.size != 8 {
+		t.Errorf("expected the size to be rounded up to the shard count: %d shards, size %d", len(l.shards), l.size)
+	}
+}
+
+func TestShardedPurgeIncremental(t *testing.T) {
+	l, err := NewSharded[int](64, 4)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 64; i++ {
+		l.Add(strconv.Itoa(i), i)
+	}
+
+	calls := 0
+	l.PurgeIncremental(func(done, total int) {
+		calls++
+		if done != calls || total != 4 {
+			t.Errorf("unexpected progress: %d/%d", done, total)
+		}
+	})
+	if calls != 4 {
+		t.Errorf("expected progress to be called 4 times, not %d", calls)
+	}
+	if l.Len() != 0 {
+		t.Errorf("bad len: %v", l.Len())
+	}
+}
 
+func TestShardedContainsMulti(t *testing.T) {
+	l, err := NewSharded[int](256, 4)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	keys := make([]string, 0, 32)
+	for i := 0; i < 32; i++ {
+		k := strconv.Itoa(i)
+		keys = append(keys, k)
+		if i%2 == 0 {
+			l.Add(k, i)
+		}
+	}
+
+	found := l.ContainsMulti(keys)
+	for i, ok := range found {
+		if ok != (i%2 == 0) {
+			t.Errorf("unexpected membership for %d: %v", i, ok)
+		}
+	}
+}
+
+func TestShardedLoadOrStore(t *testing.T) {
+	l, err := NewSharded[int](64, 4)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if v, loaded := l.LoadOrStore("1", 1); loaded || v != 1 {
+		t.Errorf("1 should have been stored: %v, %v", v, loaded)
+	}
+	if v, loaded := l.LoadOrStore("1", 2); !loaded || v != 1 {
+		t.Errorf("the first value should win: %v, %v", v, loaded)
+	}
+	if l.AddIfAbsent("1", 3) || !l.AddIfAbsent("2", 2) {
+		t.Errorf("AddIfAbsent should only add missing keys")
+	}
+	if v, _ := l.Get("1"); v != 1 {
+		t.Errorf("bad value: %v", v)
+	}
+}
+
+func TestShardedKeysPage(t *testing.T) {
+	l, err := NewSharded[int](256, 4)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 100; i++ {
+		l.Add(strconv.Itoa(i), i)
+	}
+
+	seen := make(map[string]bool)
+	cursor := ""
+	for {
+		keys, next := l.KeysPage(cursor, 7)
+		if len(keys) > 7 {
+			t.Fatalf("page too large: %d", len(keys))
+		}
+		for _, k := range keys {
+			if seen[k] {
+				t.Fatalf("key %s returned twice", k)
+			}
+			seen[k] = true
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	if len(seen) != l.Len() {
+		t.Errorf("expected %d keys, not %d", l.Len(), len(seen))
+	}
 }
 
 func TestShardSize(t *testing.T) {
 	if 128 != unsafe.Sizeof(shard[int]{}) {
 		t.Fatalf("expected shard to be 128-bytes in size")
 	}
+	if unsafe.Sizeof(shard[int]{})%cacheLineSize != 0 {
+		t.Fatalf("expected shard to be a multiple of the %d-byte cache line", cacheLineSize)
+	}
+}
+
+// BenchmarkShardedLRU_FalseSharing has every goroutine hammer its own
+// shard, so any slowdown as parallelism grows comes from neighbouring
+// shards sharing cache lines rather than from lock contention.
+func BenchmarkShardedLRU_FalseSharing(b *testing.B) {
+	const shardCount = 64
+	l, err := NewSharded[int64](shardCount*1024, shardCount)
+	if err != nil {
+		b.Fatalf("err: %v", err)
+	}
+
+	// find a handful of keys living in each shard
+	keys := make([][]string, shardCount)
+	for i, found := 0, 0; found < shardCount; i++ {
+		k := strconv.Itoa(i)
+		id := l.shardIndex(k)
+		if len(keys[id]) < 8 {
+			keys[id] = append(keys[id], k)
+			if len(keys[id]) == 8 {
+				found++
+			}
+		}
+	}
+
+	var next int64
+	var nextMu sync.Mutex
+	b.ResetTimer()
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		nextMu.Lock()
+		mine := keys[next%shardCount]
+		next++
+		nextMu.Unlock()
+
+		i := 0
+		for pb.Next() {
+			k := mine[i%len(mine)]
+			if i%2 == 0 {
+				l.Add(k, int64(i))
+			} else {
+				l.Get(k)
+			}
+			i++
+		}
+	})
 }
 
 func BenchmarkLRU_BigSharded(b *testing.B) {
 	var rngMu sync.Mutex
 	rng := newRand()
 	rngMu.Lock()
-	l, err := NewSharded[int64](128*1024, defaultShardCount)
+	l, err := NewSharded[int64](128*1024, defaultShardCount())
 	if err != nil {
 		b.Fatalf("err: %v", err)
 	}
@@ -74,7 +235,7 @@ func BenchmarkShardedLRU_HotKey(b *testing.B) {
 	var rngMu sync.Mutex
 	rng := newRand()
 	rngMu.Lock()
-	l, err := NewSharded[int64](128*1024, defaultShardCount)
+	l, err := NewSharded[int64](128*1024, defaultShardCount())
 	if err != nil {
 		b.Fatalf("err: %v", err)
 	}
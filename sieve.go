@@ -0,0 +1,270 @@
+package lru
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// SieveCache is a thread-safe fixed size cache using the SIEVE eviction
+// algorithm, with the same core methods as Cache so the two can be
+// compared on a workload without changing call sites.  SIEVE keeps
+// entries in insertion order with a "visited" bit set by lookups; to
+// evict, a hand sweeps from the oldest entry towards the newest, clearing
+// visited bits and evicting the first unvisited entry.  Unlike LRU, hits
+// never move entries, so lookups only take a read lock; and on many web
+// workloads SIEVE's hit ratio beats LRU's.
+type SieveCache[K comparable, V any] struct {
+	lock    sync.RWMutex
+	size    int
+	onEvict func(key K, value V)
+	items   map[K]int
+	// entries holds the list nodes, linked from head (newest) to tail
+	// (oldest) by index, with removed nodes' indexes in free.  -1 means
+	// no node.
+	entries    []sieveEntry[K, V]
+	free       []int
+	head, tail int
+	// hand is the next node the eviction hand examines, or -1 to start
+	// from the tail.
+	hand  int
+	stats *statsCounters
+}
+
+type sieveEntry[K comparable, V any] struct {
+	key        K
+	value      V
+	visited    uint32 // accessed atomically, with the read lock held
+	prev, next int
+}
+
+// NewSieve creates a SIEVE cache of the given size.
+func NewSieve[K comparable, V any](size int) (*SieveCache[K, V], error) {
+	return NewSieveWithEvict[K, V](size, nil)
+}
+
+// NewSieveWithEvict constructs a fixed size SIEVE cache with the given
+// eviction callback, which is called with the write lock held.
+func NewSieveWithEvict[K comparable, V any](size int, onEvict func(key K, value V)) (*SieveCache[K, V], error) {
+	if size <= 0 {
+		return nil, errors.New("must provide a positive size")
+	}
+	return &SieveCache[K, V]{
+		size:    size,
+		onEvict: onEvict,
+		items:   make(map[K]int, size),
+		entries: make([]sieveEntry[K, V], 0, size),
+		head:    -1,
+		tail:    -1,
+		hand:    -1,
+		stats:   new(statsCounters),
+	}, nil
+}
+
+// Add adds a value to the cache.  Returns true if an eviction occurred.
+// Updating an existing key marks it visited, but doesn't move it.
+func (c *SieveCache[K, V]) Add(key K, value V) (evicted bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if i, ok := c.items[key]; ok {
+		c.entries[i].value = value
+		c.entries[i].visited = 1
+		c.stats.added(false)
+		return false
+	}
+	if len(c.items) >= c.size {
+		c.evict()
+		evicted = true
+	}
+
+	ent := sieveEntry[K, V]{key: key, value: value, prev: -1, next: c.head}
+	var i int
+	if n := len(c.free); n > 0 {
+		i = c.free[n-1]
+		c.free = c.free[:n-1]
+		c.entries[i] = ent
+	} else {
+		i = len(c.entries)
+		c.entries = append(c.entries, ent)
+	}
+	if c.head >= 0 {
+		c.entries[c.head].prev = i
+	}
+	c.head = i
+	if c.tail < 0 {
+		c.tail = i
+	}
+	c.items[key] = i
+	c.stats.added(evicted)
+	return evicted
+}
+
+// evict removes the entry under the eviction hand.  c.lock must be held,
+// and the cache non-empty.
+func (c *SieveCache[K, V]) evict() {
+	i := c.hand
+	if i < 0 {
+		i = c.tail
+	}
+	for c.entries[i].visited != 0 {
+		c.entries[i].visited = 0
+		if i = c.entries[i].prev; i < 0 {
+			i = c.tail
+		}
+	}
+	c.hand = c.entries[i].prev
+	c.unlink(i)
+}
+
+// unlink removes node i from the list, keeping the hand valid, and calls
+// the eviction callback.  c.lock must be held.
+func (c *SieveCache[K, V]) unlink(i int) {
+	ent := &c.entries[i]
+	key, value := ent.key, ent.value
+	if c.hand == i {
+		c.hand = ent.prev
+	}
+	if ent.prev >= 0 {
+		c.entries[ent.prev].next = ent.next
+	} else {
+		c.head = ent.next
+	}
+	if ent.next >= 0 {
+		c.entries[ent.next].prev = ent.prev
+	} else {
+		c.tail = ent.prev
+	}
+	delete(c.items, ent.key)
+	// drop references to the key and value
+	*ent = sieveEntry[K, V]{}
+	c.free = append(c.free, i)
+	if c.onEvict != nil {
+		c.onEvict(key, value)
+	}
+}
+
+// Get looks up a key's value from the cache, marking it visited.  It only
+// takes the read lock.
+func (c *SieveCache[K, V]) Get(key K) (value V, ok bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	i, ok := c.items[key]
+	c.stats.lookup(ok)
+	if !ok {
+		return value, false
+	}
+	ent := &c.entries[i]
+	// skip the store when already visited, to keep hot entries' cache
+	// lines shared between readers
+	if atomic.LoadUint32(&ent.visited) == 0 {
+		atomic.StoreUint32(&ent.visited, 1)
+	}
+	return ent.value, true
+}
+
+// Contains checks if a key is in the cache, without marking it visited.
+func (c *SieveCache[K, V]) Contains(key K) bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	_, ok := c.items[key]
+	return ok
+}
+
+// Peek returns the key's value without marking it visited.
+func (c *SieveCache[K, V]) Peek(key K) (value V, ok bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if i, ok := c.items[key]; ok {
+		return c.entries[i].value, true
+	}
+	return value, false
+}
+
+// Remove removes the provided key from the cache, returning if the key was
+// contained.
+func (c *SieveCache[K, V]) Remove(key K) (present bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	i, ok := c.items[key]
+	if ok {
+		c.unlink(i)
+	}
+	return ok
+}
+
+// Resize changes the cache size, evicting entries as needed to shrink it
+// and returning the number evicted.  Non-positive sizes are ignored.
+func (c *SieveCache[K, V]) Resize(size int) (evicted int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if size <= 0 {
+		return 0
+	}
+	for ; len(c.items) > size; evicted++ {
+		c.evict()
+	}
+	c.size = size
+	c.stats.evicted(evicted)
+	return evicted
+}
+
+// Keys returns the keys in the cache, from oldest to newest added.
+func (c *SieveCache[K, V]) Keys() []K {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	keys := make([]K, 0, len(c.items))
+	for i := c.tail; i >= 0; i = c.entries[i].prev {
+		keys = append(keys, c.entries[i].key)
+	}
+	return keys
+}
+
+// Values returns the values in the cache, in the same order as Keys.
+func (c *SieveCache[K, V]) Values() []V {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	values := make([]V, 0, len(c.items))
+	for i := c.tail; i >= 0; i = c.entries[i].prev {
+		values = append(values, c.entries[i].value)
+	}
+	return values
+}
+
+// Purge is used to completely clear the cache.
+func (c *SieveCache[K, V]) Purge() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for c.tail >= 0 {
+		c.unlink(c.tail)
+	}
+	c.entries = c.entries[:0]
+	c.free = c.free[:0]
+}
+
+// Len returns the number of items in the cache.
+func (c *SieveCache[K, V]) Len() int {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return len(c.items)
+}
+
+// Stats returns a snapshot of the cache's activity counters, for comparing
+// its hit ratio with Cache's.
+func (c *SieveCache[K, V]) Stats() Stats {
+	return c.stats.snapshot()
+}
+
+// ResetStats zeroes the cache's activity counters.
+func (c *SieveCache[K, V]) ResetStats() {
+	c.stats.reset()
+}
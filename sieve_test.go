@@ -0,0 +1,113 @@
+package lru
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestSieve(t *testing.T) {
+	var evicted []int
+	l, err := NewSieveWithEvict[int, int](3, func(key int, value int) {
+		if key != value {
+			t.Errorf("evicted %d with value %d", key, value)
+		}
+		evicted = append(evicted, key)
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 1; i <= 3; i++ {
+		if l.Add(i, i) {
+			t.Errorf("unexpected eviction adding %d", i)
+		}
+	}
+	if _, ok := l.Get(1); !ok {
+		t.Fatalf("1 should be cached")
+	}
+
+	// the hand skips the visited 1, clearing its bit, and evicts 2
+	if !l.Add(4, 4) {
+		t.Errorf("expected an eviction")
+	}
+	if keys := l.Keys(); !reflect.DeepEqual(keys, []int{1, 3, 4}) {
+		t.Errorf("bad keys: %v", keys)
+	}
+	// then carries on from where it stopped
+	l.Add(5, 5)
+	if keys := l.Keys(); !reflect.DeepEqual(keys, []int{1, 4, 5}) {
+		t.Errorf("bad keys: %v", keys)
+	}
+	if !reflect.DeepEqual(evicted, []int{2, 3}) {
+		t.Errorf("bad evictions: %v", evicted)
+	}
+
+	// updates mark entries visited without moving them, so the hand
+	// skips 4 and evicts 5
+	l.Add(4, 4)
+	l.Add(6, 6)
+	if keys := l.Keys(); !reflect.DeepEqual(keys, []int{1, 4, 6}) {
+		t.Errorf("bad keys: %v", keys)
+	}
+	if values := l.Values(); !reflect.DeepEqual(values, []int{1, 4, 6}) {
+		t.Errorf("bad values: %v", values)
+	}
+
+	if _, ok := l.Peek(5); ok || l.Contains(5) {
+		t.Errorf("5 should be evicted")
+	}
+	if !l.Remove(4) || l.Remove(4) || l.Len() != 2 {
+		t.Errorf("bad removal: %v", l.Keys())
+	}
+	l.Add(7, 7)
+	if n := l.Resize(1); n != 2 || l.Len() != 1 {
+		t.Errorf("expected Resize to evict 2, not %d: %v", n, l.Keys())
+	}
+
+	stats := l.Stats()
+	if stats.Hits != 1 || stats.Misses != 0 || stats.Evictions != 5 {
+		t.Errorf("bad stats: %+v", stats)
+	}
+
+	evicted = nil
+	l.Purge()
+	if l.Len() != 0 || len(evicted) != 1 {
+		t.Errorf("expected Purge to evict everything: %v, %v", l.Keys(), evicted)
+	}
+	l.Add(8, 8)
+	if keys := l.Keys(); !reflect.DeepEqual(keys, []int{8}) {
+		t.Errorf("bad keys after Purge: %v", keys)
+	}
+
+	if _, err := NewSieve[int, int](0); err == nil {
+		t.Errorf("expected a non-positive size to be rejected")
+	}
+}
+
+func TestSieveConcurrentGet(t *testing.T) {
+	l, err := NewSieve[int, int](64)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 64; i++ {
+		l.Add(i, i)
+	}
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				if g == 0 {
+					l.Add(64+i, i)
+				} else {
+					l.Get(i % 128)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	if l.Len() != 64 {
+		t.Errorf("expected a full cache: %d", l.Len())
+	}
+}
@@ -0,0 +1,81 @@
+package lru
+
+import (
+	"math/bits"
+	"sync/atomic"
+)
+
+// sketchBuckets is the number of buckets in a costSketch: exact buckets
+// for costs below 8, then 4 buckets per power of two up to the largest
+// int64.
+const sketchBuckets = 248
+
+// costSketch is a log-linear histogram of the costs of a cache's entries,
+// for estimating their quantiles.  Each bucket spans at most a quarter of
+// its lower bound, so estimates are within 12.5% of the true quantile.
+// Counts are updated atomically, so quantiles can be read without taking
+// the cache lock.
+type costSketch struct {
+	counts [sketchBuckets]uint64
+}
+
+// sketchBucket returns the bucket counting cost.
+func sketchBucket(cost int64) int {
+	if cost < 8 {
+		if cost < 0 {
+			return 0
+		}
+		return int(cost)
+	}
+	shift := bits.Len64(uint64(cost)) - 3
+	mantissa := int(cost >> shift) // in [4, 8)
+	return (shift+1)*4 + mantissa - 4
+}
+
+// sketchBucketMid returns the midpoint of the costs counted by bucket.
+func sketchBucketMid(bucket int) int64 {
+	if bucket < 8 {
+		return int64(bucket)
+	}
+	shift := bucket/4 - 1
+	lower := int64(bucket%4+4) << shift
+	return lower + (int64(1)<<shift)/2
+}
+
+func (s *costSketch) add(cost int64) {
+	atomic.AddUint64(&s.counts[sketchBucket(cost)], 1)
+}
+
+func (s *costSketch) remove(cost int64) {
+	atomic.AddUint64(&s.counts[sketchBucket(cost)], ^uint64(0))
+}
+
+// quantiles estimates each of the quantiles qs, or returns zeros if the
+// sketch is empty.
+func (s *costSketch) quantiles(qs ...float64) []int64 {
+	var counts [sketchBuckets]uint64
+	var total uint64
+	for i := range counts {
+		counts[i] = atomic.LoadUint64(&s.counts[i])
+		total += counts[i]
+	}
+
+	estimates := make([]int64, len(qs))
+	if total == 0 {
+		return estimates
+	}
+	for i, q := range qs {
+		rank := uint64(q*float64(total) + 0.5)
+		if rank < 1 {
+			rank = 1
+		}
+		var seen uint64
+		for bucket, n := range counts {
+			if seen += n; seen >= rank {
+				estimates[i] = sketchBucketMid(bucket)
+				break
+			}
+		}
+	}
+	return estimates
+}
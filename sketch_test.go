@@ -0,0 +1,62 @@
+package lru
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCostSketchBuckets(t *testing.T) {
+	prev := 0
+	for _, cost := range []int64{0, 1, 7, 8, 9, 15, 16, 100, 1 << 20, 1<<40 + 12345, math.MaxInt64} {
+		bucket := sketchBucket(cost)
+		if bucket < prev || bucket >= sketchBuckets {
+			t.Fatalf("bad bucket %d for %d", bucket, cost)
+		}
+		prev = bucket
+		mid := sketchBucketMid(bucket)
+		if err := math.Abs(float64(mid-cost)) / math.Max(float64(cost), 1); err > 0.125 {
+			t.Errorf("bucket midpoint %d too far from %d: %.1f%%", mid, cost, err*100)
+		}
+	}
+	if sketchBucket(-5) != 0 {
+		t.Errorf("negative costs should be counted as 0")
+	}
+}
+
+func TestLRUCostQuantiles(t *testing.T) {
+	l, err := NewWithCost[int, []byte](1<<20, func(key int, value []byte) int64 {
+		return int64(len(value))
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if stats := l.Stats(); stats.CostP50 != 0 || stats.CostP99 != 0 {
+		t.Errorf("an empty cache should have no cost quantiles: %+v", stats)
+	}
+
+	for i := 1; i <= 100; i++ {
+		l.Add(i, make([]byte, i*10))
+	}
+	within := func(name string, actual, expected int64) {
+		if math.Abs(float64(actual-expected))/float64(expected) > 0.125 {
+			t.Errorf("%s: expected about %d, not %d", name, expected, actual)
+		}
+	}
+	stats := l.Stats()
+	within("p50", stats.CostP50, 500)
+	within("p90", stats.CostP90, 900)
+	within("p99", stats.CostP99, 990)
+
+	// quantiles follow the entries in the cache
+	for i := 1; i <= 90; i++ {
+		l.Add(i, make([]byte, 10000))
+	}
+	stats = l.Stats()
+	within("p50", stats.CostP50, 10000)
+	for i := 1; i <= 100; i++ {
+		l.Remove(i)
+	}
+	if stats := l.Stats(); stats.CostP50 != 0 {
+		t.Errorf("an empty cache should have no cost quantiles: %+v", stats)
+	}
+}
@@ -0,0 +1,249 @@
+package lru
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// snapshotMagic starts every snapshot, followed by the format version.
+const (
+	snapshotMagic   = "ALRU"
+	snapshotVersion = 1
+)
+
+// maxSnapshotRecord bounds the size of a key or value read from a
+// snapshot, so a corrupt length can't make LoadFrom allocate all of memory.
+const maxSnapshotRecord = 1 << 30
+
+var errBadSnapshot = errors.New("lru: not a cache snapshot")
+
+// snapshotEntry is an entry as saved in a snapshot.
+type snapshotEntry[K comparable, V any] struct {
+	Entry[K, V]
+	// ttl is the entry's remaining time-to-live, or 0 if it never
+	// expires.
+	ttl time.Duration
+	// rank orders entries from least to most recently used.
+	rank int64
+}
+
+// snapshot copies the cache's live entries, in no particular order, along
+// with their remaining time-to-live.  c.lock must be held, but needn't be
+// while sorting the snapshot with sortSnapshot.
+func (c *Cache[K, V]) snapshot() []snapshotEntry[K, V] {
+	ranked := c.rankedEntries()
+	snapshot := make([]snapshotEntry[K, V], len(ranked))
+	var t *ttlState[K]
+	if c.ext != nil {
+		t = c.ext.ttl
+	}
+	var now int64
+	if t != nil {
+		now = t.now().UnixNano()
+	}
+	for i, e := range ranked {
+		snapshot[i].Entry = Entry[K, V]{Key: e.Key, Value: e.Value}
+		snapshot[i].rank = e.Rank
+		if t == nil {
+			continue
+		}
+		if expires, ok := t.expires[e.Key]; ok {
+			if ttl := time.Duration(expires - now); ttl > 0 {
+				snapshot[i].ttl = ttl
+			} else {
+				// stale entries served in degraded mode are
+				// saved as just about to expire
+				snapshot[i].ttl = 1
+			}
+		}
+	}
+	return snapshot
+}
+
+// sortSnapshot sorts a snapshot from least to most recently used.
+func sortSnapshot[K comparable, V any](snapshot []snapshotEntry[K, V]) {
+	sort.Slice(snapshot, func(i, j int) bool {
+		return snapshot[i].rank < snapshot[j].rank
+	})
+}
+
+// SaveTo writes a snapshot of the cache's entries to w, in recency order
+// and along with their remaining time-to-live, using keys and values to
+// serialize them.  Nil codecs default to AutoCodec.  Restore it with
+// LoadFrom or NewFromReader.  The snapshot is consistent as of the call,
+// but the cache lock is only held while copying the entries, which is
+// O(n): they are put in order, encoded and written once it is released.
+func (c *Cache[K, V]) SaveTo(w io.Writer, keys Codec[K], values Codec[V]) error {
+	keys, values = defaultCodecs(keys, values)
+	c.lock.Lock()
+	snapshot := c.snapshot()
+	c.unlock()
+	sortSnapshot(snapshot)
+
+	return writeSnapshot(w, len(snapshot), func(i int) (rec snapshotRecord, err error) {
+		e := snapshot[i]
+		if rec.key, err = keys.Encode(e.Key); err != nil {
+			return rec, fmt.Errorf("lru: encoding key: %w", err)
+		}
+		if rec.value, err = values.Encode(e.Value); err != nil {
+			return rec, fmt.Errorf("lru: encoding value: %w", err)
+		}
+		rec.ttl = e.ttl
+		return rec, nil
+	})
+}
+
+// snapshotRecord is an entry of a snapshot, with its key and value still
+// serialized.
+type snapshotRecord struct {
+	key, value []byte
+	ttl        time.Duration
+}
+
+// writeSnapshot writes a snapshot of n records, produced in order by
+// record, to w.
+func writeSnapshot(w io.Writer, n int, record func(i int) (snapshotRecord, error)) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(snapshotMagic)
+	bw.WriteByte(snapshotVersion)
+	var buf [binary.MaxVarintLen64]byte
+	writeUvarint := func(v uint64) {
+		bw.Write(buf[:binary.PutUvarint(buf[:], v)])
+	}
+	writeBytes := func(data []byte) {
+		writeUvarint(uint64(len(data)))
+		bw.Write(data)
+	}
+
+	writeUvarint(uint64(n))
+	for i := 0; i < n; i++ {
+		rec, err := record(i)
+		if err != nil {
+			return err
+		}
+		writeBytes(rec.key)
+		writeBytes(rec.value)
+		writeUvarint(uint64(rec.ttl))
+	}
+	// bufio.Writer remembers the first error, so checking here covers
+	// every write above.
+	return bw.Flush()
+}
+
+// readSnapshot reads a snapshot written by writeSnapshot from r, calling
+// fn with each record in order.  It stops at the first error fn returns.
+func readSnapshot(r io.Reader, fn func(rec snapshotRecord) error) error {
+	br := bufio.NewReader(r)
+	var header [len(snapshotMagic) + 1]byte
+	if _, err := io.ReadFull(br, header[:]); err != nil {
+		return errBadSnapshot
+	}
+	if string(header[:len(snapshotMagic)]) != snapshotMagic {
+		return errBadSnapshot
+	}
+	if version := header[len(snapshotMagic)]; version != snapshotVersion {
+		return fmt.Errorf("lru: unsupported snapshot version %d", version)
+	}
+	readBytes := func() ([]byte, error) {
+		n, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, err
+		}
+		if n > maxSnapshotRecord {
+			return nil, errBadSnapshot
+		}
+		// read into a growing buffer rather than allocating n bytes up
+		// front, in case the snapshot is truncated
+		var data bytes.Buffer
+		if _, err := io.CopyN(&data, br, int64(n)); err != nil {
+			if err == io.EOF {
+				err = errBadSnapshot
+			}
+			return nil, err
+		}
+		return data.Bytes(), nil
+	}
+
+	n, err := binary.ReadUvarint(br)
+	if err != nil {
+		return fmt.Errorf("lru: reading snapshot: %w", err)
+	}
+	for ; n > 0; n-- {
+		var rec snapshotRecord
+		if rec.key, err = readBytes(); err != nil {
+			return fmt.Errorf("lru: reading snapshot: %w", err)
+		}
+		if rec.value, err = readBytes(); err != nil {
+			return fmt.Errorf("lru: reading snapshot: %w", err)
+		}
+		ttl, err := binary.ReadUvarint(br)
+		if err != nil {
+			return fmt.Errorf("lru: reading snapshot: %w", err)
+		}
+		rec.ttl = time.Duration(ttl)
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// defaultCodecs replaces nil codecs with AutoCodec.
+func defaultCodecs[K, V any](keys Codec[K], values Codec[V]) (Codec[K], Codec[V]) {
+	if keys == nil {
+		keys = AutoCodec[K]{}
+	}
+	if values == nil {
+		values = AutoCodec[V]{}
+	}
+	return keys, values
+}
+
+// NewFromReader creates an LRU of the given size, filled from a snapshot
+// written by SaveTo.
+func NewFromReader[K comparable, V any](size int, r io.Reader, keys Codec[K], values Codec[V]) (*Cache[K, V], error) {
+	c, err := New[K, V](size)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.LoadFrom(r, keys, values); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// LoadFrom adds the entries of a snapshot written by SaveTo to the cache,
+// preserving their recency order and remaining time-to-live.  Like
+// WarmFrom, the eviction callback is not invoked for entries evicted while
+// loading.  Returns the number of entries loaded; on error, the entries
+// read before it stay loaded.
+func (c *Cache[K, V]) LoadFrom(r io.Reader, keys Codec[K], values Codec[V]) (loaded int, err error) {
+	keys, values = defaultCodecs(keys, values)
+	c.lock.Lock()
+	defer c.unlock()
+
+	err = readSnapshot(r, func(rec snapshotRecord) error {
+		key, err := keys.Decode(rec.key)
+		if err != nil {
+			return fmt.Errorf("lru: decoding key: %w", err)
+		}
+		value, err := values.Decode(rec.value)
+		if err != nil {
+			return fmt.Errorf("lru: decoding value: %w", err)
+		}
+
+		c.addWithoutCallback(key, value)
+		if rec.ttl > 0 && c.lru.Contains(key) {
+			c.extension().ttlState().set(key, rec.ttl)
+		}
+		loaded++
+		return nil
+	})
+	return loaded, err
+}
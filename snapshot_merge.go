@@ -0,0 +1,133 @@
+package lru
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// SnapshotDiff describes how the entries of one snapshot differ from those
+// of another.
+type SnapshotDiff[K comparable] struct {
+	// Added holds the keys only in the newer snapshot, in its recency
+	// order, and Removed the keys only in the older one, in its.
+	Added   []K
+	Removed []K
+	// Changed holds the keys in both snapshots whose values differ, in
+	// the newer snapshot's recency order.
+	Changed []K
+}
+
+// DiffSnapshots compares the snapshots older and newer, written by SaveTo,
+// decoding their keys with keys.  Keys and values are compared in their
+// serialized form, so both snapshots must have been written with the same
+// codecs, and those must serialize equal values identically.
+func DiffSnapshots[K comparable](older, newer io.Reader, keys Codec[K]) (diff SnapshotDiff[K], err error) {
+	// the older snapshot's keys, both in order and by their value
+	var order [][]byte
+	values := make(map[string][]byte)
+	err = readSnapshot(older, func(rec snapshotRecord) error {
+		if _, ok := values[string(rec.key)]; !ok {
+			order = append(order, rec.key)
+		}
+		values[string(rec.key)] = rec.value
+		return nil
+	})
+	if err != nil {
+		return diff, err
+	}
+
+	decode := func(data []byte) (K, error) {
+		key, err := keys.Decode(data)
+		if err != nil {
+			return key, fmt.Errorf("lru: decoding key: %w", err)
+		}
+		return key, nil
+	}
+	seen := make(map[string]bool)
+	err = readSnapshot(newer, func(rec snapshotRecord) error {
+		if seen[string(rec.key)] {
+			return nil
+		}
+		seen[string(rec.key)] = true
+		old, ok := values[string(rec.key)]
+		if ok && bytes.Equal(old, rec.value) {
+			return nil
+		}
+		key, err := decode(rec.key)
+		if err != nil {
+			return err
+		}
+		if ok {
+			diff.Changed = append(diff.Changed, key)
+		} else {
+			diff.Added = append(diff.Added, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return diff, err
+	}
+
+	for _, data := range order {
+		if seen[string(data)] {
+			continue
+		}
+		key, err := decode(data)
+		if err != nil {
+			return diff, err
+		}
+		diff.Removed = append(diff.Removed, key)
+	}
+	return diff, nil
+}
+
+// MergeSnapshots merges the snapshots srcs, written by SaveTo, into a
+// single snapshot written to dst, for example to combine the warm entries
+// of several replicas into one snapshot for their replacement.  Snapshots
+// don't record when entries were used, so the merged recency order
+// interleaves the sources by each entry's relative position in its own
+// snapshot.  A key in several snapshots is kept once, with the value and
+// time-to-live of its most recently used copy; ties go to the later
+// source.  Keys are compared in their serialized form, so all the
+// snapshots must have been written with the same key codec.  Load the
+// result into a cache of the desired size to keep only the most recently
+// used entries.
+func MergeSnapshots(dst io.Writer, srcs ...io.Reader) error {
+	type ranked struct {
+		snapshotRecord
+		// rank is the record's position in its snapshot, from 0 for
+		// the least recently used to 1 for the most.
+		rank float64
+	}
+	var records []ranked
+	byKey := make(map[string]int)
+	for _, src := range srcs {
+		var recs []snapshotRecord
+		if err := readSnapshot(src, func(rec snapshotRecord) error {
+			recs = append(recs, rec)
+			return nil
+		}); err != nil {
+			return err
+		}
+		for i, rec := range recs {
+			r := ranked{rec, float64(i+1) / float64(len(recs))}
+			if j, ok := byKey[string(rec.key)]; ok {
+				if records[j].rank <= r.rank {
+					records[j] = r
+				}
+				continue
+			}
+			byKey[string(rec.key)] = len(records)
+			records = append(records, r)
+		}
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].rank < records[j].rank
+	})
+	return writeSnapshot(dst, len(records), func(i int) (snapshotRecord, error) {
+		return records[i].snapshotRecord, nil
+	})
+}
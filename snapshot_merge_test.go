@@ -0,0 +1,66 @@
+package lru
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func snapshotOf(t *testing.T, entries ...Entry[string, int]) *bytes.Buffer {
+	t.Helper()
+	l, err := New[string, int](0)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for _, e := range entries {
+		l.Add(e.Key, e.Value)
+	}
+	var buf bytes.Buffer
+	if err := l.SaveTo(&buf, JSONCodec[string]{}, JSONCodec[int]{}); err != nil {
+		t.Fatalf("SaveTo: %v", err)
+	}
+	return &buf
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	older := snapshotOf(t, Entry[string, int]{"1", 1}, Entry[string, int]{"2", 2}, Entry[string, int]{"3", 3})
+	newer := snapshotOf(t, Entry[string, int]{"4", 4}, Entry[string, int]{"2", 2}, Entry[string, int]{"3", 30})
+
+	diff, err := DiffSnapshots[string](older, newer, JSONCodec[string]{})
+	if err != nil {
+		t.Fatalf("DiffSnapshots: %v", err)
+	}
+	expected := SnapshotDiff[string]{
+		Added:   []string{"4"},
+		Removed: []string{"1"},
+		Changed: []string{"3"},
+	}
+	if !reflect.DeepEqual(diff, expected) {
+		t.Errorf("expected %+v, not %+v", expected, diff)
+	}
+
+	if _, err := DiffSnapshots[string](bytes.NewReader([]byte("nope")), newer, JSONCodec[string]{}); err == nil {
+		t.Errorf("expected a bad snapshot to be rejected")
+	}
+}
+
+func TestMergeSnapshots(t *testing.T) {
+	a := snapshotOf(t, Entry[string, int]{"a1", 1}, Entry[string, int]{"a2", 2}, Entry[string, int]{"shared", 1})
+	b := snapshotOf(t, Entry[string, int]{"shared", 2}, Entry[string, int]{"b1", 1})
+
+	var merged bytes.Buffer
+	if err := MergeSnapshots(&merged, a, b); err != nil {
+		t.Fatalf("MergeSnapshots: %v", err)
+	}
+	l, err := NewFromReader[string, int](0, &merged, JSONCodec[string]{}, JSONCodec[int]{})
+	if err != nil {
+		t.Fatalf("NewFromReader: %v", err)
+	}
+	// ranks: a1 1/3, a2 2/3, shared 1 in a and 1/2 in b, b1 1
+	if keys := l.Keys(); !reflect.DeepEqual(keys, []string{"a1", "a2", "shared", "b1"}) {
+		t.Errorf("bad merged order: %v", keys)
+	}
+	if v, _ := l.Peek("shared"); v != 1 {
+		t.Errorf("expected the most recently used copy to win: %v", v)
+	}
+}
@@ -0,0 +1,197 @@
+package lru
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLRUSnapshot(t *testing.T) {
+	l, err := New[string, []int](8)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add("1", []int{1})
+	l.AddWithTTL("2", []int{2, 2}, time.Hour)
+	l.Add("3", []int{3, 3, 3})
+	l.Get("1")
+
+	var buf bytes.Buffer
+	if err := l.SaveTo(&buf, JSONCodec[string]{}, GobCodec[[]int]{}); err != nil {
+		t.Fatalf("SaveTo: %v", err)
+	}
+
+	restored, err := NewFromReader[string, []int](8, bytes.NewReader(buf.Bytes()), JSONCodec[string]{}, GobCodec[[]int]{})
+	if err != nil {
+		t.Fatalf("NewFromReader: %v", err)
+	}
+	if keys := restored.Keys(); !reflect.DeepEqual(keys, []string{"2", "3", "1"}) {
+		t.Errorf("expected recency order to be preserved: %v", keys)
+	}
+	if values := restored.Values(); !reflect.DeepEqual(values, [][]int{{2, 2}, {3, 3, 3}, {1}}) {
+		t.Errorf("bad values: %v", values)
+	}
+	ttl := restored.ext.ttl
+	if remaining := time.Until(time.Unix(0, ttl.expires["2"])); remaining < 59*time.Minute || remaining > time.Hour {
+		t.Errorf("expected the remaining TTL to be preserved: %v", remaining)
+	}
+	if _, ok := ttl.expires["1"]; ok {
+		t.Errorf("1 shouldn't expire")
+	}
+
+	// a smaller cache keeps the most recently used entries
+	small, err := NewFromReader[string, []int](2, bytes.NewReader(buf.Bytes()), JSONCodec[string]{}, GobCodec[[]int]{})
+	if err != nil {
+		t.Fatalf("NewFromReader: %v", err)
+	}
+	if keys := small.Keys(); !reflect.DeepEqual(keys, []string{"3", "1"}) {
+		t.Errorf("bad keys: %v", keys)
+	}
+
+	if _, err := NewFromReader[string, []int](8, strings.NewReader("nope"), JSONCodec[string]{}, GobCodec[[]int]{}); err == nil {
+		t.Errorf("expected a bad snapshot to be rejected")
+	}
+	truncated := buf.Bytes()[:buf.Len()-3]
+	if _, err := NewFromReader[string, []int](8, bytes.NewReader(truncated), JSONCodec[string]{}, GobCodec[[]int]{}); err == nil {
+		t.Errorf("expected a truncated snapshot to be rejected")
+	}
+
+	// a record claiming to be enormous is rejected without allocating it
+	corrupt := []byte("ALRU\x01\x01\xff\xff\xff\xff\xff\xff\xff\xff\x7f")
+	if _, err := NewFromReader[string, []int](8, bytes.NewReader(corrupt), JSONCodec[string]{}, GobCodec[[]int]{}); !errors.Is(err, errBadSnapshot) {
+		t.Errorf("expected a corrupt snapshot to be rejected: %v", err)
+	}
+	corrupt = []byte("ALRU\x01\x01\xff\xff\xff\x03")
+	if _, err := NewFromReader[string, []int](8, bytes.NewReader(corrupt), JSONCodec[string]{}, GobCodec[[]int]{}); !errors.Is(err, errBadSnapshot) {
+		t.Errorf("expected a truncated record to be rejected: %v", err)
+	}
+}
+
+// point serializes itself as text, through a pointer receiver.
+type point struct{ x, y int }
+
+func (p *point) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("%d,%d", p.x, p.y)), nil
+}
+
+func (p *point) UnmarshalText(text []byte) error {
+	_, err := fmt.Sscanf(string(text), "%d,%d", &p.x, &p.y)
+	return err
+}
+
+func TestLRUSnapshotAutoCodec(t *testing.T) {
+	l, err := New[time.Time, *point](8)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	noon := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	l.Add(noon, &point{1, 2})
+	l.Add(noon.Add(time.Hour), &point{3, 4})
+
+	var buf bytes.Buffer
+	if err := l.SaveTo(&buf, nil, nil); err != nil {
+		t.Fatalf("SaveTo: %v", err)
+	}
+	restored, err := NewFromReader[time.Time, *point](8, &buf, nil, nil)
+	if err != nil {
+		t.Fatalf("NewFromReader: %v", err)
+	}
+	if p, ok := restored.Get(noon); !ok || *p != (point{1, 2}) {
+		t.Errorf("bad value: %v, %v", p, ok)
+	}
+	if p, ok := restored.Get(noon.Add(time.Hour)); !ok || *p != (point{3, 4}) {
+		t.Errorf("bad value: %v, %v", p, ok)
+	}
+
+	// time.Time uses MarshalBinary, point MarshalText, and []int gob
+	if data, _ := (AutoCodec[time.Time]{}).Encode(noon); data[0] != autoBinary {
+		t.Errorf("expected MarshalBinary to be used: %q", data)
+	}
+	if data, _ := (AutoCodec[point]{}).Encode(point{5, 6}); string(data) != "t5,6" {
+		t.Errorf("expected MarshalText to be used: %q", data)
+	}
+	data, err := AutoCodec[[]int]{}.Encode([]int{7})
+	if err != nil || data[0] != autoGob {
+		t.Fatalf("expected gob to be used: %q, %v", data, err)
+	}
+	if v, err := (AutoCodec[[]int]{}).Decode(data); err != nil || !reflect.DeepEqual(v, []int{7}) {
+		t.Errorf("bad gob round trip: %v, %v", v, err)
+	}
+	if _, err := (AutoCodec[time.Time]{}).Decode(data); err == nil {
+		t.Errorf("expected a mismatched encoding to be rejected")
+	}
+}
+
+// lockCheckCodec is a Codec checking that the cache lock isn't held while
+// encoding.
+type lockCheckCodec struct {
+	t *testing.T
+	l *Cache[string, int]
+}
+
+func (c lockCheckCodec) Encode(value int) ([]byte, error) {
+	if !c.l.lock.TryLock() {
+		c.t.Errorf("the cache lock shouldn't be held while encoding")
+	} else {
+		c.l.lock.Unlock()
+	}
+	return JSONCodec[int]{}.Encode(value)
+}
+
+func (c lockCheckCodec) Decode(data []byte) (int, error) {
+	return JSONCodec[int]{}.Decode(data)
+}
+
+func TestLRUSnapshotUnlocked(t *testing.T) {
+	l, err := New[string, int](128)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 100; i++ {
+		l.Add(fmt.Sprint(i), i)
+	}
+	l.Get("0")
+
+	var buf bytes.Buffer
+	if err := l.SaveTo(&buf, nil, lockCheckCodec{t, l}); err != nil {
+		t.Fatalf("SaveTo: %v", err)
+	}
+	restored, err := NewFromReader[string, int](128, &buf, nil, lockCheckCodec{t, l})
+	if err != nil {
+		t.Fatalf("NewFromReader: %v", err)
+	}
+	if !reflect.DeepEqual(restored.Keys(), l.Keys()) {
+		t.Errorf("expected recency order to be preserved: %v", restored.Keys())
+	}
+}
+
+func TestLRUSnapshotAutoCodecInterface(t *testing.T) {
+	gob.Register(time.Time{})
+	l, err := New[string, any](8)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	noon := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	l.Add("time", noon)
+	l.Add("int", 1)
+
+	var buf bytes.Buffer
+	if err := l.SaveTo(&buf, nil, nil); err != nil {
+		t.Fatalf("SaveTo: %v", err)
+	}
+	restored, err := NewFromReader[string, any](8, &buf, nil, nil)
+	if err != nil {
+		t.Fatalf("NewFromReader: %v", err)
+	}
+	if v, ok := restored.Get("time"); !ok || !v.(time.Time).Equal(noon) {
+		t.Errorf("bad value: %v, %v", v, ok)
+	}
+	if v, ok := restored.Get("int"); !ok || v != 1 {
+		t.Errorf("bad value: %v, %v", v, ok)
+	}
+}
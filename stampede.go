@@ -0,0 +1,86 @@
+package lru
+
+import (
+	"time"
+)
+
+// loadRateWindow is the period over which StampedeThreshold counts loads.
+const loadRateWindow = time.Minute
+
+type loadRate struct {
+	start time.Time
+	loads int
+}
+
+// loadRates counts the loads of each key in the current one-minute window,
+// and reports keys loaded more than threshold times in one.
+type loadRates[K comparable] struct {
+	threshold   int
+	onStampede  func(key K, loads int)
+	rates       map[K]*loadRate
+	lastPruneAt int
+	now         func() time.Time
+}
+
+func newLoadRates[K comparable](threshold int, onStampede func(K, int)) *loadRates[K] {
+	return &loadRates[K]{
+		threshold:  threshold,
+		onStampede: onStampede,
+		rates:      make(map[K]*loadRate),
+		now:        time.Now,
+	}
+}
+
+// loading counts a load of key, reporting it the first time the key's
+// loads in a window exceed the threshold.
+func (r *loadRates[K]) loading(key K) {
+	now := r.now()
+	rate, ok := r.rates[key]
+	if !ok {
+		r.prune(now)
+		rate = &loadRate{start: now}
+		r.rates[key] = rate
+	} else if now.Sub(rate.start) >= loadRateWindow {
+		*rate = loadRate{start: now}
+	}
+	rate.loads++
+	if rate.loads == r.threshold+1 && r.onStampede != nil {
+		r.onStampede(key, rate.loads)
+	}
+}
+
+// rate returns the number of loads of key in its current window.
+func (r *loadRates[K]) rate(key K) int {
+	rate, ok := r.rates[key]
+	if !ok || r.now().Sub(rate.start) >= loadRateWindow {
+		return 0
+	}
+	return rate.loads
+}
+
+// prune forgets the keys whose windows have ended, once the number of keys
+// has doubled since the last prune, so it is amortized over their loads.
+func (r *loadRates[K]) prune(now time.Time) {
+	if len(r.rates) < 2*r.lastPruneAt+16 {
+		return
+	}
+	for key, rate := range r.rates {
+		if now.Sub(rate.start) >= loadRateWindow {
+			delete(r.rates, key)
+		}
+	}
+	r.lastPruneAt = len(r.rates)
+}
+
+// LoadRate returns the number of times GetOrLoad has called a loader for
+// key in the current minute.  It is only counted for caches with
+// Options.StampedeThreshold, and is 0 for others.
+func (c *Cache[K, V]) LoadRate(key K) int {
+	c.lock.Lock()
+	defer c.unlock()
+
+	if c.ext == nil || c.ext.loadRates == nil {
+		return 0
+	}
+	return c.ext.loadRates.rate(key)
+}
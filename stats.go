@@ -0,0 +1,237 @@
+package lru
+
+import (
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+// Stats is a snapshot of a cache's activity counters.
+type Stats struct {
+	// Hits and Misses count lookups that found or didn't find their key.
+	// Peek and Contains aren't counted.
+	Hits   uint64
+	Misses uint64
+	// Adds counts adds, including those replacing an existing value.
+	Adds uint64
+	// Evictions counts entries evicted to make room for others, or by
+	// shrinking the cache.
+	Evictions uint64
+	// Expirations counts entries removed for outliving their TTL.
+	Expirations uint64
+	// Rejected counts adds rejected by Options.TinyLFU.
+	Rejected uint64
+	// Invalid counts entries removed for failing Options.Validate.
+	Invalid uint64
+	// Collapses counts the lookup windows in which the hit ratio of a
+	// cache with Options.CardinalityWindow set collapsed.
+	Collapses uint64
+	// Loads counts calls to GetOrLoad's loader, and LoadTime is the total
+	// time spent in them.
+	Loads    uint64
+	LoadTime time.Duration
+	// LoadWaiters counts the callers that waited on a load started by
+	// another caller rather than calling loader themselves, and
+	// MaxLoadWaiters is the most that waited on a single load.  Many
+	// waiters per load are a sign of a stampede on a hot key.
+	LoadWaiters    uint64
+	MaxLoadWaiters uint64
+
+	// Live is the number of entries in the cache that haven't expired, as
+	// returned by LenLive.  Unlike the counters, it isn't zeroed by
+	// ResetStats.
+	Live uint64
+
+	// CostP50, CostP90 and CostP99 estimate quantiles of the costs of the
+	// entries currently in a cache bounded by cost, to within 12.5%.  They
+	// are zero for other caches.
+	CostP50 int64
+	CostP90 int64
+	CostP99 int64
+}
+
+// HitRatio returns the fraction of lookups that were hits, or 0 if there
+// haven't been any.
+func (s Stats) HitRatio() float64 {
+	lookups := s.Hits + s.Misses
+	if lookups == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(lookups)
+}
+
+// MeanLoadTime returns the average time spent loading a value, or 0 if
+// nothing has been loaded.
+func (s Stats) MeanLoadTime() time.Duration {
+	if s.Loads == 0 {
+		return 0
+	}
+	return s.LoadTime / time.Duration(s.Loads)
+}
+
+// MeanLoadWaiters returns the average number of callers that waited on
+// each load, or 0 if nothing has been loaded.
+func (s Stats) MeanLoadWaiters() float64 {
+	if s.Loads == 0 {
+		return 0
+	}
+	return float64(s.LoadWaiters) / float64(s.Loads)
+}
+
+// statsCounters holds a cache's activity counters.  They are updated with
+// atomic increments, so Stats can read them without taking the cache lock.
+type statsCounters struct {
+	hits        uint64
+	misses      uint64
+	adds        uint64
+	evictions   uint64
+	expirations uint64
+	invalid     uint64
+	rejected    uint64
+	loads       uint64
+	loadNanos   uint64
+	loadWaiters uint64
+	maxWaiters  uint64
+	collapses   uint64
+	// costs is set for caches bounded by cost.
+	costs *costSketch
+	// keep the counters of different shards on different cache lines
+	_ [statsPadding]byte
+}
+
+const statsPadding = (cacheLineSize - (12*unsafe.Sizeof(uint64(0))+unsafe.Sizeof(uintptr(0)))%cacheLineSize) % cacheLineSize
+
+func (s *statsCounters) lookup(ok bool) {
+	if ok {
+		atomic.AddUint64(&s.hits, 1)
+	} else {
+		atomic.AddUint64(&s.misses, 1)
+	}
+}
+
+func (s *statsCounters) added(evicted bool) {
+	atomic.AddUint64(&s.adds, 1)
+	if evicted {
+		atomic.AddUint64(&s.evictions, 1)
+	}
+}
+
+func (s *statsCounters) evicted(n int) {
+	atomic.AddUint64(&s.evictions, uint64(n))
+}
+
+func (s *statsCounters) expired(n int) {
+	atomic.AddUint64(&s.expirations, uint64(n))
+}
+
+func (s *statsCounters) rejectedAdd() {
+	atomic.AddUint64(&s.rejected, 1)
+}
+
+func (s *statsCounters) invalidated() {
+	atomic.AddUint64(&s.invalid, 1)
+}
+
+func (s *statsCounters) collapsed() {
+	atomic.AddUint64(&s.collapses, 1)
+}
+
+func (s *statsCounters) loaded(d time.Duration) {
+	atomic.AddUint64(&s.loads, 1)
+	atomic.AddUint64(&s.loadNanos, uint64(d))
+}
+
+func (s *statsCounters) loadWaited(waiters int) {
+	if waiters == 0 {
+		return
+	}
+	atomic.AddUint64(&s.loadWaiters, uint64(waiters))
+	for {
+		max := atomic.LoadUint64(&s.maxWaiters)
+		if uint64(waiters) <= max || atomic.CompareAndSwapUint64(&s.maxWaiters, max, uint64(waiters)) {
+			return
+		}
+	}
+}
+
+func (s *statsCounters) snapshot() Stats {
+	stats := Stats{
+		Hits:           atomic.LoadUint64(&s.hits),
+		Misses:         atomic.LoadUint64(&s.misses),
+		Adds:           atomic.LoadUint64(&s.adds),
+		Evictions:      atomic.LoadUint64(&s.evictions),
+		Expirations:    atomic.LoadUint64(&s.expirations),
+		Rejected:       atomic.LoadUint64(&s.rejected),
+		Invalid:        atomic.LoadUint64(&s.invalid),
+		Collapses:      atomic.LoadUint64(&s.collapses),
+		Loads:          atomic.LoadUint64(&s.loads),
+		LoadTime:       time.Duration(atomic.LoadUint64(&s.loadNanos)),
+		LoadWaiters:    atomic.LoadUint64(&s.loadWaiters),
+		MaxLoadWaiters: atomic.LoadUint64(&s.maxWaiters),
+	}
+	if s.costs != nil {
+		q := s.costs.quantiles(0.50, 0.90, 0.99)
+		stats.CostP50, stats.CostP90, stats.CostP99 = q[0], q[1], q[2]
+	}
+	return stats
+}
+
+func (s *statsCounters) reset() {
+	atomic.StoreUint64(&s.hits, 0)
+	atomic.StoreUint64(&s.misses, 0)
+	atomic.StoreUint64(&s.adds, 0)
+	atomic.StoreUint64(&s.evictions, 0)
+	atomic.StoreUint64(&s.expirations, 0)
+	atomic.StoreUint64(&s.rejected, 0)
+	atomic.StoreUint64(&s.invalid, 0)
+	atomic.StoreUint64(&s.collapses, 0)
+	atomic.StoreUint64(&s.loads, 0)
+	atomic.StoreUint64(&s.loadNanos, 0)
+	atomic.StoreUint64(&s.loadWaiters, 0)
+	atomic.StoreUint64(&s.maxWaiters, 0)
+}
+
+// Stats returns a snapshot of the cache's activity counters.  The counters
+// are read without the cache lock, which is only taken briefly to count
+// live entries.
+func (c *Cache[K, V]) Stats() Stats {
+	stats := c.stats.snapshot()
+	c.lock.Lock()
+	stats.Live = uint64(c.lenLive())
+	c.unlock()
+	return stats
+}
+
+// ResetStats zeroes the cache's activity counters, including those behind
+// the hit ratio curve.  Cost quantiles describe the entries currently in
+// the cache, so they aren't reset.
+func (c *Cache[K, V]) ResetStats() {
+	c.stats.reset()
+	c.lock.Lock()
+	if c.ext != nil && c.ext.curve != nil {
+		c.ext.curve.reset()
+	}
+	c.unlock()
+}
+
+// Stats returns a snapshot of the cache's activity counters, summed across
+// shards.  Shards are read one at a time, so the snapshot isn't atomic.
+func (c *ShardedCache[V]) Stats() (stats Stats) {
+	for i := range c.shards {
+		s := c.shards[i].stats.snapshot()
+		stats.Hits += s.Hits
+		stats.Misses += s.Misses
+		stats.Adds += s.Adds
+		stats.Evictions += s.Evictions
+		stats.Expirations += s.Expirations
+	}
+	stats.Live = uint64(c.Len())
+	return stats
+}
+
+// ResetStats zeroes the cache's activity counters.
+func (c *ShardedCache[V]) ResetStats() {
+	for i := range c.shards {
+		c.shards[i].stats.reset()
+	}
+}
@@ -0,0 +1,66 @@
+package lru
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestLRUStats(t *testing.T) {
+	now := time.Unix(1000, 0)
+	l, err := NewWithOptions[int, int](2, Options[int, int]{TTL: time.Minute})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.ext.ttl.now = func() time.Time { return now }
+
+	l.Add(1, 1)
+	l.Add(2, 2)
+	l.Add(2, 2)
+	l.Add(3, 3) // evicts 1
+	l.Get(2)
+	l.Get(1)
+	l.Peek(3)
+	l.Resize(1) // evicts 3
+	now = now.Add(2 * time.Minute)
+	l.Get(2) // expired
+
+	expected := Stats{Hits: 1, Misses: 2, Adds: 4, Evictions: 2, Expirations: 1}
+	if stats := l.Stats(); stats != expected {
+		t.Errorf("expected %+v, not %+v", expected, stats)
+	}
+	if ratio := l.Stats().HitRatio(); ratio != 1.0/3 {
+		t.Errorf("bad hit ratio: %v", ratio)
+	}
+
+	l.ResetStats()
+	if stats := l.Stats(); stats != (Stats{}) {
+		t.Errorf("expected stats to be reset: %+v", stats)
+	}
+	if ratio := l.Stats().HitRatio(); ratio != 0 {
+		t.Errorf("bad hit ratio: %v", ratio)
+	}
+}
+
+func TestShardedStats(t *testing.T) {
+	l, err := NewSharded[int](256, 4)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for i := 0; i < 16; i++ {
+		l.Add(strconv.Itoa(i), i)
+	}
+	for i := 0; i < 32; i++ {
+		l.Get(strconv.Itoa(i))
+	}
+	expected := Stats{Hits: 16, Misses: 16, Adds: 16, Live: 16}
+	if stats := l.Stats(); stats != expected {
+		t.Errorf("expected %+v, not %+v", expected, stats)
+	}
+
+	l.ResetStats()
+	if stats := l.Stats(); stats != (Stats{Live: 16}) {
+		t.Errorf("expected stats to be reset: %+v", stats)
+	}
+}
@@ -0,0 +1,95 @@
+package lru
+
+// tagIndex maps tags to the keys carrying them, and back.
+type tagIndex[K comparable] struct {
+	byTag map[string]map[K]struct{}
+	byKey map[K][]string
+}
+
+func newTagIndex[K comparable]() *tagIndex[K] {
+	return &tagIndex[K]{
+		byTag: make(map[string]map[K]struct{}),
+		byKey: make(map[K][]string),
+	}
+}
+
+// set replaces the tags of key.
+func (t *tagIndex[K]) set(key K, tags []string) {
+	t.remove(key)
+	if len(tags) == 0 {
+		return
+	}
+
+	t.byKey[key] = append([]string(nil), tags...)
+	for _, tag := range tags {
+		keys, ok := t.byTag[tag]
+		if !ok {
+			keys = make(map[K]struct{})
+			t.byTag[tag] = keys
+		}
+		keys[key] = struct{}{}
+	}
+}
+
+// remove drops key from the index.
+func (t *tagIndex[K]) remove(key K) {
+	tags, ok := t.byKey[key]
+	if !ok {
+		return
+	}
+	delete(t.byKey, key)
+	for _, tag := range tags {
+		keys := t.byTag[tag]
+		delete(keys, key)
+		if len(keys) == 0 {
+			delete(t.byTag, tag)
+		}
+	}
+}
+
+// keys returns the keys carrying tag.
+func (t *tagIndex[K]) keys(tag string) []K {
+	tagged := t.byTag[tag]
+	keys := make([]K, 0, len(tagged))
+	for key := range tagged {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// AddWithTags adds a value to the cache, tagged with each of tags so it can
+// later be removed with InvalidateTag.  The tags replace any the key
+// previously had; a plain Add of an existing key keeps its tags.  Returns
+// true if an eviction occurred.
+func (c *Cache[K, V]) AddWithTags(key K, value V, tags ...string) (evicted bool) {
+	c.lock.Lock()
+	defer c.unlock()
+
+	e := c.extension()
+	if e.tags == nil {
+		e.tags = newTagIndex[K]()
+	}
+	evicted = c.add(key, value)
+	// the add may have been dropped, e.g. by a tombstone
+	if c.lru.Contains(key) {
+		e.tags.set(key, tags)
+	}
+	return evicted
+}
+
+// InvalidateTag removes every entry tagged with tag, returning the number
+// of entries removed.
+func (c *Cache[K, V]) InvalidateTag(tag string) (removed int) {
+	c.lock.Lock()
+	defer c.unlock()
+
+	if c.ext == nil || c.ext.tags == nil {
+		return 0
+	}
+	for _, key := range c.ext.tags.keys(tag) {
+		if _, ok := c.remove(key); ok {
+			removed++
+		}
+	}
+	return removed
+}
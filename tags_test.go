@@ -0,0 +1,54 @@
+package lru
+
+import (
+	"testing"
+)
+
+func TestLRUInvalidateTag(t *testing.T) {
+	evictCounter := 0
+	l, err := NewWithEvict[string, int](3, func(k string, v int) {
+		evictCounter++
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.AddWithTags("1", 1, "odd", "all")
+	l.AddWithTags("2", 2, "even", "all")
+	l.Add("3", 3)
+
+	if removed := l.InvalidateTag("odd"); removed != 1 {
+		t.Errorf("expected 1 entry to be removed, not %d", removed)
+	}
+	if l.Contains("1") || !l.Contains("2") || !l.Contains("3") {
+		t.Errorf("only 1 should have been removed")
+	}
+	if evictCounter != 1 {
+		t.Errorf("onEvicted should have been called 1 time: %v", evictCounter)
+	}
+
+	// retagging replaces the old tags, and a plain Add keeps them
+	l.AddWithTags("2", 2, "even")
+	l.Add("2", 20)
+	if removed := l.InvalidateTag("all"); removed != 0 {
+		t.Errorf("2 should no longer be tagged all: %d", removed)
+	}
+	if removed := l.InvalidateTag("even"); removed != 1 {
+		t.Errorf("expected 1 entry to be removed, not %d", removed)
+	}
+
+	// evicted entries leave the index
+	l.AddWithTags("4", 4, "t")
+	l.Add("5", 5)
+	l.Add("6", 6)
+	l.Add("7", 7)
+	if l.Contains("4") {
+		t.Fatalf("4 should have been evicted")
+	}
+	if len(l.ext.tags.byKey) != 0 || len(l.ext.tags.byTag) != 0 {
+		t.Errorf("tag index should be empty: %v", l.ext.tags.byKey)
+	}
+	if removed := l.InvalidateTag("missing"); removed != 0 {
+		t.Errorf("unknown tags shouldn't remove anything: %d", removed)
+	}
+}
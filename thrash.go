@@ -0,0 +1,65 @@
+package lru
+
+import (
+	"time"
+)
+
+type thrashGhost[K comparable] struct {
+	key K
+	at  time.Time
+}
+
+// thrashDetector remembers recently evicted keys in a fixed-size ring, and
+// reports keys that are added back shortly after being evicted.
+type thrashDetector[K comparable] struct {
+	window   time.Duration
+	onThrash func(key K, evictedAgo time.Duration)
+	ghosts   map[K]time.Time
+	ring     []thrashGhost[K]
+	next     int
+}
+
+func newThrashDetector[K comparable](size int, window time.Duration, onThrash func(K, time.Duration)) *thrashDetector[K] {
+	return &thrashDetector[K]{
+		window:   window,
+		onThrash: onThrash,
+		ghosts:   make(map[K]time.Time, size),
+		ring:     make([]thrashGhost[K], size),
+	}
+}
+
+// evicted records that key was evicted to make room for another entry.
+func (d *thrashDetector[K]) evicted(key K) {
+	// caches created unbounded have no room to remember ghosts in
+	if len(d.ring) == 0 {
+		return
+	}
+
+	// forget the oldest ghost, unless its key has been evicted again since
+	// and is now tracked by a newer slot in the ring.
+	if old := &d.ring[d.next]; !old.at.IsZero() {
+		if at, ok := d.ghosts[old.key]; ok && at.Equal(old.at) {
+			delete(d.ghosts, old.key)
+		}
+	}
+
+	now := time.Now()
+	d.ring[d.next] = thrashGhost[K]{key, now}
+	d.ghosts[key] = now
+	d.next++
+	if d.next == len(d.ring) {
+		d.next = 0
+	}
+}
+
+// added checks whether a key newly added to the cache was recently evicted.
+func (d *thrashDetector[K]) added(key K) {
+	at, ok := d.ghosts[key]
+	if !ok {
+		return
+	}
+	delete(d.ghosts, key)
+	if ago := time.Since(at); ago <= d.window {
+		d.onThrash(key, ago)
+	}
+}
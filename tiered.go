@@ -0,0 +1,151 @@
+package lru
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Backend is a shared second-level store, such as Redis or memcached,
+// behind a Tiered cache.  Values are stored serialized.  Get reports
+// whether the key was found; a missing key isn't an error.
+type Backend[K comparable] interface {
+	Get(ctx context.Context, key K) (value []byte, ok bool, err error)
+	Set(ctx context.Context, key K, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, key K) error
+}
+
+// Tiered is an in-process Cache in front of a Backend.  Lookups that miss
+// the local cache fall back to the backend, filling the local cache with
+// what they find, and adds and removes write through to the backend.
+// Local entries are only as fresh as the local cache's TTL allows: changes
+// other processes make to the backend aren't seen until they expire or are
+// evicted locally.
+type Tiered[K comparable, V any] struct {
+	local   *Cache[K, V]
+	backend Backend[K]
+	codec   Codec[V]
+	ttl     time.Duration
+
+	backendHits   uint64
+	backendMisses uint64
+	backendWrites uint64
+	backendErrors uint64
+}
+
+// TieredStats is a snapshot of a Tiered cache's activity counters, per
+// tier.
+type TieredStats struct {
+	// Local holds the local cache's counters.  Its misses are the lookups
+	// that fell back to the backend.
+	Local Stats
+	// BackendHits and BackendMisses count backend lookups that found or
+	// didn't find their key.
+	BackendHits   uint64
+	BackendMisses uint64
+	// BackendWrites counts the sets and deletes written through to the
+	// backend.
+	BackendWrites uint64
+	// BackendErrors counts backend calls that failed, including values
+	// that failed to decode.
+	BackendErrors uint64
+}
+
+// NewTiered creates a Tiered cache from a local cache and a backend, using
+// codec to serialize values for the backend; a nil codec means AutoCodec.
+// Values added are stored in both tiers with the given ttl, where a
+// non-positive ttl means they never expire.
+func NewTiered[K comparable, V any](local *Cache[K, V], backend Backend[K], codec Codec[V], ttl time.Duration) (*Tiered[K, V], error) {
+	if local == nil || backend == nil {
+		return nil, errors.New("must provide a local cache and a backend")
+	}
+	if codec == nil {
+		codec = AutoCodec[V]{}
+	}
+	return &Tiered[K, V]{
+		local:   local,
+		backend: backend,
+		codec:   codec,
+		ttl:     ttl,
+	}, nil
+}
+
+// Get looks up a key's value, first in the local cache, and then in the
+// backend.  Values found in the backend are added to the local cache.
+func (t *Tiered[K, V]) Get(ctx context.Context, key K) (value V, ok bool, err error) {
+	if value, ok, err = t.local.GetCtx(ctx, key); ok || err != nil {
+		return value, ok, err
+	}
+
+	data, ok, err := t.backend.Get(ctx, key)
+	if err != nil {
+		atomic.AddUint64(&t.backendErrors, 1)
+		return value, false, err
+	}
+	if !ok {
+		atomic.AddUint64(&t.backendMisses, 1)
+		return value, false, nil
+	}
+	atomic.AddUint64(&t.backendHits, 1)
+	if value, err = t.codec.Decode(data); err != nil {
+		atomic.AddUint64(&t.backendErrors, 1)
+		return value, false, fmt.Errorf("lru: decoding value: %w", err)
+	}
+	t.local.AddWithTTL(key, value, t.ttl)
+	return value, true, nil
+}
+
+// Add writes a value through to the backend, and then adds it to the local
+// cache.  If the backend write fails, the local cache is left unchanged.
+func (t *Tiered[K, V]) Add(ctx context.Context, key K, value V) error {
+	data, err := t.codec.Encode(value)
+	if err != nil {
+		return fmt.Errorf("lru: encoding value: %w", err)
+	}
+	if err := t.write(t.backend.Set(ctx, key, data, t.ttl)); err != nil {
+		return err
+	}
+	t.local.AddWithTTL(key, value, t.ttl)
+	return nil
+}
+
+// Remove deletes a key from the backend, and then removes it from the
+// local cache, even if the backend delete fails.  Deleting from the
+// backend first keeps a concurrent Get from refilling the local cache
+// with the value from the backend after it has been removed locally.
+// That still leaves a window in which a Get that read the backend before
+// the delete adds the old value back after the local remove; give the
+// local cache an Options.TombstoneTTL to drop such adds.
+func (t *Tiered[K, V]) Remove(ctx context.Context, key K) error {
+	err := t.write(t.backend.Delete(ctx, key))
+	t.local.Remove(key)
+	return err
+}
+
+// write counts a write through to the backend, returning its error.
+func (t *Tiered[K, V]) write(err error) error {
+	atomic.AddUint64(&t.backendWrites, 1)
+	if err != nil {
+		atomic.AddUint64(&t.backendErrors, 1)
+	}
+	return err
+}
+
+// Local returns the local cache, for example to purge it without touching
+// the backend.
+func (t *Tiered[K, V]) Local() *Cache[K, V] {
+	return t.local
+}
+
+// Stats returns a snapshot of the cache's activity counters.
+func (t *Tiered[K, V]) Stats() TieredStats {
+	return TieredStats{
+		Local:         t.local.Stats(),
+		BackendHits:   atomic.LoadUint64(&t.backendHits),
+		BackendMisses: atomic.LoadUint64(&t.backendMisses),
+		BackendWrites: atomic.LoadUint64(&t.backendWrites),
+		BackendErrors: atomic.LoadUint64(&t.backendErrors),
+	}
+}
@@ -0,0 +1,160 @@
+package lru
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mapBackend is a Backend storing values in a map.
+type mapBackend struct {
+	mu     sync.Mutex
+	values map[string][]byte
+	ttls   map[string]time.Duration
+	err    error
+	// onDelete, if non-nil, is called by Delete before deleting.
+	onDelete func(key string)
+}
+
+func (b *mapBackend) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.err != nil {
+		return nil, false, b.err
+	}
+	value, ok := b.values[key]
+	return value, ok, nil
+}
+
+func (b *mapBackend) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.err != nil {
+		return b.err
+	}
+	b.values[key] = value
+	b.ttls[key] = ttl
+	return nil
+}
+
+func (b *mapBackend) Delete(ctx context.Context, key string) error {
+	if b.onDelete != nil {
+		b.onDelete(key)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.err != nil {
+		return b.err
+	}
+	delete(b.values, key)
+	return nil
+}
+
+func TestTiered(t *testing.T) {
+	local, err := New[string, int](2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	backend := &mapBackend{values: map[string][]byte{}, ttls: map[string]time.Duration{}}
+	l, err := NewTiered[string, int](local, backend, JSONCodec[int]{}, time.Hour)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	ctx := context.Background()
+
+	// adds write through
+	for i, key := range []string{"a", "b", "c"} {
+		if err := l.Add(ctx, key, i); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+	if string(backend.values["a"]) != "0" || backend.ttls["a"] != time.Hour {
+		t.Errorf("expected the add to be written through: %q, %v", backend.values["a"], backend.ttls["a"])
+	}
+	if local.Contains("a") {
+		t.Errorf("a should be evicted locally")
+	}
+
+	// misses fall back to the backend, and fill the local cache
+	if v, ok, err := l.Get(ctx, "a"); err != nil || !ok || v != 0 {
+		t.Errorf("bad Get: %v, %v, %v", v, ok, err)
+	}
+	if !local.Contains("a") {
+		t.Errorf("a should be cached locally")
+	}
+	if v, ok, err := l.Get(ctx, "a"); err != nil || !ok || v != 0 {
+		t.Errorf("bad Get: %v, %v, %v", v, ok, err)
+	}
+	if _, ok, err := l.Get(ctx, "missing"); err != nil || ok {
+		t.Errorf("expected a miss: %v, %v", ok, err)
+	}
+
+	// removes write through
+	if err := l.Remove(ctx, "a"); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if _, ok := backend.values["a"]; ok || local.Contains("a") {
+		t.Errorf("expected a to be removed from both tiers")
+	}
+
+	// backend errors are returned, and leave the local cache untouched
+	backend.err = errors.New("down")
+	if err := l.Add(ctx, "d", 3); err != backend.err || local.Contains("d") {
+		t.Errorf("expected the failed write not to be cached: %v", err)
+	}
+	if _, _, err := l.Get(ctx, "d"); err != backend.err {
+		t.Errorf("expected the backend error: %v", err)
+	}
+	if v, ok, err := l.Get(ctx, "c"); err != nil || !ok || v != 2 {
+		t.Errorf("local hits shouldn't need the backend: %v, %v, %v", v, ok, err)
+	}
+	backend.err = nil
+
+	// values that fail to decode are reported as errors
+	backend.values["e"] = []byte("nope")
+	if _, ok, err := l.Get(ctx, "e"); err == nil || ok {
+		t.Errorf("expected a decoding error: %v, %v", ok, err)
+	}
+
+	stats := l.Stats()
+	if stats.Local.Hits != 2 || stats.Local.Misses != 4 {
+		t.Errorf("bad local stats: %+v", stats.Local)
+	}
+	if stats.BackendHits != 2 || stats.BackendMisses != 1 || stats.BackendWrites != 5 || stats.BackendErrors != 3 {
+		t.Errorf("bad backend stats: %+v", stats)
+	}
+
+	if _, err := NewTiered[string, int](local, nil, nil, 0); err == nil {
+		t.Errorf("expected a missing backend to be rejected")
+	}
+}
+
+func TestTieredRemoveRefill(t *testing.T) {
+	local, err := New[string, int](2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	backend := &mapBackend{values: map[string][]byte{}, ttls: map[string]time.Duration{}}
+	l, err := NewTiered[string, int](local, backend, JSONCodec[int]{}, time.Hour)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	ctx := context.Background()
+	if err := l.Add(ctx, "a", 1); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	// a lookup racing the remove mustn't refill the local cache from the
+	// backend before the value is deleted there
+	backend.onDelete = func(key string) {
+		l.Get(ctx, key)
+	}
+	if err := l.Remove(ctx, "a"); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if local.Contains("a") {
+		t.Errorf("a was refilled from the backend during the remove")
+	}
+}
@@ -0,0 +1,199 @@
+package lru
+
+import (
+	"errors"
+	"hash/maphash"
+)
+
+// tinyLFU is a TinyLFU admission filter: it estimates how often keys are
+// used with a count-min sketch, so new keys are only admitted in place of
+// keys used less often.  A doorkeeper bloom filter absorbs the first use
+// of each key, so one-off keys in a scan never reach the sketch; and every
+// resetAt uses the counters are halved, so old popularity fades.
+type tinyLFU[K comparable] struct {
+	hash func(key K) uint64
+	// counters holds tinyLFURows rows of 4-bit saturating counters, stored
+	// one per byte.
+	counters []uint8
+	door     []uint64
+	mask     uint64
+	uses     int
+	resetAt  int
+}
+
+const (
+	tinyLFURows       = 4
+	tinyLFUMaxCounter = 15
+)
+
+var errTinyLFUSize = errors.New("must provide a positive size with TinyLFU")
+
+func newTinyLFU[K comparable](size int, hash func(key K) uint64) (*tinyLFU[K], error) {
+	if size <= 0 {
+		return nil, errTinyLFUSize
+	}
+	if hash == nil {
+		if hash = defaultKeyHash[K](); hash == nil {
+			return nil, errors.New("must provide a KeyHash with TinyLFU for this key type")
+		}
+	}
+	// a few counters per entry keep collisions down, and with uses
+	// reset every 10*size, the doorkeeper sees at most that many keys
+	width := uint64(64)
+	for width < 4*uint64(size) {
+		width <<= 1
+	}
+	return &tinyLFU[K]{
+		hash:     hash,
+		counters: make([]uint8, tinyLFURows*width),
+		door:     make([]uint64, width/8),
+		mask:     width - 1,
+		resetAt:  10 * size,
+	}, nil
+}
+
+// defaultKeyHash returns a hash function for the common key types, or nil
+// for others.
+func defaultKeyHash[K comparable]() func(key K) uint64 {
+	var zero K
+	switch any(zero).(type) {
+	case string:
+		seed := maphash.MakeSeed()
+		return func(key K) uint64 {
+			var h maphash.Hash
+			h.SetSeed(seed)
+			h.WriteString(any(key).(string))
+			return h.Sum64()
+		}
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, uintptr:
+		return func(key K) uint64 {
+			var n uint64
+			switch k := any(key).(type) {
+			case int:
+				n = uint64(k)
+			case int8:
+				n = uint64(k)
+			case int16:
+				n = uint64(k)
+			case int32:
+				n = uint64(k)
+			case int64:
+				n = uint64(k)
+			case uint:
+				n = uint64(k)
+			case uint8:
+				n = uint64(k)
+			case uint16:
+				n = uint64(k)
+			case uint32:
+				n = uint64(k)
+			case uint64:
+				n = k
+			case uintptr:
+				n = uint64(k)
+			}
+			return mix64(n)
+		}
+	}
+	return nil
+}
+
+// mix64 is the splitmix64 finalizer, spreading the bits of integer keys.
+func mix64(n uint64) uint64 {
+	n ^= n >> 30
+	n *= 0xbf58476d1ce4e5b9
+	n ^= n >> 27
+	n *= 0x94d049bb133111eb
+	n ^= n >> 31
+	return n
+}
+
+// slots returns the offsets of a hash's counters, one per row.
+func (t *tinyLFU[K]) slots(h uint64) (slots [tinyLFURows]uint64) {
+	width := t.mask + 1
+	for i := range slots {
+		// rehash for every row, so keys colliding in one row are
+		// unlikely to collide in the others
+		h = mix64(h + 0x9e3779b97f4a7c15)
+		slots[i] = uint64(i)*width + h&t.mask
+	}
+	return slots
+}
+
+// doorBits returns the two doorkeeper bits of a hash.
+func (t *tinyLFU[K]) doorBits(h uint64) [2]uint64 {
+	n := uint64(len(t.door)) * 64
+	return [2]uint64{h % n, (h >> 32) % n}
+}
+
+func (t *tinyLFU[K]) inDoor(h uint64) bool {
+	for _, bit := range t.doorBits(h) {
+		if t.door[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// used records a use of key.
+func (t *tinyLFU[K]) used(key K) {
+	h := t.hash(key)
+	if !t.inDoor(h) {
+		for _, bit := range t.doorBits(h) {
+			t.door[bit/64] |= 1 << (bit % 64)
+		}
+	} else {
+		// conservative update: only the smallest counters grow, which
+		// keeps overestimates from hash collisions down
+		slots := t.slots(h)
+		min := t.min(slots)
+		if min < tinyLFUMaxCounter {
+			for _, s := range slots {
+				if t.counters[s] == min {
+					t.counters[s]++
+				}
+			}
+		}
+	}
+
+	if t.uses++; t.uses >= t.resetAt {
+		t.reset()
+	}
+}
+
+func (t *tinyLFU[K]) min(slots [tinyLFURows]uint64) uint8 {
+	min := uint8(tinyLFUMaxCounter)
+	for _, s := range slots {
+		if t.counters[s] < min {
+			min = t.counters[s]
+		}
+	}
+	return min
+}
+
+// estimate returns the estimated number of recent uses of key.
+func (t *tinyLFU[K]) estimate(key K) int {
+	h := t.hash(key)
+	n := int(t.min(t.slots(h)))
+	if t.inDoor(h) {
+		n++
+	}
+	return n
+}
+
+// admit reports whether key should replace victim in the cache.
+func (t *tinyLFU[K]) admit(key, victim K) bool {
+	return t.estimate(key) > t.estimate(victim)
+}
+
+// reset ages the filter, halving every counter and clearing the
+// doorkeeper.
+func (t *tinyLFU[K]) reset() {
+	for i := range t.counters {
+		t.counters[i] >>= 1
+	}
+	for i := range t.door {
+		t.door[i] = 0
+	}
+	t.uses /= 2
+}
@@ -0,0 +1,90 @@
+package lru
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestLRUTinyLFU(t *testing.T) {
+	l, err := NewWithOptions[string, int](16, Options[string, int]{TinyLFU: true})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// a hot working set, used several times
+	for round := 0; round < 4; round++ {
+		for i := 0; i < 8; i++ {
+			k := "hot" + strconv.Itoa(i)
+			if _, ok := l.Get(k); !ok {
+				l.Add(k, i)
+			}
+		}
+	}
+	// a scan of one-off keys, while the hot keys stay in use
+	for i := 0; i < 1000; i++ {
+		k := "scan" + strconv.Itoa(i)
+		if _, ok := l.Get(k); !ok {
+			l.Add(k, i)
+		}
+		l.Get("hot" + strconv.Itoa(i%8))
+	}
+
+	// the sketch is approximate, so a scan key colliding with hot keys
+	// can occasionally displace one
+	survived := 0
+	for i := 0; i < 8; i++ {
+		if l.Contains("hot" + strconv.Itoa(i)) {
+			survived++
+		}
+	}
+	if survived < 7 {
+		t.Errorf("the hot keys should have survived the scan: %d of 8 did", survived)
+	}
+	if rejected := l.Stats().Rejected; rejected < 500 {
+		t.Errorf("expected much of the scan to be rejected: %d", rejected)
+	}
+
+	type point struct{ x, y int }
+	if _, err := NewWithOptions[point, int](16, Options[point, int]{TinyLFU: true}); err == nil {
+		t.Errorf("expected an error without a KeyHash for struct keys")
+	}
+	if _, err := NewWithOptions[string, int](0, Options[string, int]{TinyLFU: true}); err == nil {
+		t.Errorf("expected an error for an unbounded cache")
+	}
+	hashed, err := NewWithOptions[point, int](16, Options[point, int]{
+		TinyLFU: true,
+		KeyHash: func(p point) uint64 { return mix64(uint64(p.x)<<32 | uint64(p.y)) },
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	hashed.Add(point{1, 2}, 3)
+	if !hashed.Contains(point{1, 2}) {
+		t.Errorf("keys should be admitted while there is room")
+	}
+}
+
+func TestTinyLFUEstimate(t *testing.T) {
+	f, err := newTinyLFU[int](64, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		f.used(1)
+	}
+	f.used(2)
+	if n := f.estimate(1); n < 5 {
+		t.Errorf("bad estimate for 1: %d", n)
+	}
+	if n := f.estimate(2); n != 1 {
+		t.Errorf("a single use should only reach the doorkeeper: %d", n)
+	}
+	if !f.admit(1, 2) || f.admit(2, 1) {
+		t.Errorf("more frequently used keys should win")
+	}
+
+	f.reset()
+	if n := f.estimate(1); n != 2 {
+		t.Errorf("expected the counts to be halved: %d", n)
+	}
+}
@@ -0,0 +1,71 @@
+package lru
+
+import (
+	"time"
+)
+
+// minTombstoneSweep is the fewest tombstones kept before expired ones are
+// swept out.
+const minTombstoneSweep = 64
+
+// tombstones remembers recently removed keys, so that adds racing with
+// their removal can be dropped.
+type tombstones[K comparable] struct {
+	ttl     time.Duration
+	expires map[K]time.Time
+	sweepAt int
+}
+
+func newTombstones[K comparable](ttl time.Duration) *tombstones[K] {
+	return &tombstones[K]{
+		ttl:     ttl,
+		expires: make(map[K]time.Time),
+		sweepAt: minTombstoneSweep,
+	}
+}
+
+// bury leaves a tombstone for key.
+func (t *tombstones[K]) bury(key K) {
+	now := time.Now()
+	t.expires[key] = now.Add(t.ttl)
+
+	if len(t.expires) < t.sweepAt {
+		return
+	}
+	for k, expires := range t.expires {
+		if !now.Before(expires) {
+			delete(t.expires, k)
+		}
+	}
+	t.sweepAt = 2 * len(t.expires)
+	if t.sweepAt < minTombstoneSweep {
+		t.sweepAt = minTombstoneSweep
+	}
+}
+
+// buried reports whether key has a live tombstone.
+func (t *tombstones[K]) buried(key K) bool {
+	expires, ok := t.expires[key]
+	if !ok {
+		return false
+	}
+	if time.Now().Before(expires) {
+		return true
+	}
+	delete(t.expires, key)
+	return false
+}
+
+// ClearTombstone removes the tombstone left by removing key, if any, so it
+// can be added again right away.  Returns whether there was a live
+// tombstone.
+func (c *Cache[K, V]) ClearTombstone(key K) bool {
+	c.lock.Lock()
+	defer c.unlock()
+
+	if c.ext == nil || c.ext.tombstones == nil || !c.ext.tombstones.buried(key) {
+		return false
+	}
+	delete(c.ext.tombstones.expires, key)
+	return true
+}
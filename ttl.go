@@ -0,0 +1,380 @@
+package lru
+
+import (
+	"time"
+
+	"github.com/bpowers/approx-lru/internal/approxlru"
+)
+
+// ttlState tracks when entries with a time-to-live expire.  Entries without
+// one aren't tracked at all.
+type ttlState[K comparable] struct {
+	defaultTTL time.Duration
+	// expires holds the UnixNano time each entry expires at, and ttls
+	// the time-to-live of those added with one other than defaultTTL.
+	expires map[K]int64
+	ttls    map[K]time.Duration
+	now     func() time.Time
+	// deadlines counts the entries in expires by the second they expire
+	// in, for LenLive.
+	deadlines map[int64]int
+	// maxStaleness, if positive, is how long after expiring entries are
+	// still served in degraded mode.
+	maxStaleness time.Duration
+	// revalidateWindow, if positive, is how long after expiring entries
+	// are still served while being refreshed in the background.
+	revalidateWindow time.Duration
+	// degradedServes counts lookups served by expired entries.
+	degradedServes uint64
+	// stop is closed to shut down the reaper goroutine, if one is running.
+	stop chan struct{}
+	// reapInterval, reaps and lastReap describe the reaper, for Runtime.
+	reapInterval time.Duration
+	reaps        uint64
+	lastReap     time.Time
+}
+
+func newTTLState[K comparable](defaultTTL time.Duration) *ttlState[K] {
+	return &ttlState[K]{
+		defaultTTL: defaultTTL,
+		expires:    make(map[K]int64),
+		ttls:       make(map[K]time.Duration),
+		deadlines:  make(map[int64]int),
+		now:        time.Now,
+	}
+}
+
+// set makes key expire after ttl, or never if ttl isn't positive.
+func (t *ttlState[K]) set(key K, ttl time.Duration) {
+	t.forget(key)
+	if ttl <= 0 {
+		return
+	}
+	expires := t.now().Add(ttl).UnixNano()
+	t.expires[key] = expires
+	t.deadlines[t.deadline(expires)]++
+	if ttl != t.defaultTTL {
+		t.ttls[key] = ttl
+	}
+}
+
+// ttl returns the time-to-live key was last added with.  c.lock must be
+// held.
+func (t *ttlState[K]) ttl(key K) time.Duration {
+	if ttl, ok := t.ttls[key]; ok {
+		return ttl
+	}
+	return t.defaultTTL
+}
+
+// forget stops tracking when key expires.
+func (t *ttlState[K]) forget(key K) {
+	expires, ok := t.expires[key]
+	if !ok {
+		return
+	}
+	delete(t.expires, key)
+	delete(t.ttls, key)
+	d := t.deadline(expires)
+	if t.deadlines[d]--; t.deadlines[d] == 0 {
+		delete(t.deadlines, d)
+	}
+}
+
+// deadline returns the second an entry expiring at expires expires in.
+// Buckets don't include the window entries may be served as stale for,
+// which can change while they are in the cache.
+func (t *ttlState[K]) deadline(expires int64) int64 {
+	return expires / int64(time.Second)
+}
+
+// window returns how long after expiring entries may still be served.
+func (t *ttlState[K]) window() time.Duration {
+	if t.revalidateWindow > t.maxStaleness {
+		return t.revalidateWindow
+	}
+	return t.maxStaleness
+}
+
+// dead returns the number of entries that can no longer be served, to
+// within a second: entries whose deadline is in the current second aren't
+// counted.
+func (t *ttlState[K]) dead() (n int) {
+	cutoff := t.deadline(t.now().UnixNano() - int64(t.window()))
+	for d, count := range t.deadlines {
+		if d < cutoff {
+			n += count
+		}
+	}
+	return n
+}
+
+// expired reports whether key has outlived its time-to-live, and if so
+// whether it may still be served as stale in degraded mode.
+func (t *ttlState[K]) expired(key K) (expired, stale bool) {
+	expires, ok := t.expires[key]
+	if !ok {
+		return false, false
+	}
+	return t.expiredAt(expires, t.now().UnixNano())
+}
+
+func (t *ttlState[K]) expiredAt(expires, now int64) (expired, stale bool) {
+	if now < expires {
+		return false, false
+	}
+	window := t.window()
+	return true, window > 0 && now < expires+int64(window)
+}
+
+// ttlState returns the extension's TTL state, allocating it on first use.
+func (e *cacheExt[K, V]) ttlState() *ttlState[K] {
+	if e.ttl == nil {
+		e.ttl = newTTLState[K](0)
+	}
+	return e.ttl
+}
+
+// AddWithTTL adds a value to the cache that expires after ttl, overriding
+// the cache's default TTL.  A non-positive ttl means the entry never
+// expires.  Expired entries are removed lazily when next looked up, or by
+// the background reaper if Options.ReapInterval is set, and are passed to
+// the eviction callback with ReasonExpired.  Returns true if an eviction
+// occurred.
+func (c *Cache[K, V]) AddWithTTL(key K, value V, ttl time.Duration) (evicted bool) {
+	c.lock.Lock()
+	defer c.unlock()
+
+	t := c.extension().ttlState()
+	evicted = c.add(key, value)
+	// the add may have been dropped, e.g. by a tombstone
+	if c.lru.Contains(key) {
+		t.set(key, ttl)
+	}
+	return evicted
+}
+
+// expire removes key if it has outlived its time-to-live, returning whether
+// it was kept to be served as stale instead.  c.lock must be held.
+func (c *Cache[K, V]) expire(key K) (stale bool) {
+	if c.ext == nil || c.ext.ttl == nil {
+		return false
+	}
+	expired, stale := c.ext.ttl.expired(key)
+	if expired && !stale {
+		c.lru.RemoveWithReason(key, approxlru.ReasonExpired)
+		c.stats.expired(1)
+	}
+	return stale
+}
+
+// get looks up a key's value, expiring it first if needed.  c.lock must be
+// held.
+func (c *Cache[K, V]) get(key K) (value V, ok bool) {
+	if c.ext != nil && c.ext.admission != nil {
+		c.ext.admission.used(key)
+	}
+	stale := c.expire(key)
+	value, ok = c.lru.Get(key)
+	if ok && c.ext != nil && c.ext.validate != nil && c.ext.validate(key, value) != nil {
+		c.lru.RemoveWithReason(key, approxlru.ReasonInvalid)
+		c.stats.invalidated()
+		var zero V
+		value, ok, stale = zero, false, false
+	}
+	c.stats.lookup(ok)
+	if c.ext != nil && c.ext.cardinality != nil {
+		c.cardinality(key, ok)
+	}
+	if ok && stale {
+		if c.ext.ttl.maxStaleness > 0 {
+			c.ext.ttl.degradedServes++
+		}
+		if c.ext.revalidator != nil {
+			c.revalidate(key)
+		}
+	}
+	if e := c.ext; e != nil {
+		if ok && e.accuracy != nil {
+			e.accuracy.used(key)
+		}
+		if ok && e.accessed != nil {
+			e.accessed.touch(key)
+		}
+		if e.curve != nil {
+			e.curve.used(key)
+		}
+		if ok && e.onHit != nil {
+			e.onHit(key, value)
+		} else if !ok && e.onMiss != nil {
+			e.onMiss(key)
+		}
+	}
+	return value, ok
+}
+
+// ExpiredError is returned by GetNotStale for an entry that was in the
+// cache but has outlived its time-to-live.
+type ExpiredError struct {
+	// ExpiredAt is when the entry expired.
+	ExpiredAt time.Time
+}
+
+func (e *ExpiredError) Error() string {
+	return "lru: entry expired at " + e.ExpiredAt.Format(time.RFC3339Nano)
+}
+
+// GetNotStale looks up a key's value like Get, but never serves an expired
+// entry as if it were fresh.  It returns ErrNotFound if the key is missing,
+// and an *ExpiredError along with the expired value if it is present but
+// has outlived its time-to-live, so callers doing their own revalidation
+// can choose between recomputing the value and conditionally refreshing
+// it.  Expired entries are removed as by Get, unless degraded mode or a
+// revalidation window keeps them around to be served as stale.
+func (c *Cache[K, V]) GetNotStale(key K) (value V, err error) {
+	c.lock.Lock()
+	defer c.unlock()
+
+	if c.ext != nil && c.ext.ttl != nil {
+		if expires, ok := c.ext.ttl.expires[key]; ok {
+			if expired, _ := c.ext.ttl.expiredAt(expires, c.ext.ttl.now().UnixNano()); expired {
+				value, _ = c.lru.Peek(key)
+				c.expire(key)
+				c.stats.lookup(false)
+				return value, &ExpiredError{ExpiredAt: time.Unix(0, expires)}
+			}
+		}
+	}
+	value, ok := c.get(key)
+	if !ok {
+		return value, ErrNotFound
+	}
+	return value, nil
+}
+
+// peek looks up a key's value without updating its recent-ness, expiring it
+// first if needed.  c.lock must be held.
+func (c *Cache[K, V]) peek(key K) (value V, ok bool) {
+	stale := c.expire(key)
+	value, ok = c.lru.Peek(key)
+	if ok && stale && c.ext.ttl.maxStaleness > 0 {
+		c.ext.ttl.degradedServes++
+	}
+	return value, ok
+}
+
+// contains checks if a key is in the cache, expiring it first if needed.
+// c.lock must be held.
+func (c *Cache[K, V]) contains(key K) bool {
+	c.expire(key)
+	return c.lru.Contains(key)
+}
+
+// RemoveExpired removes every expired entry, returning the number removed.
+// It is O(n) in the number of entries with a time-to-live.
+func (c *Cache[K, V]) RemoveExpired() (removed int) {
+	c.lock.Lock()
+	defer c.unlock()
+
+	return c.removeExpired()
+}
+
+func (c *Cache[K, V]) removeExpired() (removed int) {
+	if c.ext == nil || c.ext.ttl == nil {
+		return 0
+	}
+	t := c.ext.ttl
+	now := t.now().UnixNano()
+	for key, expires := range t.expires {
+		if expired, stale := t.expiredAt(expires, now); expired && !stale {
+			// removing the entry deletes it from t.expires, which is
+			// safe while ranging over the map.
+			c.lru.RemoveWithReason(key, approxlru.ReasonExpired)
+			removed++
+		}
+	}
+	c.stats.expired(removed)
+	return removed
+}
+
+// SetDegraded switches degraded mode on or off.  While on, lookups keep
+// serving entries for up to maxStaleness after they expire, rather than
+// missing, for example to shed load from a struggling backend.  A
+// non-positive maxStaleness switches degraded mode off, after which stale
+// entries expire as usual.
+func (c *Cache[K, V]) SetDegraded(maxStaleness time.Duration) {
+	c.lock.Lock()
+	defer c.unlock()
+
+	if maxStaleness <= 0 && (c.ext == nil || c.ext.ttl == nil) {
+		return
+	}
+	c.extension().ttlState().maxStaleness = maxStaleness
+}
+
+// DegradedServes returns the number of lookups served by expired entries
+// in degraded mode.
+func (c *Cache[K, V]) DegradedServes() uint64 {
+	c.lock.Lock()
+	defer c.unlock()
+
+	if c.ext == nil || c.ext.ttl == nil {
+		return 0
+	}
+	return c.ext.ttl.degradedServes
+}
+
+// startReaper starts a goroutine removing expired entries every interval,
+// until Close is called.
+func (c *Cache[K, V]) startReaper(interval time.Duration) {
+	stop := make(chan struct{})
+	t := c.ext.ttlState()
+	t.stop = stop
+	t.reapInterval = interval
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				c.lock.Lock()
+				c.removeExpired()
+				c.flushExpirations()
+				t.reaps++
+				t.lastReap = t.now()
+				c.unlock()
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// Close stops the cache's background goroutines, if any, waiting for
+// queued eviction callbacks and mirrored changes to be handled first.  The
+// cache remains usable, but expired entries are then only removed lazily,
+// eviction callbacks run with the cache lock held, SetTargetSizeFunc
+// policies no longer resize it, and the Expirations channel is closed.
+func (c *Cache[K, V]) Close() {
+	c.lock.Lock()
+	if c.ext != nil && c.ext.ttl != nil && c.ext.ttl.stop != nil {
+		close(c.ext.ttl.stop)
+		c.ext.ttl.stop = nil
+	}
+	c.stopExpirations()
+	evictsDone := c.stopAsyncEvict()
+	mirrorDone := c.stopMirror()
+	autoSizeDone := c.stopAutoSize()
+	c.unlock()
+
+	if autoSizeDone != nil {
+		<-autoSizeDone
+	}
+
+	if evictsDone != nil {
+		<-evictsDone
+	}
+	if mirrorDone != nil {
+		<-mirrorDone
+	}
+}
@@ -0,0 +1,350 @@
+package lru
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestLRUTTL(t *testing.T) {
+	now := time.Unix(1000, 0)
+	var reasons []EvictReason
+	l, err := NewWithOptions[string, int](8, Options[string, int]{
+		OnEvictReason: func(k string, v int, reason EvictReason) {
+			reasons = append(reasons, reason)
+		},
+		TTL: time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.ext.ttl.now = func() time.Time { return now }
+
+	l.Add("1", 1)
+	l.AddWithTTL("2", 2, time.Hour)
+	l.AddWithTTL("3", 3, 0) // never expires
+
+	now = now.Add(2 * time.Minute)
+	if _, ok := l.Get("1"); ok {
+		t.Errorf("1 should have expired")
+	}
+	if len(reasons) != 1 || reasons[0] != ReasonExpired {
+		t.Errorf("expected 1 to be evicted as expired: %v", reasons)
+	}
+	if v, ok := l.Peek("2"); !ok || v != 2 {
+		t.Errorf("2 should outlive the default TTL: %v, %v", v, ok)
+	}
+
+	// re-adding resets the TTL
+	l.Add("1", 1)
+	now = now.Add(30 * time.Second)
+	l.Add("1", 1)
+	now = now.Add(45 * time.Second)
+	if !l.Contains("1") {
+		t.Errorf("re-adding 1 should have reset its TTL")
+	}
+
+	now = now.Add(2 * time.Hour)
+	if removed := l.RemoveExpired(); removed != 2 {
+		t.Errorf("expected 1 and 2 to have expired: %d", removed)
+	}
+	if l.Len() != 1 || !l.Contains("3") {
+		t.Errorf("only 3 should remain")
+	}
+	if len(l.ext.ttl.expires) != 0 {
+		t.Errorf("expiry times should have been cleaned up: %v", l.ext.ttl.expires)
+	}
+
+	l.Remove("3")
+	if reasons[len(reasons)-1] != ReasonRemoved {
+		t.Errorf("expected 3 to be removed: %v", reasons)
+	}
+}
+
+func TestLRUTTLReaper(t *testing.T) {
+	expired := make(chan string, 1)
+	l, err := NewWithOptions[string, int](8, Options[string, int]{
+		OnEvictReason: func(k string, v int, reason EvictReason) {
+			if reason == ReasonExpired {
+				expired <- k
+			}
+		},
+		ReapInterval: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer l.Close()
+
+	l.AddWithTTL("1", 1, time.Millisecond)
+	select {
+	case k := <-expired:
+		if k != "1" {
+			t.Errorf("unexpected expired key %q", k)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("reaper should have removed 1")
+	}
+	if l.Len() != 0 {
+		t.Errorf("bad len: %v", l.Len())
+	}
+}
+
+func TestLRUDegraded(t *testing.T) {
+	now := time.Unix(1000, 0)
+	l, err := NewWithOptions[string, int](8, Options[string, int]{
+		TTL:          time.Minute,
+		ReapInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer l.Close()
+	l.ext.ttl.now = func() time.Time { return now }
+
+	l.Add("1", 1)
+	l.Add("2", 2)
+	l.SetDegraded(time.Minute)
+
+	now = now.Add(90 * time.Second)
+	if removed := l.RemoveExpired(); removed != 0 {
+		t.Errorf("stale entries shouldn't be removed in degraded mode: %d", removed)
+	}
+	if v, ok := l.Get("1"); !ok || v != 1 {
+		t.Errorf("1 should be served stale: %v, %v", v, ok)
+	}
+	if v, ok := l.Peek("2"); !ok || v != 2 {
+		t.Errorf("2 should be served stale: %v, %v", v, ok)
+	}
+	if n := l.DegradedServes(); n != 2 {
+		t.Errorf("expected 2 degraded serves, not %d", n)
+	}
+
+	// past the max staleness entries expire even in degraded mode
+	now = now.Add(time.Minute)
+	if _, ok := l.Get("1"); ok {
+		t.Errorf("1 should have expired")
+	}
+
+	l.SetDegraded(0)
+	if _, ok := l.Get("2"); ok {
+		t.Errorf("2 should have expired")
+	}
+	if n := l.DegradedServes(); n != 2 {
+		t.Errorf("expected 2 degraded serves, not %d", n)
+	}
+}
+
+func TestLRURuntime(t *testing.T) {
+	l, err := NewWithOptions[string, int](8, Options[string, int]{
+		ReapInterval: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		report := l.Runtime()
+		if report.Goroutines != 1 || report.Reaper == nil || report.Reaper.Interval != time.Millisecond {
+			t.Fatalf("expected a running reaper: %+v", report)
+		}
+		if report.Reaper.Runs > 0 {
+			if report.Reaper.LastRun.IsZero() {
+				t.Errorf("expected the last run to be recorded: %+v", report.Reaper)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("the reaper should have run")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	l.Close()
+	if report := l.Runtime(); report.Goroutines != 0 || report.Reaper != nil {
+		t.Errorf("expected no goroutines after Close: %+v", report)
+	}
+	plain, _ := New[string, int](8)
+	if report := plain.Runtime(); report.Goroutines != 0 {
+		t.Errorf("plain caches run no goroutines: %+v", report)
+	}
+}
+
+func TestLRULenLive(t *testing.T) {
+	now := time.Unix(1000, 0)
+	l, err := NewWithOptions[string, int](8, Options[string, int]{TTL: time.Minute})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.ext.ttl.now = func() time.Time { return now }
+
+	l.Add("1", 1)
+	l.Add("2", 2)
+	l.AddWithTTL("3", 3, time.Hour)
+	l.AddWithTTL("4", 4, 0) // never expires
+	l.AddWithTTL("2", 2, 2*time.Hour)
+	if n := l.LenLive(); n != 4 {
+		t.Errorf("expected 4 live entries, not %d", n)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if n, live := l.Len(), l.LenLive(); n != 4 || live != 3 {
+		t.Errorf("expected 1 to be expired but not removed: len %d, live %d", n, live)
+	}
+	if stats := l.Stats(); stats.Live != 3 {
+		t.Errorf("bad live gauge: %+v", stats)
+	}
+	if keys, _ := l.KeysPage("", 0); len(keys) != 3 {
+		t.Errorf("expired keys shouldn't be paged: %v", keys)
+	}
+
+	// removing expired entries leaves the live count alone
+	l.RemoveExpired()
+	if n, live := l.Len(), l.LenLive(); n != 3 || live != 3 {
+		t.Errorf("bad len after removing expired entries: len %d, live %d", n, live)
+	}
+	l.Remove("2")
+	now = now.Add(2 * time.Hour)
+	if n, live := l.Len(), l.LenLive(); n != 2 || live != 1 {
+		t.Errorf("bad len: len %d, live %d", n, live)
+	}
+	if len(l.ext.ttl.deadlines) != 1 {
+		t.Errorf("removed entries' deadlines should be forgotten: %v", l.ext.ttl.deadlines)
+	}
+}
+
+func TestLRUGetNotStale(t *testing.T) {
+	now := time.Unix(1000, 0)
+	l, err := NewWithOptions[string, int](8, Options[string, int]{TTL: time.Minute})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.ext.ttl.now = func() time.Time { return now }
+
+	l.Add("1", 1)
+	l.Add("2", 2)
+	if v, err := l.GetNotStale("1"); err != nil || v != 1 {
+		t.Errorf("bad value: %v, %v", v, err)
+	}
+	if _, err := l.GetNotStale("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected a missing key to be not found: %v", err)
+	}
+
+	now = now.Add(2 * time.Minute)
+	v, err := l.GetNotStale("1")
+	var expired *ExpiredError
+	if !errors.As(err, &expired) || v != 1 {
+		t.Fatalf("expected 1 to be returned as expired: %v, %v", v, err)
+	}
+	if !expired.ExpiredAt.Equal(time.Unix(1060, 0)) {
+		t.Errorf("bad expiry time: %v", expired.ExpiredAt)
+	}
+	if l.Contains("1") {
+		t.Errorf("1 should have been removed")
+	}
+	if _, err := l.GetNotStale("1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected 1 to be missing once removed: %v", err)
+	}
+
+	// stale entries served in degraded mode still report their expiry
+	l.SetDegraded(time.Hour)
+	if v, err := l.GetNotStale("2"); !errors.As(err, &expired) || v != 2 {
+		t.Errorf("expected 2 to be returned as expired: %v, %v", v, err)
+	}
+	if v, ok := l.Get("2"); !ok || v != 2 {
+		t.Errorf("2 should still be served as stale: %v, %v", v, ok)
+	}
+}
+
+func TestLRUExpirations(t *testing.T) {
+	// rejected options don't leak the goroutines of the valid ones
+	goroutines := runtime.NumGoroutine()
+	for i := 0; i < 10; i++ {
+		mirror, _ := New[string, int](8)
+		_, err := NewWithOptions[string, int](8, Options[string, int]{
+			OnEvict:         func(string, int) {},
+			AsyncEvictQueue: 1,
+			Mirror:          mirror,
+			MirrorQueue:     1,
+			ExpirationQueue: 1,
+		})
+		if err == nil {
+			t.Fatalf("expected ExpirationQueue without ReapInterval to be rejected")
+		}
+	}
+	if n := runtime.NumGoroutine(); n > goroutines {
+		t.Errorf("leaked %d goroutines", n-goroutines)
+	}
+
+	l, err := NewWithOptions[string, int](8, Options[string, int]{
+		TTL:             time.Millisecond,
+		ReapInterval:    time.Millisecond,
+		ExpirationQueue: 4,
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add("1", 1)
+	l.Add("2", 2)
+	l.AddWithTTL("3", 3, 0) // never expires
+
+	got := make(map[string]int)
+	timeout := time.After(5 * time.Second)
+	for len(got) < 2 {
+		select {
+		case batch := <-l.Expirations():
+			for _, e := range batch {
+				if e.ExpiredAt.IsZero() || e.ExpiredAt.After(time.Now()) {
+					t.Errorf("bad expiry time for %s: %v", e.Key, e.ExpiredAt)
+				}
+				got[e.Key] = e.Value
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for expirations: %v", got)
+		}
+	}
+	if got["1"] != 1 || got["2"] != 2 || len(got) != 2 {
+		t.Errorf("bad expirations: %v", got)
+	}
+
+	l.Close()
+	if _, ok := <-l.Expirations(); ok {
+		t.Errorf("expected Close to close the channel")
+	}
+	if n := l.DroppedExpirations(); n != 0 {
+		t.Errorf("expected no dropped batches: %d", n)
+	}
+}
+
+func TestLRULenLiveDegraded(t *testing.T) {
+	now := time.Unix(1000, 0)
+	l, err := NewWithOptions[string, int](8, Options[string, int]{TTL: time.Minute})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.ext.ttl.now = func() time.Time { return now }
+
+	l.Add("1", 1)
+	l.Add("2", 2)
+	// changing the stale window leaves the entries' buckets alone
+	l.SetDegraded(time.Hour)
+	l.Remove("1")
+	if n, live := l.Len(), l.LenLive(); n != 1 || live != 1 {
+		t.Errorf("bad len: len %d, live %d", n, live)
+	}
+	if len(l.ext.ttl.deadlines) != 1 {
+		t.Errorf("removed entries' deadlines should be forgotten: %v", l.ext.ttl.deadlines)
+	}
+
+	// expired entries are live while they may be served as stale
+	now = now.Add(30 * time.Minute)
+	if live := l.LenLive(); live != 1 {
+		t.Errorf("2 should still be servable: %d", live)
+	}
+	l.SetDegraded(0)
+	if live := l.LenLive(); live != 0 {
+		t.Errorf("2 should be dead once degraded mode is off: %d", live)
+	}
+}
@@ -0,0 +1,213 @@
+package lru
+
+import (
+	"errors"
+
+	"github.com/bpowers/approx-lru/internal/approxlru"
+)
+
+const (
+	// Default2QRecentRatio is the ratio of the 2Q cache dedicated to
+	// recently added entries that have only been accessed once.
+	Default2QRecentRatio = 0.25
+
+	// Default2QGhostEntries is the default ratio of ghost entries kept to
+	// track entries recently evicted.
+	Default2QGhostEntries = 0.50
+)
+
+// TwoQueueCache is a thread-safe fixed size 2Q cache.  2Q is an enhancement
+// over the standard LRU cache in that it tracks both frequently and
+// recently used entries separately.  This avoids a burst in access to new
+// entries from evicting frequently used entries, such as during a bulk
+// scan.  It keeps track of keys recently evicted from the recent queue as
+// ghost entries, so a key added back soon after is promoted to the frequent
+// queue.  Like Cache, each queue is an approximate LRU.
+type TwoQueueCache[K comparable, V any] struct {
+	lock       mutex
+	size       int
+	recentSize int
+
+	recent      approxlru.LRU[K, V]
+	frequent    approxlru.LRU[K, V]
+	recentEvict approxlru.LRU[K, struct{}]
+}
+
+// New2Q creates a new TwoQueueCache using the default values for the
+// parameters.
+func New2Q[K comparable, V any](size int) (*TwoQueueCache[K, V], error) {
+	return New2QParams[K, V](size, Default2QRecentRatio, Default2QGhostEntries)
+}
+
+// New2QParams creates a new TwoQueueCache using the provided parameter
+// values.  recentRatio is the fraction of size dedicated to recently added
+// entries, and ghostRatio the fraction of size kept as ghost entries.
+func New2QParams[K comparable, V any](size int, recentRatio, ghostRatio float64) (*TwoQueueCache[K, V], error) {
+	if size <= 0 {
+		return nil, errors.New("invalid size")
+	}
+	if recentRatio < 0.0 || recentRatio > 1.0 {
+		return nil, errors.New("invalid recent ratio")
+	}
+	if ghostRatio < 0.0 || ghostRatio > 1.0 {
+		return nil, errors.New("invalid ghost ratio")
+	}
+
+	recentSize := int(float64(size) * recentRatio)
+	evictSize := int(float64(size) * ghostRatio)
+	// a zero-sized approxlru is unbounded, so always keep at least one
+	// ghost entry.
+	if evictSize < 1 {
+		evictSize = 1
+	}
+
+	recent, err := approxlru.NewLRU[K, V](size, nil)
+	if err != nil {
+		return nil, err
+	}
+	frequent, err := approxlru.NewLRU[K, V](size, nil)
+	if err != nil {
+		return nil, err
+	}
+	recentEvict, err := approxlru.NewLRU[K, struct{}](evictSize, nil)
+	if err != nil {
+		return nil, err
+	}
+	c := &TwoQueueCache[K, V]{
+		size:        size,
+		recentSize:  recentSize,
+		recent:      *recent,
+		frequent:    *frequent,
+		recentEvict: *recentEvict,
+	}
+	return c, nil
+}
+
+// Get looks up a key's value from the cache.
+func (c *TwoQueueCache[K, V]) Get(key K) (value V, ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	// Check if this is a frequent value
+	if value, ok = c.frequent.Get(key); ok {
+		return value, ok
+	}
+
+	// If the value is contained in recent, then we promote it to frequent
+	if value, ok = c.recent.RemoveAndGet(key); ok {
+		c.frequent.Add(key, value)
+		return value, ok
+	}
+	return value, false
+}
+
+// Add adds a value to the cache.  Returns true if an eviction occurred.
+func (c *TwoQueueCache[K, V]) Add(key K, value V) (evicted bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	// Check if the value is frequently used already, and just update the
+	// value
+	if c.frequent.Contains(key) {
+		c.frequent.Add(key, value)
+		return false
+	}
+
+	// Check if the value is recently used, and promote the value into the
+	// frequent list
+	if c.recent.Contains(key) {
+		c.recent.Remove(key)
+		c.frequent.Add(key, value)
+		return false
+	}
+
+	// If the value was recently evicted, add it to the frequently used
+	// list
+	if c.recentEvict.Contains(key) {
+		evicted = c.ensureSpace(true)
+		c.recentEvict.Remove(key)
+		c.frequent.Add(key, value)
+		return evicted
+	}
+
+	// Add to the recently seen list
+	evicted = c.ensureSpace(false)
+	c.recent.Add(key, value)
+	return evicted
+}
+
+// ensureSpace is used to ensure we have space in the cache, returning
+// whether an entry was evicted.  c.lock must be held.
+func (c *TwoQueueCache[K, V]) ensureSpace(recentEvict bool) bool {
+	// If we have space, nothing to do
+	recentLen := c.recent.Len()
+	freqLen := c.frequent.Len()
+	if recentLen+freqLen < c.size {
+		return false
+	}
+
+	// If the recent buffer is larger than the target, evict from there
+	if recentLen > 0 && (recentLen > c.recentSize || (recentLen == c.recentSize && !recentEvict)) {
+		key, _, _ := c.recent.RemoveOldest()
+		c.recentEvict.Add(key, struct{}{})
+		return true
+	}
+
+	// Remove from the frequent list otherwise
+	_, _, ok := c.frequent.RemoveOldest()
+	return ok
+}
+
+// Contains checks if a key is in the cache, without updating the
+// recent-ness.
+func (c *TwoQueueCache[K, V]) Contains(key K) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.frequent.Contains(key) || c.recent.Contains(key)
+}
+
+// Peek returns the key value (or undefined if not found) without updating
+// the "recently used"-ness of the key.
+func (c *TwoQueueCache[K, V]) Peek(key K) (value V, ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if value, ok = c.frequent.Peek(key); ok {
+		return value, ok
+	}
+	return c.recent.Peek(key)
+}
+
+// Remove removes the provided key from the cache.
+func (c *TwoQueueCache[K, V]) Remove(key K) (present bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.frequent.Remove(key) {
+		return true
+	}
+	if c.recent.Remove(key) {
+		return true
+	}
+	c.recentEvict.Remove(key)
+	return false
+}
+
+// Purge is used to completely clear the cache.
+func (c *TwoQueueCache[K, V]) Purge() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.recent.Purge()
+	c.frequent.Purge()
+	c.recentEvict.Purge()
+}
+
+// Len returns the number of items in the cache.
+func (c *TwoQueueCache[K, V]) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.recent.Len() + c.frequent.Len()
+}
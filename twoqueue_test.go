@@ -0,0 +1,125 @@
+package lru
+
+import (
+	"testing"
+)
+
+func Test2Q(t *testing.T) {
+	l, err := New2Q[int, int](128)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for i := 0; i < 256; i++ {
+		l.Add(i, i)
+	}
+	if l.Len() != 128 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+	for i := 0; i < 256; i++ {
+		if v, ok := l.Peek(i); ok && v != i {
+			t.Fatalf("bad key %v: %v", i, v)
+		}
+	}
+
+	l.Purge()
+	if l.Len() != 0 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+	if _, ok := l.Get(200); ok {
+		t.Fatalf("should contain nothing")
+	}
+
+	if _, err := New2Q[int, int](0); err == nil {
+		t.Errorf("expected zero sizes to be rejected")
+	}
+	if _, err := New2QParams[int, int](8, 1.5, 0.5); err == nil {
+		t.Errorf("expected invalid ratios to be rejected")
+	}
+}
+
+// Test that entries move from the recent to the frequent queue
+func Test2Q_Promotion(t *testing.T) {
+	l, err := New2Q[int, int](128)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for i := 0; i < 128; i++ {
+		l.Add(i, i)
+	}
+	if n := l.recent.Len(); n != 128 {
+		t.Fatalf("bad: %d", n)
+	}
+	if n := l.frequent.Len(); n != 0 {
+		t.Fatalf("bad: %d", n)
+	}
+
+	// Get moves entries to frequent
+	for i := 0; i < 32; i++ {
+		if v, ok := l.Get(i); !ok || v != i {
+			t.Fatalf("bad key %v: %v, %v", i, v, ok)
+		}
+	}
+	// so does adding again
+	for i := 32; i < 64; i++ {
+		l.Add(i, i)
+	}
+	if n := l.recent.Len(); n != 64 {
+		t.Fatalf("bad: %d", n)
+	}
+	if n := l.frequent.Len(); n != 64 {
+		t.Fatalf("bad: %d", n)
+	}
+}
+
+// Test that a scan doesn't flush the frequently used entries
+func Test2Q_ScanResistance(t *testing.T) {
+	l, err := New2Q[int, int](128)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for i := 0; i < 64; i++ {
+		l.Add(i, i)
+		l.Get(i)
+	}
+	for i := 1000; i < 2000; i++ {
+		l.Add(i, i)
+	}
+	for i := 0; i < 64; i++ {
+		if !l.Contains(i) {
+			t.Fatalf("frequently used key %d was flushed by the scan", i)
+		}
+	}
+	if l.Len() != 128 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+}
+
+// Test that recently evicted keys are promoted when added back
+func Test2Q_Ghost(t *testing.T) {
+	l, err := New2Q[int, int](4)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		l.Add(i, i)
+	}
+	// an approximate LRU only probes 8 entries, so with 4 this is exact
+	if l.Contains(0) || !l.recentEvict.Contains(0) {
+		t.Fatalf("expected 0 to be evicted to the ghost queue")
+	}
+	l.Add(0, 0)
+	if !l.frequent.Contains(0) || l.recentEvict.Contains(0) {
+		t.Errorf("expected 0 to be promoted to the frequent queue")
+	}
+	if l.Len() != 4 {
+		t.Errorf("bad len: %v", l.Len())
+	}
+
+	if !l.Remove(0) || l.Contains(0) {
+		t.Errorf("0 should have been removed")
+	}
+}
@@ -0,0 +1,28 @@
+package lru
+
+// AddIfNewer adds a value to the cache unless the key is already present at
+// the same or a newer version, so that out-of-order updates from several
+// producers can't overwrite fresher data.  version is any increasing
+// number, such as a sequence number or a UnixNano timestamp.  Adds that
+// don't go through AddIfNewer leave the recorded version unchanged, and it
+// is forgotten once the entry leaves the cache.  Returns whether the value
+// was added and whether an eviction occurred.
+func (c *Cache[K, V]) AddIfNewer(key K, value V, version int64) (added, evicted bool) {
+	c.lock.Lock()
+	defer c.unlock()
+
+	e := c.extension()
+	if e.versions == nil {
+		e.versions = make(map[K]int64)
+	}
+	if current, ok := e.versions[key]; ok && current >= version && c.contains(key) {
+		return false, false
+	}
+	evicted = c.add(key, value)
+	// the add may have been dropped, e.g. by a tombstone
+	if !c.lru.Contains(key) {
+		return false, evicted
+	}
+	e.versions[key] = version
+	return true, evicted
+}
@@ -0,0 +1,44 @@
+package lru
+
+import (
+	"testing"
+)
+
+func TestLRUAddIfNewer(t *testing.T) {
+	l, err := New[string, string](2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if added, _ := l.AddIfNewer("1", "v2", 2); !added {
+		t.Errorf("absent keys should be added")
+	}
+	if added, _ := l.AddIfNewer("1", "v1", 1); added {
+		t.Errorf("older versions shouldn't be added")
+	}
+	if added, _ := l.AddIfNewer("1", "v2'", 2); added {
+		t.Errorf("the same version shouldn't be added")
+	}
+	if v, _ := l.Peek("1"); v != "v2" {
+		t.Errorf("bad value: %q", v)
+	}
+	if added, _ := l.AddIfNewer("1", "v3", 3); !added {
+		t.Errorf("newer versions should be added")
+	}
+	if v, _ := l.Peek("1"); v != "v3" {
+		t.Errorf("bad value: %q", v)
+	}
+
+	// versions are forgotten along with their entries
+	l.Remove("1")
+	if added, _ := l.AddIfNewer("1", "v1", 1); !added {
+		t.Errorf("removed keys should be added at any version")
+	}
+	l.Add("2", "")
+	if _, evicted := l.AddIfNewer("3", "", 1); !evicted {
+		t.Errorf("should have an eviction")
+	}
+	if len(l.ext.versions) != 1 {
+		t.Errorf("evicted keys' versions should be forgotten: %v", l.ext.versions)
+	}
+}